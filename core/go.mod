@@ -23,6 +23,7 @@ require (
 	github.com/contenox/contenox/libs/libbus v0.0.0-00010101000000-000000000000
 	github.com/contenox/contenox/libs/libcipher v0.0.0-00010101000000-000000000000
 	github.com/contenox/contenox/libs/libdb v0.0.0-00010101000000-000000000000
+	github.com/contenox/contenox/libs/libkv v0.0.0-00010101000000-000000000000
 	github.com/contenox/contenox/libs/libroutine v0.0.0-00010101000000-000000000000
 	github.com/contenox/contenox/libs/libtestenv v0.0.0-00010101000000-000000000000
 	github.com/google/uuid v1.6.0
@@ -79,6 +80,7 @@ require (
 	github.com/sirupsen/logrus v1.9.3 // indirect
 	github.com/testcontainers/testcontainers-go/modules/nats v0.36.0 // indirect
 	github.com/testcontainers/testcontainers-go/modules/postgres v0.36.0 // indirect
+	github.com/testcontainers/testcontainers-go/modules/valkey v0.36.0 // indirect
 	github.com/tklauser/go-sysconf v0.3.15 // indirect
 	github.com/tklauser/numcpus v0.10.0 // indirect
 	github.com/yusufpapurcu/wmi v1.2.4 // indirect
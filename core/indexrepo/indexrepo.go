@@ -83,6 +83,10 @@ func ExecuteVectorSearch(
 			if err != nil {
 				return nil, fmt.Errorf("failed to get chunk index: %w", err)
 			}
+			// Trigger vectors share the store but are not searchable content.
+			if chunkIndex.ResourceType == store.ResourceTypeTrigger {
+				continue
+			}
 
 			searchResults = append(searchResults, SearchResult{
 				ID:           chunkIndex.ResourceID,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/contenox/contenox/core/modelprovider"
 	"github.com/contenox/contenox/core/runtimestate"
@@ -94,13 +95,13 @@ type modelManager struct {
 
 // GetRuntime implements Embedder.
 func (e *modelManager) GetRuntime(ctx context.Context) modelprovider.RuntimeState {
-	provider, err := e.GetProvider(ctx)
+	provider, providerType, err := e.provider(ctx)
 
 	return func(ctx context.Context, backendType string) ([]modelprovider.Provider, error) {
 		if err != nil {
 			return nil, err
 		}
-		if backendType != "Ollama" && backendType != "" {
+		if backendType != "" && !strings.EqualFold(backendType, providerType) {
 			return nil, fmt.Errorf("unsupported backend-type")
 		}
 		return []modelprovider.Provider{provider}, nil
@@ -108,33 +109,57 @@ func (e *modelManager) GetRuntime(ctx context.Context) modelprovider.RuntimeStat
 }
 
 func (e *modelManager) GetProvider(ctx context.Context) (modelprovider.Provider, error) {
-	backends := map[string]store.Backend{}
+	provider, _, err := e.provider(ctx)
+	return provider, err
+}
+
+// provider returns the provider of the model and its backend type. If the model is
+// pulled to backends of several types in the pool, the Ollama backends are used.
+func (e *modelManager) provider(ctx context.Context) (modelprovider.Provider, string, error) {
+	backends := map[string]map[string]store.Backend{}
 
 	for _, v := range e.runtime.Get(ctx) {
 		ok, err := e.backendIsInPool(ctx, v.Backend)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		if !ok {
 			continue
 		}
 		for _, lmr := range v.PulledModels {
 			if lmr.Model == e.model.Model {
-				backends[v.Backend.BaseURL] = v.Backend
+				if backends[v.Backend.Type] == nil {
+					backends[v.Backend.Type] = map[string]store.Backend{}
+				}
+				backends[v.Backend.Type][v.Backend.BaseURL] = v.Backend
 			}
 		}
 	}
+	backendType := store.BackendTypeOllama
+	if len(backends[backendType]) == 0 {
+		backendType = store.BackendTypeOpenAI
+	}
 	var results []string
-	for _, backend := range backends {
+	apiKeys := map[string]string{}
+	for _, backend := range backends[backendType] {
 		results = append(results, backend.BaseURL)
+		if backend.APIKey != "" {
+			apiKeys[backend.BaseURL] = backend.APIKey
+		}
 	}
 	if len(results) == 0 {
-		return nil, errors.New("no backends found")
+		return nil, "", errors.New("no backends found")
+	}
+	if backendType == store.BackendTypeOpenAI {
+		provider := modelprovider.NewOpenAIModelProvider(e.model.Model, results,
+			modelprovider.WithAPIKeys(apiKeys),
+			modelprovider.WithCapabilities(!e.embed, e.embed, e.prompt, !e.embed))
+		return provider, backendType, nil
 	}
 	provider := modelprovider.NewOllamaModelProvider(e.model.Model, results,
 		modelprovider.WithEmbed(e.embed),
 		modelprovider.WithPrompt(e.prompt))
-	return provider, nil
+	return provider, backendType, nil
 }
 
 func (e *modelManager) backendIsInPool(ctx context.Context, backendToVerify store.Backend) (bool, error) {
@@ -20,7 +20,7 @@ var (
 
 // Request contains requirements for selecting a model provider.
 type Request struct {
-	Provider      string   // Optional: if empty, uses default provider
+	Provider      string   // Optional: backend type like "Ollama" or "OpenAI", if empty, any type is considered
 	ModelNames    []string // Optional: if empty, any model is considered
 	ContextLength int      // Minimum required context length; 0 means no requirement
 }
@@ -31,10 +31,7 @@ func filterCandidates(
 	getModels modelprovider.RuntimeState,
 	capCheck func(modelprovider.Provider) bool,
 ) ([]modelprovider.Provider, error) {
-	providerType := req.Provider
-	if providerType == "" {
-		providerType = "Ollama" // Default provider
-	}
+	providerType := req.Provider // empty considers the models of all backend types
 
 	providers, err := getModels(ctx, providerType)
 	if err != nil {
@@ -224,7 +221,7 @@ func Chat(
 
 type EmbedRequest struct {
 	ModelName string
-	Provider  string // Optional. Empty uses default.
+	Provider  string // Optional. Empty considers any backend type.
 }
 
 // Embed finds a provider supporting embeddings
@@ -272,7 +269,14 @@ func Stream(
 
 type PromptRequest struct {
 	ModelName string
-	Provider  string // Optional. Empty uses default.
+	Provider  string // Optional. Empty considers any backend type.
+
+	// PreferredModels are tried in order before ModelName, which becomes the fallback.
+	// The resolver only chooses among the backends of the first model that is available.
+	PreferredModels []string
+
+	// Options are applied to every prompt sent through the resolved client.
+	Options []serverops.PromptOption
 }
 
 func PromptExecute(
@@ -281,14 +285,25 @@ func PromptExecute(
 	getModels modelprovider.RuntimeState,
 	resolver Policy,
 ) (serverops.LLMPromptExecClient, error) {
-	if reqExec.ModelName == "" {
+	modelNames := reqExec.PreferredModels
+	if reqExec.ModelName != "" {
+		modelNames = append(modelNames[:len(modelNames):len(modelNames)], reqExec.ModelName)
+	}
+	if len(modelNames) == 0 {
 		return nil, fmt.Errorf("model name is required")
 	}
-	req := Request{
-		ModelNames: []string{reqExec.ModelName},
-		Provider:   reqExec.Provider,
+	var candidates []modelprovider.Provider
+	var err error
+	for _, modelName := range modelNames {
+		req := Request{
+			ModelNames: []string{modelName},
+			Provider:   reqExec.Provider,
+		}
+		candidates, err = filterCandidates(ctx, req, getModels, modelprovider.Provider.CanPrompt)
+		if err == nil || !errors.Is(err, ErrNoSatisfactoryModel) {
+			break
+		}
 	}
-	candidates, err := filterCandidates(ctx, req, getModels, modelprovider.Provider.CanPrompt)
 	if err != nil {
 		return nil, err
 	}
@@ -296,5 +311,20 @@ func PromptExecute(
 	if err != nil {
 		return nil, err
 	}
-	return provider.GetPromptConnection(backend)
+	client, err := provider.GetPromptConnection(backend)
+	if err != nil || len(reqExec.Options) == 0 {
+		return client, err
+	}
+	return &promptClientWithOptions{client: client, options: reqExec.Options}, nil
+}
+
+// promptClientWithOptions applies the options of a PromptRequest before the options of each call.
+type promptClientWithOptions struct {
+	client  serverops.LLMPromptExecClient
+	options []serverops.PromptOption
+}
+
+func (c *promptClientWithOptions) Prompt(ctx context.Context, prompt string, opts ...serverops.PromptOption) (string, error) {
+	all := append(append([]serverops.PromptOption{}, c.options...), opts...)
+	return c.client.Prompt(ctx, prompt, all...)
 }
@@ -10,15 +10,22 @@ import (
 	"time"
 
 	"github.com/contenox/contenox/core/llmrepo"
+	"github.com/contenox/contenox/core/modelprovider"
 	"github.com/contenox/contenox/core/runtimestate"
 	"github.com/contenox/contenox/core/serverapi"
 	"github.com/contenox/contenox/core/serverops"
 	"github.com/contenox/contenox/core/serverops/store"
 	"github.com/contenox/contenox/core/serverops/vectors"
+	"github.com/contenox/contenox/core/services/backendservice"
+	"github.com/contenox/contenox/core/services/chainservice"
+	"github.com/contenox/contenox/core/services/execservice"
+	"github.com/contenox/contenox/core/services/secretservice"
+	"github.com/contenox/contenox/core/services/tokenizerservice"
 	"github.com/contenox/contenox/core/taskengine"
 	"github.com/contenox/contenox/core/taskengine/hooks"
 	"github.com/contenox/contenox/libs/libbus"
 	"github.com/contenox/contenox/libs/libdb"
+	"github.com/contenox/contenox/libs/libkv"
 	"github.com/contenox/contenox/libs/libroutine"
 )
 
@@ -59,6 +66,15 @@ func initPubSub(ctx context.Context, cfg *serverops.Config) (libbus.Messenger, e
 	return ps, nil
 }
 
+// initResponseCache opens the bucket holding the cached prompt responses of executions.
+func initResponseCache(cfg *serverops.Config) (libkv.KVManager, error) {
+	opts := []libkv.NatsOption{libkv.WithBucketTTL(execservice.ResponseCacheMaxTTL)}
+	if cfg.NATSUser != "" {
+		opts = append(opts, libkv.WithNatsUserInfo(cfg.NATSUser, cfg.NATSPassword))
+	}
+	return libkv.NewNatsKVManager(cfg.NATSURL, execservice.ResponseCacheBucket, true, opts...)
+}
+
 func main() {
 	serverops.DefaultAdminUser = cliSetAdminUser
 	if serverops.DefaultAdminUser == "" {
@@ -102,7 +118,11 @@ func main() {
 	if err != nil {
 		log.Fatalf("initializing OpenSearch failed: %v", err)
 	}
-	state, err := runtimestate.New(ctx, dbInstance, ps, runtimestate.WithPools())
+	apiKeys, err := backendservice.NewAPIKeyCipher(config.EncryptionKey)
+	if err != nil {
+		log.Fatalf("initializing api key encryption failed: %v", err)
+	}
+	state, err := runtimestate.New(ctx, dbInstance, ps, runtimestate.WithPools(), runtimestate.WithAPIKeys(apiKeys))
 	if err != nil {
 		log.Fatalf("initializing runtime state failed: %v", err)
 	}
@@ -131,16 +151,45 @@ func main() {
 		"rag":     rag,
 		"webhook": webcall,
 	})
-	exec, err := taskengine.NewExec(ctx, execRepo, hookrepo)
+	responseCache, err := initResponseCache(config)
+	if err != nil {
+		log.Fatalf("initializing response cache failed: %v", err)
+	}
+	cleanups = append(cleanups, responseCache.Close)
+	exec, err := taskengine.NewExec(ctx, execRepo, hookrepo,
+		taskengine.WithModelRuntime(func(ctx context.Context) modelprovider.RuntimeState {
+			return modelprovider.ModelProviderAdapter(ctx, state.Get(ctx))
+		}),
+		taskengine.WithResponseCache(execservice.NewResponseStore(responseCache)),
+	)
 	if err != nil {
 		log.Fatalf("initializing task engine engine failed: %v", err)
 	}
-	environmentExec, err := taskengine.NewEnv(ctx, serverops.NoopTracker{}, exec)
+	tokenizer, closeTokenizer, err := tokenizerservice.NewGRPCTokenizer(ctx, tokenizerservice.ConfigGRPC{
+		ServerAddress: config.TokenizerServiceURL,
+	})
+	if err != nil {
+		log.Fatalf("initializing tokenizer failed: %v", err)
+	}
+	cleanups = append(cleanups, closeTokenizer)
+	secrets, err := secretservice.NewResolver(dbInstance, config.EncryptionKey)
+	if err != nil {
+		log.Fatalf("initializing secrets failed: %v", err)
+	}
+	environmentExec, err := taskengine.NewEnv(ctx, execservice.NewTraceTracker(dbInstance), exec,
+		taskengine.WithCheckpointer(execservice.NewCheckpointer(dbInstance)),
+		taskengine.WithApprovalGate(execservice.NewApprovalGate(dbInstance, ps)),
+		taskengine.WithPrinter(execservice.NewPrinter(ps)),
+		taskengine.WithHookQueue(execservice.NewHookQueue(dbInstance)),
+		taskengine.WithChainResolver(chainservice.NewChainResolver(dbInstance)),
+		taskengine.WithSecrets(secrets),
+		taskengine.WithTokenizer(tokenizer, config.TasksModel),
+	)
 	if err != nil {
 		log.Fatalf("initializing task engine failed: %v", err)
 	}
 	cleanups = append(cleanups, cleanup)
-	apiHandler, cleanup, err := serverapi.New(ctx, config, dbInstance, ps, embedder, execRepo, environmentExec, state, vectorStore, hookrepo)
+	apiHandler, cleanup, err := serverapi.New(ctx, config, dbInstance, ps, embedder, execRepo, environmentExec, state, vectorStore, hookrepo, tokenizer, apiKeys, secrets)
 	cleanups = append(cleanups, cleanup)
 	if err != nil {
 		log.Fatalf("initializing API handler failed: %v", err)
@@ -2,31 +2,48 @@ package modelprovider
 
 import (
 	"context"
+	"strings"
 
 	"github.com/contenox/contenox/core/runtimestate"
+	"github.com/contenox/contenox/core/serverops/store"
 )
 
 // RuntimeState retrieves available model providers for a specific backend type
 type RuntimeState func(ctx context.Context, backendType string) ([]Provider, error)
 
+// ModelProviderAdapter returns the providers of the models pulled to the backends in runtime.
+// The returned RuntimeState returns the providers of all backend types if backendType is empty.
 func ModelProviderAdapter(ctx context.Context, runtime map[string]runtimestate.LLMState) RuntimeState {
-	models := make(map[string][]string)
+	ollamaModels := make(map[string][]string)
+	openAIModels := make(map[string][]string)
+	apiKeys := make(map[string]string)
 	for _, state := range runtime {
+		models := ollamaModels
+		if state.Backend.Type == store.BackendTypeOpenAI {
+			models = openAIModels
+			if state.Backend.APIKey != "" {
+				apiKeys[state.Backend.BaseURL] = state.Backend.APIKey
+			}
+		}
 		for _, model := range state.PulledModels {
 			models[model.Model] = append(models[model.Model], state.Backend.BaseURL)
 		}
 	}
-	res := []Provider{}
-	for model, backends := range models {
+	res := map[string][]Provider{}
+	for model, backends := range ollamaModels {
 		provider := NewOllamaModelProvider(model, backends)
-		res = append(res, provider)
+		res[store.BackendTypeOllama] = append(res[store.BackendTypeOllama], provider)
+	}
+	for model, backends := range openAIModels {
+		provider := NewOpenAIModelProvider(model, backends, WithAPIKeys(apiKeys))
+		res[store.BackendTypeOpenAI] = append(res[store.BackendTypeOpenAI], provider)
 	}
 	return func(ctx context.Context, backendType string) ([]Provider, error) {
 		var providers []Provider
-		for _, provider := range res {
-			// if provider.BackendType() == backendType { // TODO: Implement backend type filtering
-			providers = append(providers, provider)
-			// }
+		for providerType, typeProviders := range res {
+			if backendType == "" || strings.EqualFold(providerType, backendType) {
+				providers = append(providers, typeProviders...)
+			}
 		}
 		return providers, nil
 	}
@@ -2,6 +2,7 @@ package modelprovider
 
 import (
 	"context"
+	"sync"
 
 	"github.com/contenox/contenox/core/serverops"
 )
@@ -18,6 +19,20 @@ type MockProvider struct {
 	CanEmbedFlag  bool
 	CanPromptFlag bool
 	CanStreamFlag bool
+
+	// PromptResponses, if set, are returned by the prompt client in order.
+	// Once exhausted, the client falls back to echoing the prompt.
+	PromptResponses []string
+	// Prompts records every prompt received by the prompt client.
+	Prompts []string
+	// PromptConfigs records the generation options of every prompt, in the order of Prompts.
+	PromptConfigs []serverops.PromptConfig
+	// ChatResponses, if set, are returned by the chat client in order.
+	// Once exhausted, the client falls back to answering "pong".
+	ChatResponses []string
+	// Chats records the messages of every chat received by the chat client.
+	Chats [][]serverops.Message
+	mu    sync.Mutex
 }
 
 // GetBackendIDs returns available backend IDs.
@@ -63,7 +78,7 @@ func (m *MockProvider) CanPrompt() bool {
 // Here we simply return a dummy implementation that meets the required interface.
 func (m *MockProvider) GetChatConnection(backendID string) (serverops.LLMChatClient, error) {
 	// In a real implementation this would create and return a connection to a chat LLM.
-	return &mockChatClient{}, nil
+	return &mockChatClient{provider: m}, nil
 }
 
 // GetEmbedConnection returns a dummy LLMEmbedClient.
@@ -78,13 +93,23 @@ func (m *MockProvider) GetStreamConnection(backendID string) (serverops.LLMStrea
 
 // GetPromptConnection implements Provider.
 func (m *MockProvider) GetPromptConnection(backendID string) (serverops.LLMPromptExecClient, error) {
-	return &mockPromptClient{}, nil
+	return &mockPromptClient{provider: m}, nil
 }
 
-type mockChatClient struct{}
+type mockChatClient struct {
+	provider *MockProvider
+}
 
-// Chat simulates a response by simply echoing the prompt.
-func (m *mockChatClient) Chat(context.Context, []serverops.Message) (serverops.Message, error) {
+// Chat simulates a response by returning the next scripted response or "pong".
+func (m *mockChatClient) Chat(_ context.Context, messages []serverops.Message) (serverops.Message, error) {
+	m.provider.mu.Lock()
+	defer m.provider.mu.Unlock()
+	m.provider.Chats = append(m.provider.Chats, messages)
+	if len(m.provider.ChatResponses) > 0 {
+		response := m.provider.ChatResponses[0]
+		m.provider.ChatResponses = m.provider.ChatResponses[1:]
+		return serverops.Message{Role: "assistant", Content: response}, nil
+	}
 	return serverops.Message{Role: "system", Content: "pong"}, nil
 }
 
@@ -107,9 +132,20 @@ func (m *mockStreamClient) Stream(ctx context.Context, prompt string) (<-chan st
 	return ch, nil
 }
 
-type mockPromptClient struct{}
+type mockPromptClient struct {
+	provider *MockProvider
+}
 
-// Prompt simulates prompting by returning a dummy response.
-func (m *mockPromptClient) Prompt(ctx context.Context, prompt string) (string, error) {
+// Prompt simulates prompting by returning the next scripted response or a dummy response.
+func (m *mockPromptClient) Prompt(ctx context.Context, prompt string, opts ...serverops.PromptOption) (string, error) {
+	m.provider.mu.Lock()
+	defer m.provider.mu.Unlock()
+	m.provider.Prompts = append(m.provider.Prompts, prompt)
+	m.provider.PromptConfigs = append(m.provider.PromptConfigs, serverops.NewPromptConfig(opts...))
+	if len(m.provider.PromptResponses) > 0 {
+		response := m.provider.PromptResponses[0]
+		m.provider.PromptResponses = m.provider.PromptResponses[1:]
+		return response, nil
+	}
 	return "prompted response for: " + prompt, nil
 }
@@ -15,16 +15,34 @@ type OllamaPromptClient struct {
 }
 
 // Prompt implements serverops.LLMPromptClient.
-func (o *OllamaPromptClient) Prompt(ctx context.Context, prompt string) (string, error) {
+func (o *OllamaPromptClient) Prompt(ctx context.Context, prompt string, opts ...serverops.PromptOption) (string, error) {
+	config := serverops.NewPromptConfig(opts...)
+	options := map[string]any{
+		"temperature": 0.0,
+	}
+	if config.Temperature != nil {
+		options["temperature"] = *config.Temperature
+	}
+	if config.TopP != nil {
+		options["top_p"] = *config.TopP
+	}
+	if config.Seed != nil {
+		options["seed"] = *config.Seed
+	}
+	if len(config.Stop) > 0 {
+		options["stop"] = config.Stop
+	}
+	if config.MaxTokens > 0 {
+		options["num_predict"] = config.MaxTokens
+	}
+
 	stream := false
 	req := &api.GenerateRequest{
-		Model:  o.modelName,
-		Prompt: prompt,
-		System: "You are a task processing engine talking to other machines. Identify the goal of the task and return the direct answer without explanation to the given task.",
-		Stream: &stream, // Disable streaming to get a single response
-		Options: map[string]any{
-			"temperature": 0.0,
-		},
+		Model:   o.modelName,
+		Prompt:  prompt,
+		System:  "You are a task processing engine talking to other machines. Identify the goal of the task and return the direct answer without explanation to the given task.",
+		Stream:  &stream, // Disable streaming to get a single response
+		Options: options,
 	}
 
 	var (
@@ -52,6 +70,10 @@ func (o *OllamaPromptClient) Prompt(ctx context.Context, prompt string) (string,
 	case "error":
 		return "", fmt.Errorf("ollama generation error for model %s: %s", o.modelName, content)
 	case "length":
+		if config.MaxTokens > 0 && content != "" {
+			// The caller asked for the limit.
+			return content, nil
+		}
 		return "", fmt.Errorf("token limit reached for model %s (partial response: %q)", o.modelName, content)
 	case "stop":
 		if content == "" {
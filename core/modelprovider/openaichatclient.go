@@ -0,0 +1,67 @@
+package modelprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/contenox/contenox/core/serverops"
+)
+
+type OpenAIChatClient struct {
+	client *openAIClient
+}
+
+var _ serverops.LLMChatClient = (*OpenAIChatClient)(nil)
+
+// Chat sends messages to /v1/chat/completions and returns the reply of the model.
+func (c *OpenAIChatClient) Chat(ctx context.Context, messages []serverops.Message) (serverops.Message, error) {
+	apiMessages := make([]openAIMessage, 0, len(messages))
+	for _, msg := range messages {
+		apiMessages = append(apiMessages, openAIMessage{Role: msg.Role, Content: msg.Content})
+	}
+	req := openAIChatRequest{
+		Model:    c.client.modelName,
+		Messages: apiMessages,
+	}
+
+	var resp openAIChatResponse
+	if err := c.client.do(ctx, "/v1/chat/completions", req, &resp); err != nil {
+		return serverops.Message{}, fmt.Errorf("openai chat request failed for model %s: %w", c.client.modelName, err)
+	}
+	if len(resp.Choices) == 0 {
+		return serverops.Message{}, fmt.Errorf("no response received for model %s", c.client.modelName)
+	}
+	choice := resp.Choices[0]
+
+	switch choice.FinishReason {
+	case "length":
+		// Treat token limit hits as application errors
+		return serverops.Message{}, fmt.Errorf(
+			"token limit reached for model %s (partial response: %q)",
+			c.client.modelName,
+			choice.Message.Content,
+		)
+	case "stop", "":
+		if choice.Message.Content == "" {
+			return serverops.Message{}, fmt.Errorf(
+				"empty content from model %s despite normal completion",
+				c.client.modelName,
+			)
+		}
+	default:
+		return serverops.Message{}, fmt.Errorf(
+			"unexpected completion reason %q for model %s",
+			choice.FinishReason,
+			c.client.modelName,
+		)
+	}
+
+	role := choice.Message.Role
+	if role == "" {
+		role = "assistant"
+	}
+	return serverops.Message{
+		Role:    role,
+		Content: choice.Message.Content,
+	}, nil
+}
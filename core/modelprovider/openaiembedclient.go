@@ -0,0 +1,39 @@
+package modelprovider
+
+import (
+	"context"
+	"fmt"
+)
+
+type OpenAIEmbedClient struct {
+	client *openAIClient
+}
+
+type openAIEmbeddingRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed returns the embedding of text from /v1/embeddings.
+func (c *OpenAIEmbedClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	req := openAIEmbeddingRequest{
+		Model: c.client.modelName,
+		Input: text,
+	}
+
+	var resp openAIEmbeddingResponse
+	if err := c.client.do(ctx, "/v1/embeddings", req, &resp); err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("no embedding received for model %s", c.client.modelName)
+	}
+
+	return resp.Data[0].Embedding, nil
+}
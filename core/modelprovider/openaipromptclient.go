@@ -0,0 +1,73 @@
+package modelprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/contenox/contenox/core/serverops"
+)
+
+type OpenAIPromptClient struct {
+	client *openAIClient
+}
+
+type openAICompletionRequest struct {
+	Model       string   `json:"model"`
+	Prompt      string   `json:"prompt"`
+	Temperature float64  `json:"temperature"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+}
+
+type openAICompletionResponse struct {
+	Choices []struct {
+		Text         string `json:"text"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// Prompt implements serverops.LLMPromptClient using /v1/completions.
+func (o *OpenAIPromptClient) Prompt(ctx context.Context, prompt string, opts ...serverops.PromptOption) (string, error) {
+	config := serverops.NewPromptConfig(opts...)
+	req := openAICompletionRequest{
+		Model:     o.client.modelName,
+		Prompt:    prompt,
+		TopP:      config.TopP,
+		Seed:      config.Seed,
+		Stop:      config.Stop,
+		MaxTokens: config.MaxTokens,
+	}
+	if config.Temperature != nil {
+		req.Temperature = *config.Temperature
+	}
+
+	var resp openAICompletionResponse
+	if err := o.client.do(ctx, "/v1/completions", req, &resp); err != nil {
+		return "", fmt.Errorf("openai completion request failed for model %s: %w", o.client.modelName, err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no completion received for model %s", o.client.modelName)
+	}
+	content := resp.Choices[0].Text
+
+	switch resp.Choices[0].FinishReason {
+	case "length":
+		if config.MaxTokens > 0 && content != "" {
+			// The caller asked for the limit.
+			return content, nil
+		}
+		return "", fmt.Errorf("token limit reached for model %s (partial response: %q)", o.client.modelName, content)
+	case "stop", "":
+		if content == "" {
+			return "", fmt.Errorf("empty content from model %s despite normal completion", o.client.modelName)
+		}
+	default:
+		return "", fmt.Errorf("unexpected completion reason %q for model %s", resp.Choices[0].FinishReason, o.client.modelName)
+	}
+
+	return content, nil
+}
+
+var _ serverops.LLMPromptExecClient = (*OpenAIPromptClient)(nil)
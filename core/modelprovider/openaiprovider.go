@@ -0,0 +1,247 @@
+package modelprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/contenox/contenox/core/serverops"
+)
+
+// OpenAIProvider serves a model from backends speaking the OpenAI API,
+// such as vLLM, llama.cpp server or LocalAI.
+type OpenAIProvider struct {
+	Name           string
+	ID             string
+	ContextLength  int
+	SupportsChat   bool
+	SupportsEmbed  bool
+	SupportsStream bool
+	SupportsPrompt bool
+	Backends       []string          // we assume that Backend IDs are urls to the instance
+	APIKeys        map[string]string // bearer tokens by backend URL, optional
+}
+
+func (p *OpenAIProvider) GetBackendIDs() []string {
+	return p.Backends
+}
+
+func (p *OpenAIProvider) ModelName() string {
+	return p.Name
+}
+
+func (p *OpenAIProvider) GetID() string {
+	return p.ID
+}
+
+func (p *OpenAIProvider) GetContextLength() int {
+	return p.ContextLength
+}
+
+func (p *OpenAIProvider) CanChat() bool {
+	return p.SupportsChat
+}
+
+func (p *OpenAIProvider) CanEmbed() bool {
+	return p.SupportsEmbed
+}
+
+func (p *OpenAIProvider) CanStream() bool {
+	return p.SupportsStream
+}
+
+func (p *OpenAIProvider) CanPrompt() bool {
+	return p.SupportsPrompt
+}
+
+func (p *OpenAIProvider) GetChatConnection(backendID string) (serverops.LLMChatClient, error) {
+	if !p.CanChat() {
+		return nil, fmt.Errorf("provider %s (model %s) does not support chat", p.GetID(), p.ModelName())
+	}
+	client, err := p.client(backendID)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAIChatClient{client: client}, nil
+}
+
+func (p *OpenAIProvider) GetPromptConnection(backendID string) (serverops.LLMPromptExecClient, error) {
+	if !p.CanPrompt() {
+		return nil, fmt.Errorf("provider %s (model %s) does not support prompting", p.GetID(), p.ModelName())
+	}
+	client, err := p.client(backendID)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAIPromptClient{client: client}, nil
+}
+
+func (p *OpenAIProvider) GetEmbedConnection(backendID string) (serverops.LLMEmbedClient, error) {
+	if !p.CanEmbed() {
+		return nil, fmt.Errorf("provider %s (model %s) does not support embeddings", p.GetID(), p.ModelName())
+	}
+	client, err := p.client(backendID)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAIEmbedClient{client: client}, nil
+}
+
+func (p *OpenAIProvider) GetStreamConnection(backendID string) (serverops.LLMStreamClient, error) {
+	if !p.CanStream() {
+		return nil, fmt.Errorf("provider %s (model %s) does not support streaming", p.GetID(), p.ModelName())
+	}
+	client, err := p.client(backendID)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAIStreamClient{client: client}, nil
+}
+
+func (p *OpenAIProvider) client(backendID string) (*openAIClient, error) {
+	u, err := url.Parse(backendID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backend URL '%s' for provider %s: %w", backendID, p.GetID(), err)
+	}
+	// TODO: Consider using a configurable http.Client with timeouts
+	return &openAIClient{
+		httpClient: http.DefaultClient,
+		baseURL:    strings.TrimRight(u.String(), "/"),
+		apiKey:     p.APIKeys[backendID],
+		modelName:  p.ModelName(),
+	}, nil
+}
+
+type OpenAIOption func(*OpenAIProvider)
+
+// NewOpenAIModelProvider returns a provider for a model served by OpenAI compatible backends.
+// The API does not report capabilities, so models are assumed to be embedding models
+// if their name says so or they are known to be one, and to be chat models otherwise.
+func NewOpenAIModelProvider(name string, backends []string, opts ...OpenAIOption) Provider {
+	baseName := parseModelName(name)
+	embedding := canEmbed[name] || canEmbed[baseName] || strings.Contains(strings.ToLower(name), "embed")
+	contextLength, ok := modelContextLengthsFullNames[name]
+	if !ok {
+		contextLength = modelContextLengths[baseName]
+	}
+
+	p := &OpenAIProvider{
+		Name:           name,
+		ID:             "openai:" + name,
+		ContextLength:  contextLength,
+		SupportsChat:   !embedding,
+		SupportsEmbed:  embedding,
+		SupportsStream: !embedding,
+		SupportsPrompt: !embedding,
+		Backends:       backends,
+		APIKeys:        map[string]string{},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// WithAPIKeys sets the bearer tokens of the backends by backend URL.
+func WithAPIKeys(keys map[string]string) OpenAIOption {
+	return func(p *OpenAIProvider) {
+		for backend, key := range keys {
+			p.APIKeys[backend] = key
+		}
+	}
+}
+
+// WithCapabilities overrides the capabilities assumed for the model.
+func WithCapabilities(chat, embed, prompt, stream bool) OpenAIOption {
+	return func(p *OpenAIProvider) {
+		p.SupportsChat = chat
+		p.SupportsEmbed = embed
+		p.SupportsPrompt = prompt
+		p.SupportsStream = stream
+	}
+}
+
+// openAIClient sends requests for a model to an OpenAI compatible backend.
+type openAIClient struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	modelName  string
+}
+
+// openAIError is the error body returned by OpenAI compatible servers.
+type openAIError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// post sends body as JSON to path and returns the response if its status is 200 OK.
+// The caller must close the body of the response.
+func (c *openAIClient) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var apiErr openAIError
+		if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("request failed with status %s: %s", resp.Status, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("request failed with status %s: %s", resp.Status, strings.TrimSpace(string(raw)))
+	}
+	return resp, nil
+}
+
+// do posts body to path and decodes the response into out.
+func (c *openAIClient) do(ctx context.Context, path string, body, out any) error {
+	resp, err := c.post(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+	Stream   bool            `json:"stream,omitempty"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		Delta        openAIMessage `json:"delta"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+}
@@ -0,0 +1,168 @@
+package modelprovider_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/contenox/contenox/core/modelprovider"
+	"github.com/contenox/contenox/core/runtimestate"
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/ollama/ollama/api"
+	"github.com/stretchr/testify/require"
+)
+
+// openAIStandIn serves the OpenAI endpoints used by the provider and records the requests.
+type openAIStandIn struct {
+	apiKey   string
+	requests map[string]map[string]any
+}
+
+func newOpenAIStandIn(t *testing.T, apiKey string) (*openAIStandIn, *httptest.Server) {
+	standIn := &openAIStandIn{apiKey: apiKey, requests: map[string]map[string]any{}}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	return standIn, server
+}
+
+func (s *openAIStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.apiKey != "" && r.Header.Get("Authorization") != "Bearer "+s.apiKey {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error": {"message": "invalid api key"}}`))
+		return
+	}
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	s.requests[r.URL.Path] = body
+
+	switch r.URL.Path {
+	case "/v1/chat/completions":
+		if body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, chunk := range []string{"Hel", "lo", "!"} {
+				fmt.Fprintf(w, "data: {\"choices\": [{\"delta\": {\"content\": %q}}]}\n\n", chunk)
+			}
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "Hello!"}, "finish_reason": "stop"}]}`))
+	case "/v1/completions":
+		_, _ = w.Write([]byte(`{"choices": [{"text": "42", "finish_reason": "stop"}]}`))
+	case "/v1/embeddings":
+		_, _ = w.Write([]byte(`{"data": [{"embedding": [0.1, 0.2, 0.3]}]}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestOpenAIProvider_Clients(t *testing.T) {
+	ctx := context.Background()
+	standIn, server := newOpenAIStandIn(t, "secret-key")
+	provider := modelprovider.NewOpenAIModelProvider("llama3", []string{server.URL},
+		modelprovider.WithAPIKeys(map[string]string{server.URL: "secret-key"}),
+		modelprovider.WithCapabilities(true, true, true, true),
+	)
+	require.Equal(t, "openai:llama3", provider.GetID())
+	require.Equal(t, 8192, provider.GetContextLength())
+
+	t.Run("chat", func(t *testing.T) {
+		client, err := provider.GetChatConnection(server.URL)
+		require.NoError(t, err)
+		reply, err := client.Chat(ctx, []serverops.Message{{Role: "user", Content: "Hi"}})
+		require.NoError(t, err)
+		require.Equal(t, serverops.Message{Role: "assistant", Content: "Hello!"}, reply)
+		require.Equal(t, "llama3", standIn.requests["/v1/chat/completions"]["model"])
+	})
+
+	t.Run("prompt", func(t *testing.T) {
+		client, err := provider.GetPromptConnection(server.URL)
+		require.NoError(t, err)
+		answer, err := client.Prompt(ctx, "What is 6*7?", serverops.WithMaxTokens(5))
+		require.NoError(t, err)
+		require.Equal(t, "42", answer)
+		request := standIn.requests["/v1/completions"]
+		require.Equal(t, "What is 6*7?", request["prompt"])
+		require.Equal(t, float64(5), request["max_tokens"])
+		require.Equal(t, float64(0), request["temperature"])
+	})
+
+	t.Run("embed", func(t *testing.T) {
+		client, err := provider.GetEmbedConnection(server.URL)
+		require.NoError(t, err)
+		embedding, err := client.Embed(ctx, "text")
+		require.NoError(t, err)
+		require.Equal(t, []float64{0.1, 0.2, 0.3}, embedding)
+		require.Equal(t, "text", standIn.requests["/v1/embeddings"]["input"])
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		client, err := provider.GetStreamConnection(server.URL)
+		require.NoError(t, err)
+		chunks, err := client.Stream(ctx, "Hi")
+		require.NoError(t, err)
+		var content string
+		for chunk := range chunks {
+			content += chunk
+		}
+		require.Equal(t, "Hello!", content)
+	})
+}
+
+func TestOpenAIProvider_RequiresAPIKey(t *testing.T) {
+	_, server := newOpenAIStandIn(t, "secret-key")
+	provider := modelprovider.NewOpenAIModelProvider("llama3", []string{server.URL})
+
+	client, err := provider.GetChatConnection(server.URL)
+	require.NoError(t, err)
+	_, err = client.Chat(context.Background(), []serverops.Message{{Role: "user", Content: "Hi"}})
+	require.ErrorContains(t, err, "invalid api key")
+}
+
+func TestOpenAIProvider_Capabilities(t *testing.T) {
+	chat := modelprovider.NewOpenAIModelProvider("meta-llama/Llama-3-8B-Instruct", nil)
+	require.True(t, chat.CanChat())
+	require.True(t, chat.CanPrompt())
+	require.True(t, chat.CanStream())
+	require.False(t, chat.CanEmbed())
+
+	embed := modelprovider.NewOpenAIModelProvider("text-embedding-3-small", nil)
+	require.True(t, embed.CanEmbed())
+	require.False(t, embed.CanChat())
+	_, err := embed.GetChatConnection("http://localhost")
+	require.Error(t, err)
+}
+
+func TestModelProviderAdapter_FiltersBackendTypes(t *testing.T) {
+	runtime := map[string]runtimestate.LLMState{
+		"ollama": {
+			ID:           "ollama",
+			Backend:      store.Backend{ID: "ollama", Type: store.BackendTypeOllama, BaseURL: "http://ollama:11434"},
+			PulledModels: []api.ListModelResponse{{Model: "llama3:latest"}},
+		},
+		"vllm": {
+			ID:           "vllm",
+			Backend:      store.Backend{ID: "vllm", Type: store.BackendTypeOpenAI, BaseURL: "http://vllm:8000", APIKey: "secret-key"},
+			PulledModels: []api.ListModelResponse{{Model: "mistral"}},
+		},
+	}
+	adapter := modelprovider.ModelProviderAdapter(context.Background(), runtime)
+
+	all, err := adapter(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+
+	openAI, err := adapter(context.Background(), "OpenAI")
+	require.NoError(t, err)
+	require.Len(t, openAI, 1)
+	require.Equal(t, "openai:mistral", openAI[0].GetID())
+	require.Equal(t, "secret-key", openAI[0].(*modelprovider.OpenAIProvider).APIKeys["http://vllm:8000"])
+
+	ollama, err := adapter(context.Background(), "Ollama")
+	require.NoError(t, err)
+	require.Len(t, ollama, 1)
+	require.Equal(t, "ollama:llama3:latest", ollama[0].GetID())
+}
@@ -0,0 +1,68 @@
+package modelprovider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/contenox/contenox/core/serverops"
+)
+
+type OpenAIStreamClient struct {
+	client *openAIClient
+}
+
+var _ serverops.LLMStreamClient = (*OpenAIStreamClient)(nil)
+
+// Stream sends prompt as user message to /v1/chat/completions and returns the
+// content of the streamed reply chunk by chunk. The channel is closed when the reply
+// is complete, the stream breaks or ctx ends.
+func (c *OpenAIStreamClient) Stream(ctx context.Context, prompt string) (<-chan string, error) {
+	req := openAIChatRequest{
+		Model:    c.client.modelName,
+		Messages: []openAIMessage{{Role: "user", Content: prompt}},
+		Stream:   true,
+	}
+	resp, err := c.client.post(ctx, "/v1/chat/completions", req)
+	if err != nil {
+		return nil, fmt.Errorf("openai stream request failed for model %s: %w", c.client.modelName, err)
+	}
+
+	chunks := make(chan string)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				return
+			}
+			var chunk openAIChatResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				log.Printf("invalid stream chunk from model %s: %v", c.client.modelName, err)
+				return
+			}
+			if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+				continue
+			}
+			select {
+			case chunks <- chunk.Choices[0].Delta.Content:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			log.Printf("stream from model %s broke: %v", c.client.modelName, err)
+		}
+	}()
+	return chunks, nil
+}
@@ -0,0 +1,105 @@
+package runtimestate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/ollama/ollama/api"
+)
+
+// openAIModelList is the response of GET /v1/models of an OpenAI compatible server.
+type openAIModelList struct {
+	Data []struct {
+		ID      string `json:"id"`
+		Created int64  `json:"created"`
+	} `json:"data"`
+}
+
+// openAPIKey decrypts the stored API key of backend.
+func (s *State) openAPIKey(backend *store.Backend) error {
+	if len(backend.EncryptedAPIKey) == 0 {
+		return nil
+	}
+	if s.apiKeys == nil {
+		return fmt.Errorf("backend %s has an api key, but api keys cannot be decrypted", backend.ID)
+	}
+	return s.apiKeys.Open(backend)
+}
+
+// processOpenAIBackend handles the state reconciliation for a single OpenAI compatible backend.
+// Such servers serve a fixed set of models, so unlike for Ollama backends no models are
+// downloaded or deleted, and the models listed by /v1/models are stored as the pulled models
+// of the backend whether they are declared or not.
+func (s *State) processOpenAIBackend(ctx context.Context, backend *store.Backend, declaredModels []*store.Model) {
+	models := []string{}
+	for _, model := range declaredModels {
+		models = append(models, model.Model)
+	}
+
+	err := s.openAPIKey(backend)
+	var served []api.ListModelResponse
+	if err == nil {
+		served, err = ListOpenAIModels(ctx, http.DefaultClient, backend.BaseURL, backend.APIKey)
+	}
+	if err != nil {
+		log.Printf("Error listing models for backend %s: %v", backend.ID, err)
+		s.state.Store(backend.ID, &LLMState{
+			ID:      backend.ID,
+			Name:    backend.Name,
+			Models:  models,
+			Backend: *backend,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	stateservice := &LLMState{
+		ID:           backend.ID,
+		Name:         backend.Name,
+		Models:       models,
+		PulledModels: served,
+		Backend:      *backend,
+	}
+	s.state.Store(backend.ID, stateservice)
+}
+
+// ListOpenAIModels lists the models served by the OpenAI compatible server at baseURL.
+// If apiKey is set it is sent as bearer token.
+func ListOpenAIModels(ctx context.Context, client *http.Client, baseURL, apiKey string) ([]api.ListModelResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/v1/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backend URL %q: %w", baseURL, err)
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("listing models failed with status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var list openAIModelList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("invalid model list: %w", err)
+	}
+	models := make([]api.ListModelResponse, 0, len(list.Data))
+	for _, model := range list.Data {
+		models = append(models, api.ListModelResponse{
+			Name:       model.ID,
+			Model:      model.ID,
+			ModifiedAt: time.Unix(model.Created, 0).UTC(),
+		})
+	}
+	return models, nil
+}
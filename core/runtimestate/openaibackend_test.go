@@ -0,0 +1,39 @@
+package runtimestate_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/contenox/contenox/core/runtimestate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOpenAIModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"object": "list", "data": [
+			{"id": "mistral", "object": "model", "created": 1700000000, "owned_by": "vllm"},
+			{"id": "nomic-embed-text", "object": "model", "created": 1700000000, "owned_by": "vllm"}
+		]}`))
+	}))
+	defer server.Close()
+
+	models, err := runtimestate.ListOpenAIModels(context.Background(), server.Client(), server.URL+"/", "secret-key")
+	require.NoError(t, err)
+	require.Len(t, models, 2)
+	require.Equal(t, "mistral", models[0].Model)
+	require.Equal(t, "nomic-embed-text", models[1].Name)
+	require.Equal(t, int64(1700000000), models[0].ModifiedAt.Unix())
+
+	_, err = runtimestate.ListOpenAIModels(context.Background(), server.Client(), server.URL, "")
+	require.ErrorContains(t, err, "401")
+}
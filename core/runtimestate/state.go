@@ -42,6 +42,7 @@ type State struct {
 	psInstance libbus.Messenger
 	dwQueue    dwqueue
 	withPools  bool
+	apiKeys    APIKeyOpener
 }
 
 type Option func(*State)
@@ -52,6 +53,18 @@ func WithPools() Option {
 	}
 }
 
+// APIKeyOpener decrypts the stored API keys of backends, see backendservice.APIKeyCipher.
+type APIKeyOpener interface {
+	Open(backend *store.Backend) error
+}
+
+// WithAPIKeys decrypts the API keys of backends with apiKeys, backends with a key fail without it.
+func WithAPIKeys(apiKeys APIKeyOpener) Option {
+	return func(s *State) {
+		s.apiKeys = apiKeys
+	}
+}
+
 // New creates and initializes a new State manager.
 // It requires a database manager (dbInstance) to load the desired configurations
 // and a messenger instance (psInstance) for event handling and progress updates.
@@ -189,6 +202,8 @@ func (s *State) Get(ctx context.Context) map[string]LLMState {
 		if err != nil {
 			log.Printf("failed to unmarshal backend: %v", err)
 		}
+		// API keys are never encoded.
+		backendCopy.Backend.APIKey = backend.Backend.APIKey
 		state[backend.ID] = backendCopy
 		return true
 	})
@@ -316,13 +331,15 @@ func (s *State) syncBackends(ctx context.Context) error {
 }
 
 // processBackend routes the backend processing logic based on the backend's Type.
-// It acts as a dispatcher to type-specific handling functions (e.g., for Ollama).
+// It acts as a dispatcher to type-specific handling functions (e.g., for Ollama or OpenAI).
 // It updates the internal state map with the results of the processing,
 // including any errors encountered for unsupported types.
 func (s *State) processBackend(ctx context.Context, backend *store.Backend, declaredOllamaModels []*store.Model) {
 	switch backend.Type {
-	case "Ollama":
+	case store.BackendTypeOllama:
 		s.processOllamaBackend(ctx, backend, declaredOllamaModels)
+	case store.BackendTypeOpenAI:
+		s.processOpenAIBackend(ctx, backend, declaredOllamaModels)
 	default:
 		log.Printf("Unsupported backend type: %s", backend.Type)
 		brokenService := &LLMState{
@@ -21,6 +21,20 @@ func AddBackendRoutes(mux *http.ServeMux, _ *serverops.Config, backendService ba
 	mux.HandleFunc("GET /backends/{id}", b.get)
 	mux.HandleFunc("PUT /backends/{id}", b.update)
 	mux.HandleFunc("DELETE /backends/{id}", b.delete)
+	mux.HandleFunc("DELETE /backends/{id}/apikey", b.clearAPIKey)
+}
+
+// backendRequest is a backend as sent by clients. API keys are write-only,
+// store.Backend never encodes them.
+type backendRequest struct {
+	store.Backend
+	APIKey string `json:"apiKey,omitempty"`
+}
+
+func (req backendRequest) backend() store.Backend {
+	backend := req.Backend
+	backend.APIKey = req.APIKey
+	return backend
 }
 
 type respBackendList struct {
@@ -45,11 +59,12 @@ type backendManager struct {
 func (b *backendManager) create(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	backend, err := serverops.Decode[store.Backend](r)
+	req, err := serverops.Decode[backendRequest](r)
 	if err != nil {
 		_ = serverops.Error(w, r, err, serverops.CreateOperation)
 		return
 	}
+	backend := req.backend()
 	backend.ID = uuid.NewString()
 	if err := b.service.Create(ctx, &backend); err != nil {
 		_ = serverops.Error(w, r, err, serverops.CreateOperation)
@@ -75,7 +90,7 @@ func (b *backendManager) list(w http.ResponseWriter, r *http.Request) {
 			ID:      backend.ID,
 			Name:    backend.Name,
 			BaseURL: backend.BaseURL,
-			Type:    "Ollama",
+			Type:    backend.Type,
 		}
 		state, ok := backendState[backend.ID]
 		if ok {
@@ -112,12 +127,13 @@ func (b *backendManager) update(w http.ResponseWriter, r *http.Request) {
 		_ = serverops.Error(w, r, fmt.Errorf("missing id parameter %w", serverops.ErrBadPathValue), serverops.UpdateOperation)
 		return
 	}
-	backend, err := serverops.Decode[store.Backend](r)
+	req, err := serverops.Decode[backendRequest](r)
 	if err != nil {
 		_ = serverops.Error(w, r, err, serverops.UpdateOperation)
 		return
 	}
 
+	backend := req.backend()
 	backend.ID = id
 	if err := b.service.Update(ctx, &backend); err != nil {
 		_ = serverops.Error(w, r, err, serverops.UpdateOperation)
@@ -141,3 +157,18 @@ func (b *backendManager) delete(w http.ResponseWriter, r *http.Request) {
 
 	_ = serverops.Encode(w, r, http.StatusOK, "backend removed")
 }
+
+func (b *backendManager) clearAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	if id == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("missing id parameter %w", serverops.ErrBadPathValue), serverops.UpdateOperation)
+		return
+	}
+	if err := b.service.ClearAPIKey(ctx, id); err != nil {
+		_ = serverops.Error(w, r, err, serverops.UpdateOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, "api key removed")
+}
@@ -0,0 +1,258 @@
+package chainsapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/contenox/contenox/core/serverapi/execapi"
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/services/chainservice"
+	"github.com/contenox/contenox/core/services/execservice"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/google/uuid"
+)
+
+func AddChainRoutes(mux *http.ServeMux, _ *serverops.Config, chainService chainservice.Service, taskService execservice.TasksEnvService) {
+	h := &chainHandler{
+		service:     chainService,
+		taskService: taskService,
+	}
+
+	mux.HandleFunc("POST /chains", h.create)
+	mux.HandleFunc("GET /chains", h.list)
+	mux.HandleFunc("POST /chains/validate", h.validate)
+	mux.HandleFunc("GET /chains/{id}", h.get)
+	mux.HandleFunc("PUT /chains/{id}", h.update)
+	mux.HandleFunc("DELETE /chains/{id}", h.delete)
+	mux.HandleFunc("GET /chains/{id}/versions", h.listVersions)
+	mux.HandleFunc("GET /chains/{id}/versions/{version}", h.getVersion)
+	mux.HandleFunc("POST /chains/{id}/rollback", h.rollback)
+	mux.HandleFunc("POST /chains/{id}/execute", h.execute)
+}
+
+type chainHandler struct {
+	service     chainservice.Service
+	taskService execservice.TasksEnvService
+}
+
+func (h *chainHandler) create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	chain, err := serverops.Decode[taskengine.ChainDefinition](r)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.CreateOperation)
+		return
+	}
+
+	record, err := h.service.Create(ctx, &chain)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.CreateOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusCreated, record)
+}
+
+// validate reports every static problem of a chain definition without storing it.
+func (h *chainHandler) validate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	chain, err := serverops.Decode[taskengine.ChainDefinition](r)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.GetOperation)
+		return
+	}
+
+	result, err := h.service.Validate(ctx, &chain)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.GetOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, result)
+}
+
+func (h *chainHandler) list(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	chains, err := h.service.List(ctx)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ListOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, chains)
+}
+
+func (h *chainHandler) get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	if id == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("id required: %w", serverops.ErrBadPathValue), serverops.GetOperation)
+		return
+	}
+
+	chain, err := h.service.Get(ctx, id)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.GetOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, chain)
+}
+
+func (h *chainHandler) update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	if id == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("id required: %w", serverops.ErrBadPathValue), serverops.UpdateOperation)
+		return
+	}
+
+	chain, err := serverops.Decode[taskengine.ChainDefinition](r)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.UpdateOperation)
+		return
+	}
+	chain.ID = id
+
+	record, err := h.service.Update(ctx, &chain)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.UpdateOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, record)
+}
+
+func (h *chainHandler) delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	if id == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("id required: %w", serverops.ErrBadPathValue), serverops.DeleteOperation)
+		return
+	}
+
+	if err := h.service.Delete(ctx, id); err != nil {
+		_ = serverops.Error(w, r, err, serverops.DeleteOperation)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *chainHandler) listVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	if id == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("id required: %w", serverops.ErrBadPathValue), serverops.ListOperation)
+		return
+	}
+
+	versions, err := h.service.ListVersions(ctx, id)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ListOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, versions)
+}
+
+func (h *chainHandler) getVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	if id == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("id required: %w", serverops.ErrBadPathValue), serverops.GetOperation)
+		return
+	}
+	version, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil {
+		_ = serverops.Error(w, r, fmt.Errorf("version must be a number: %w", serverops.ErrBadPathValue), serverops.GetOperation)
+		return
+	}
+
+	chain, err := h.service.GetVersion(ctx, id, version)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.GetOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, chain)
+}
+
+type rollbackRequest struct {
+	Version int `json:"version"`
+}
+
+func (h *chainHandler) rollback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	if id == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("id required: %w", serverops.ErrBadPathValue), serverops.UpdateOperation)
+		return
+	}
+
+	req, err := serverops.Decode[rollbackRequest](r)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.UpdateOperation)
+		return
+	}
+	if req.Version <= 0 {
+		_ = serverops.Error(w, r, fmt.Errorf("version required: %w", serverops.ErrMissingParameter), serverops.UpdateOperation)
+		return
+	}
+
+	record, err := h.service.Rollback(ctx, id, req.Version)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.UpdateOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, record)
+}
+
+type executeRequest struct {
+	Input string `json:"input"`
+
+	// WithLog answers with an execapi.OutputWithLog instead of the bare output.
+	WithLog bool `json:"withLog,omitempty"`
+}
+
+// execute runs the active version of a stored chain.
+func (h *chainHandler) execute(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	if id == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("id required: %w", serverops.ErrBadPathValue), serverops.ExecuteOperation)
+		return
+	}
+
+	req, err := serverops.Decode[executeRequest](r)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ExecuteOperation)
+		return
+	}
+
+	chain, err := h.service.Get(ctx, id)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.GetOperation)
+		return
+	}
+
+	executionID := uuid.NewString()
+	ctx = taskengine.WithExecutionID(ctx, executionID)
+	w.Header().Set(execapi.ExecutionIDHeader, executionID)
+
+	var logged *execapi.OutputWithLog
+	if req.WithLog {
+		ctx, logged = execapi.WithLog(ctx)
+	}
+	output, err := h.taskService.Execute(ctx, chain, req.Input)
+	var resp any = output
+	if logged != nil {
+		logged.Output = output
+		resp = logged
+	}
+	execapi.EncodeExecutionResult(w, r, executionID, resp, err)
+}
@@ -1,11 +1,17 @@
 package execapi
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/contenox/contenox/core/serverops"
 	"github.com/contenox/contenox/core/services/execservice"
 	"github.com/contenox/contenox/core/taskengine"
+	"github.com/google/uuid"
 )
 
 func AddExecRoutes(mux *http.ServeMux, _ *serverops.Config, promptService execservice.ExecService, taskService execservice.TasksEnvService) {
@@ -15,9 +21,21 @@ func AddExecRoutes(mux *http.ServeMux, _ *serverops.Config, promptService execse
 	}
 	mux.HandleFunc("POST /execute", f.execute)
 	mux.HandleFunc("POST /tasks", f.tasks)
+	mux.HandleFunc("POST /tasks/stream", f.tasksStream)
 	mux.HandleFunc("GET /supported", f.supported)
+	mux.HandleFunc("GET /executions", f.listExecutions)
+	mux.HandleFunc("GET /executions/{id}", f.getExecution)
+	mux.HandleFunc("POST /executions/{id}/resume", f.resume)
+	mux.HandleFunc("GET /approvals", f.listApprovals)
+	mux.HandleFunc("GET /approvals/{id}", f.getApproval)
+	mux.HandleFunc("POST /approvals/{id}/decision", f.decide)
+	mux.HandleFunc("GET /hooks/{id}", f.getHook)
 }
 
+// ExecutionIDHeader carries the ID of the execution started by a request,
+// which can be used to query its status via GET /executions/{id}.
+const ExecutionIDHeader = "X-Execution-ID"
+
 type taskManager struct {
 	promptService execservice.ExecService
 	taskService   execservice.TasksEnvService
@@ -41,6 +59,28 @@ func (tm *taskManager) execute(w http.ResponseWriter, r *http.Request) {
 type taskExec struct {
 	Input string                      `json:"input"`
 	Chain *taskengine.ChainDefinition `json:"chain"`
+
+	// BypassCache sends every prompt to the model instead of reusing cached responses.
+	BypassCache bool `json:"bypassCache,omitempty"`
+
+	// DryRun answers every task with canned responses instead of calling models or hooks.
+	DryRun *dryRun `json:"dryRun,omitempty"`
+
+	// WithLog answers with an OutputWithLog instead of the bare output.
+	WithLog bool `json:"withLog,omitempty"`
+}
+
+type dryRun struct {
+	Responses taskengine.DryRunResponses `json:"responses"`
+}
+
+// context returns the context to execute the request with.
+// Dry runs are set up by the service, see execservice.TasksEnvService.DryRun.
+func (req taskExec) context(ctx context.Context) context.Context {
+	if req.BypassCache {
+		ctx = taskengine.WithCacheBypass(ctx)
+	}
+	return ctx
 }
 
 func (tm *taskManager) tasks(w http.ResponseWriter, r *http.Request) {
@@ -49,8 +89,70 @@ func (tm *taskManager) tasks(w http.ResponseWriter, r *http.Request) {
 		_ = serverops.Error(w, r, err, serverops.ExecuteOperation)
 		return
 	}
+	if req.DryRun != nil {
+		result, err := tm.taskService.DryRun(req.context(r.Context()), req.Chain, req.Input, req.DryRun.Responses)
+		if err != nil {
+			_ = serverops.Error(w, r, err, serverops.ExecuteOperation)
+			return
+		}
+		_ = serverops.Encode(w, r, http.StatusOK, result)
+		return
+	}
+
+	executionID := uuid.NewString()
+	ctx := taskengine.WithExecutionID(req.context(r.Context()), executionID)
+	w.Header().Set(ExecutionIDHeader, executionID)
 
-	resp, err := tm.taskService.Execute(r.Context(), req.Chain, req.Input)
+	var logged *OutputWithLog
+	if req.WithLog {
+		ctx, logged = WithLog(ctx)
+	}
+	output, err := tm.taskService.Execute(ctx, req.Chain, req.Input)
+	var resp any = output
+	if logged != nil {
+		logged.Output = output
+		resp = logged
+	}
+	EncodeExecutionResult(w, r, executionID, resp, err)
+}
+
+// OutputWithLog is the response of an execution requested with withLog.
+type OutputWithLog struct {
+	Output any `json:"output"`
+
+	// Log lists the messages printed by the tasks of the chain, in order.
+	Log []taskengine.PrintEntry `json:"log"`
+
+	mu sync.Mutex
+}
+
+// WithLog returns a context whose execution collects its printed messages into the log
+// of the returned OutputWithLog. Parallel branches may print concurrently.
+func WithLog(ctx context.Context) (context.Context, *OutputWithLog) {
+	logged := &OutputWithLog{Log: []taskengine.PrintEntry{}}
+	return taskengine.WithPrintObserver(ctx, func(entry taskengine.PrintEntry) {
+		logged.mu.Lock()
+		defer logged.mu.Unlock()
+		logged.Log = append(logged.Log, entry)
+	}), logged
+}
+
+// WaitingResponse is returned with 202 Accepted when an execution paused for an approval.
+type WaitingResponse struct {
+	ExecutionID string                     `json:"executionId"`
+	Status      taskengine.ExecutionStatus `json:"status"`
+}
+
+// EncodeExecutionResult writes the outcome of a chain execution.
+// Executions that paused for an approval are answered with 202 Accepted and a WaitingResponse.
+func EncodeExecutionResult(w http.ResponseWriter, r *http.Request, executionID string, resp any, err error) {
+	if errors.Is(err, taskengine.ErrExecutionPaused) {
+		_ = serverops.Encode(w, r, http.StatusAccepted, WaitingResponse{
+			ExecutionID: executionID,
+			Status:      taskengine.ExecutionWaiting,
+		})
+		return
+	}
 	if err != nil {
 		_ = serverops.Error(w, r, err, serverops.ExecuteOperation)
 		return
@@ -67,3 +169,130 @@ func (tm *taskManager) supported(w http.ResponseWriter, r *http.Request) {
 
 	_ = serverops.Encode(w, r, http.StatusOK, resp)
 }
+
+func (tm *taskManager) listExecutions(w http.ResponseWriter, r *http.Request) {
+	cursor, err := parseTimeParam(r, "cursor")
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ListOperation)
+		return
+	}
+
+	executions, err := tm.taskService.ListExecutions(r.Context(), cursor)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ListOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, executions)
+}
+
+func (tm *taskManager) getExecution(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("id required: %w", serverops.ErrBadPathValue), serverops.GetOperation)
+		return
+	}
+
+	execution, err := tm.taskService.GetExecution(r.Context(), id)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.GetOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, execution)
+}
+
+// resume continues an interrupted execution from its last completed task.
+func (tm *taskManager) resume(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("id required: %w", serverops.ErrBadPathValue), serverops.ExecuteOperation)
+		return
+	}
+	w.Header().Set(ExecutionIDHeader, id)
+
+	resp, err := tm.taskService.Resume(r.Context(), id)
+	EncodeExecutionResult(w, r, id, resp, err)
+}
+
+func (tm *taskManager) listApprovals(w http.ResponseWriter, r *http.Request) {
+	approvals, err := tm.taskService.ListPendingApprovals(r.Context())
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ListOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, approvals)
+}
+
+func (tm *taskManager) getApproval(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("id required: %w", serverops.ErrBadPathValue), serverops.GetOperation)
+		return
+	}
+
+	approval, err := tm.taskService.GetApproval(r.Context(), id)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.GetOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, approval)
+}
+
+// getHook returns a hook queued by a non-blocking Hook task, by the hookId of the task output.
+func (tm *taskManager) getHook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("id required: %w", serverops.ErrBadPathValue), serverops.GetOperation)
+		return
+	}
+
+	hook, err := tm.taskService.GetHook(r.Context(), id)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.GetOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, hook)
+}
+
+// decide approves, rejects or edits a pending approval and resumes its execution.
+// The response is the result of the resumed execution.
+func (tm *taskManager) decide(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("id required: %w", serverops.ErrBadPathValue), serverops.ExecuteOperation)
+		return
+	}
+
+	decision, err := serverops.Decode[taskengine.ApprovalDecision](r)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ExecuteOperation)
+		return
+	}
+
+	approval, err := tm.taskService.GetApproval(r.Context(), id)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.GetOperation)
+		return
+	}
+	w.Header().Set(ExecutionIDHeader, approval.ExecutionID)
+
+	resp, err := tm.taskService.DecideApproval(r.Context(), id, &decision)
+	EncodeExecutionResult(w, r, approval.ExecutionID, resp, err)
+}
+
+func parseTimeParam(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s parameter: %w", name, serverops.ErrInvalidParameterValue)
+	}
+	return &t, nil
+}
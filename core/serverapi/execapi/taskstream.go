@@ -0,0 +1,168 @@
+package execapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/google/uuid"
+)
+
+// Event types sent by POST /tasks/stream.
+const (
+	EventTaskStart    = "task_start"
+	EventTaskRetry    = "task_retry"
+	EventTaskError    = "task_error"
+	EventTaskComplete = "task_complete"
+	EventBranchStart  = "branch_start"
+	EventBranchError  = "branch_error"
+	EventTransition   = "transition"
+	EventPrint        = "print"
+	EventWaiting      = "waiting"
+	EventResult       = "result"
+	EventError        = "error"
+)
+
+// streamEvent is the payload of a single Server-Sent Event.
+type streamEvent struct {
+	Type   string         `json:"type"`
+	TaskID string         `json:"taskId,omitempty"`
+	Args   map[string]any `json:"args,omitempty"`
+	Data   any            `json:"data,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// tasksStream executes a chain like POST /tasks, but streams its progress as Server-Sent Events.
+// Every task start, retry, error, completion, transition and print produces an event,
+// followed by a final "result", "waiting" or "error" event. Disconnecting cancels the execution.
+func (tm *taskManager) tasksStream(w http.ResponseWriter, r *http.Request) {
+	req, err := serverops.Decode[taskExec](r)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ExecuteOperation)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		_ = serverops.Error(w, r, fmt.Errorf("streaming unsupported"), serverops.ServerOperation)
+		return
+	}
+
+	executionID := uuid.NewString()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set(ExecutionIDHeader, executionID)
+
+	// The request context is cancelled when the client disconnects, which stops the execution.
+	events := make(chan streamEvent)
+	ctx := taskengine.WithExecutionID(req.context(r.Context()), executionID)
+	ctx = taskengine.WithTracker(ctx, &streamTracker{events: events})
+
+	type outcome struct {
+		resp any
+		err  error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		resp, err := tm.taskService.Execute(ctx, req.Chain, req.Input)
+		done <- outcome{resp: resp, err: err}
+	}()
+
+	for {
+		select {
+		case event := <-events:
+			writeEvent(w, event)
+			flusher.Flush()
+		case out := <-done:
+			// The tracker blocks until each event is received,
+			// so every event of the execution has been written at this point.
+			if errors.Is(out.err, taskengine.ErrExecutionPaused) {
+				writeEvent(w, streamEvent{Type: EventWaiting, Data: WaitingResponse{ExecutionID: executionID, Status: taskengine.ExecutionWaiting}})
+			} else if out.err != nil {
+				writeEvent(w, streamEvent{Type: EventError, Error: out.err.Error()})
+			} else {
+				writeEvent(w, streamEvent{Type: EventResult, Data: out.resp})
+			}
+			flusher.Flush()
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event streamEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		data, _ = json.Marshal(streamEvent{Type: event.Type, TaskID: event.TaskID, Error: fmt.Sprintf("failed to encode event: %v", err)})
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+}
+
+// streamTracker turns the operations reported by the task environment into stream events.
+type streamTracker struct {
+	events chan<- streamEvent
+}
+
+func (t *streamTracker) Start(
+	ctx context.Context,
+	operation string,
+	subject string,
+	kvArgs ...any,
+) (func(error), func(string, any), func()) {
+	send := func(event streamEvent) {
+		event.TaskID = subject
+		select {
+		case t.events <- event:
+		case <-ctx.Done():
+		}
+	}
+	noop := func() {}
+	args := eventArgs(kvArgs)
+
+	switch operation {
+	case "task_attempt", "branch_attempt":
+		startType, errType := EventTaskStart, EventTaskError
+		if operation == "branch_attempt" {
+			startType, errType = EventBranchStart, EventBranchError
+		}
+		if retry, ok := args["retry"].(int); ok && retry > 0 && operation == "task_attempt" {
+			startType = EventTaskRetry
+		}
+		send(streamEvent{Type: startType, Args: args})
+		return func(err error) {
+				if err != nil {
+					send(streamEvent{Type: errType, Args: args, Error: err.Error()})
+				}
+			}, func(_ string, data any) {
+				if operation == "task_attempt" {
+					send(streamEvent{Type: EventTaskComplete, Data: data})
+				}
+			}, noop
+	case "transition":
+		return func(error) {}, func(next string, _ any) {
+			send(streamEvent{Type: EventTransition, Args: args, Data: next})
+		}, noop
+	case "print":
+		return func(error) {}, func(_ string, message any) {
+			send(streamEvent{Type: EventPrint, Data: message})
+		}, noop
+	}
+	return serverops.NoopTracker{}.Start(ctx, operation, subject, kvArgs...)
+}
+
+// eventArgs converts the key-value pairs passed to Start into a map.
+func eventArgs(kvArgs []any) map[string]any {
+	args := make(map[string]any, len(kvArgs)/2)
+	for i := 0; i+1 < len(kvArgs); i += 2 {
+		args[fmt.Sprint(kvArgs[i])] = kvArgs[i+1]
+	}
+	return args
+}
+
+var _ serverops.ActivityTracker = (*streamTracker)(nil)
@@ -0,0 +1,106 @@
+package secretsapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/services/secretservice"
+)
+
+// AddSecretRoutes registers the secret endpoints. Responses never contain secret values.
+func AddSecretRoutes(mux *http.ServeMux, _ *serverops.Config, secretService secretservice.Service) {
+	h := &secretHandler{
+		service: secretService,
+	}
+
+	mux.HandleFunc("POST /secrets", h.create)
+	mux.HandleFunc("GET /secrets", h.list)
+	mux.HandleFunc("GET /secrets/{name}", h.get)
+	mux.HandleFunc("PUT /secrets/{name}", h.update)
+	mux.HandleFunc("DELETE /secrets/{name}", h.delete)
+}
+
+type secretHandler struct {
+	service secretservice.Service
+}
+
+func (h *secretHandler) create(w http.ResponseWriter, r *http.Request) {
+	input, err := serverops.Decode[secretservice.Input](r)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.CreateOperation)
+		return
+	}
+
+	secret, err := h.service.Create(r.Context(), &input)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.CreateOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusCreated, secret)
+}
+
+func (h *secretHandler) list(w http.ResponseWriter, r *http.Request) {
+	secrets, err := h.service.List(r.Context())
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ListOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, secrets)
+}
+
+func (h *secretHandler) get(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("name required: %w", serverops.ErrBadPathValue), serverops.GetOperation)
+		return
+	}
+
+	secret, err := h.service.Get(r.Context(), name)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.GetOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, secret)
+}
+
+func (h *secretHandler) update(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("name required: %w", serverops.ErrBadPathValue), serverops.UpdateOperation)
+		return
+	}
+
+	input, err := serverops.Decode[secretservice.Input](r)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.UpdateOperation)
+		return
+	}
+	input.Name = name
+
+	secret, err := h.service.Update(r.Context(), &input)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.UpdateOperation)
+		return
+	}
+
+	_ = serverops.Encode(w, r, http.StatusOK, secret)
+}
+
+func (h *secretHandler) delete(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("name required: %w", serverops.ErrBadPathValue), serverops.DeleteOperation)
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), name); err != nil {
+		_ = serverops.Error(w, r, err, serverops.DeleteOperation)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
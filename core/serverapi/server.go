@@ -9,18 +9,22 @@ import (
 	"github.com/contenox/contenox/core/llmrepo"
 	"github.com/contenox/contenox/core/runtimestate"
 	"github.com/contenox/contenox/core/serverapi/backendapi"
+	"github.com/contenox/contenox/core/serverapi/chainsapi"
 	"github.com/contenox/contenox/core/serverapi/chatapi"
 	"github.com/contenox/contenox/core/serverapi/dispatchapi"
 	"github.com/contenox/contenox/core/serverapi/execapi"
 	"github.com/contenox/contenox/core/serverapi/filesapi"
 	"github.com/contenox/contenox/core/serverapi/indexapi"
 	"github.com/contenox/contenox/core/serverapi/poolapi"
+	"github.com/contenox/contenox/core/serverapi/secretsapi"
 	"github.com/contenox/contenox/core/serverapi/systemapi"
+	"github.com/contenox/contenox/core/serverapi/triggersapi"
 	"github.com/contenox/contenox/core/serverapi/usersapi"
 	"github.com/contenox/contenox/core/serverops"
 	"github.com/contenox/contenox/core/serverops/vectors"
 	"github.com/contenox/contenox/core/services/accessservice"
 	"github.com/contenox/contenox/core/services/backendservice"
+	"github.com/contenox/contenox/core/services/chainservice"
 	"github.com/contenox/contenox/core/services/chatservice"
 	"github.com/contenox/contenox/core/services/dispatchservice"
 	"github.com/contenox/contenox/core/services/downloadservice"
@@ -29,7 +33,9 @@ import (
 	"github.com/contenox/contenox/core/services/indexservice"
 	"github.com/contenox/contenox/core/services/modelservice"
 	"github.com/contenox/contenox/core/services/poolservice"
+	"github.com/contenox/contenox/core/services/secretservice"
 	"github.com/contenox/contenox/core/services/tokenizerservice"
+	"github.com/contenox/contenox/core/services/triggerservice"
 	"github.com/contenox/contenox/core/services/userservice"
 	"github.com/contenox/contenox/core/taskengine"
 	"github.com/contenox/contenox/libs/libauth"
@@ -48,7 +54,10 @@ func New(
 	environmentExec taskengine.EnvExecutor,
 	state *runtimestate.State,
 	vectorStore vectors.Store,
-	hookRegistry taskengine.HookRegistry,
+	hookRegistry taskengine.HookRepo,
+	tokenizerSvc tokenizerservice.Tokenizer,
+	apiKeys *backendservice.APIKeyCipher,
+	secrets *secretservice.Resolver,
 ) (http.Handler, func() error, error) {
 	cleanup := func() error { return nil }
 	mux := http.NewServeMux()
@@ -63,7 +72,7 @@ func New(
 	if err != nil {
 		return nil, cleanup, err
 	}
-	backendService := backendservice.New(dbInstance)
+	backendService := backendservice.New(dbInstance, apiKeys)
 	backendapi.AddBackendRoutes(mux, config, backendService, state)
 	poolservice := poolservice.New(dbInstance)
 	poolapi.AddPoolRoutes(mux, config, poolservice)
@@ -95,12 +104,6 @@ func New(
 	backendapi.AddQueueRoutes(mux, config, downloadService)
 	modelService := modelservice.New(dbInstance, config)
 	backendapi.AddModelRoutes(mux, config, modelService, downloadService)
-	tokenizerSvc, cleanup, err := tokenizerservice.NewGRPCTokenizer(ctx, tokenizerservice.ConfigGRPC{
-		ServerAddress: config.TokenizerServiceURL,
-	})
-	if err != nil {
-		return nil, cleanup, err
-	}
 	chatService := chatservice.New(state, dbInstance, tokenizerSvc)
 	chatapi.AddChatRoutes(mux, config, chatService, state)
 	userService := userservice.New(dbInstance, config)
@@ -111,9 +114,51 @@ func New(
 	indexService := indexservice.New(ctx, embedder, execmodelrepo, vectorStore, dbInstance)
 	indexapi.AddIndexRoutes(mux, config, indexService)
 
+	pool.StartLoop(
+		ctx,
+		"approvalExpiryCycle",
+		3,
+		10*time.Second,
+		10*time.Second,
+		execservice.NewApprovalExpiryCycle(dbInstance, environmentExec),
+	)
 	execService := execservice.NewExec(ctx, execmodelrepo, dbInstance)
 	taskService := execservice.NewTasksEnv(ctx, environmentExec, dbInstance, hookRegistry)
 	execapi.AddExecRoutes(mux, config, execService, taskService)
+	chainService := chainservice.New(dbInstance, hookRegistry)
+	chainsapi.AddChainRoutes(mux, config, chainService, taskService)
+	triggerService := triggerservice.New(ctx, dbInstance, embedder, vectorStore, pubsub, environmentExec, secrets.Trusted())
+	triggersapi.AddTriggerRoutes(mux, config, triggerService)
+	pool.StartLoop(
+		ctx,
+		"triggerCycle",
+		3,
+		10*time.Second,
+		10*time.Second,
+		triggerService.Sync,
+	)
+	pool.StartLoop(
+		ctx,
+		"scheduleCycle",
+		3,
+		10*time.Second,
+		10*time.Second,
+		triggerService.RunSchedules,
+	)
+	secretService, err := secretservice.New(dbInstance, config.EncryptionKey)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	pool.StartLoop(
+		ctx,
+		"hookCycle",
+		3,
+		10*time.Second,
+		10*time.Second,
+		// Secret references of queued hooks were authorized when the hook was queued.
+		execservice.NewHookWorkerCycle(dbInstance, hookRegistry, secrets.Trusted(), execservice.NewTraceTracker(dbInstance)),
+	)
+	secretsapi.AddSecretRoutes(mux, config, secretService)
 	usersapi.AddAuthRoutes(mux, userService)
 	dispatchService := dispatchservice.New(dbInstance, config)
 	dispatchapi.AddDispatchRoutes(mux, config, dispatchService)
@@ -132,6 +177,9 @@ func New(
 		indexService,
 		dispatchService,
 		execService,
+		chainService,
+		triggerService,
+		secretService,
 	}
 	err = serverops.GetManagerInstance().RegisterServices(services...)
 	if err != nil {
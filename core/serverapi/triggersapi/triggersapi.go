@@ -0,0 +1,114 @@
+package triggersapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/services/triggerservice"
+)
+
+func AddTriggerRoutes(mux *http.ServeMux, _ *serverops.Config, triggerService triggerservice.Service) {
+	h := &triggerHandler{
+		service: triggerService,
+	}
+
+	mux.HandleFunc("POST /triggers/match", h.match)
+	mux.HandleFunc("POST /triggers/dispatch", h.dispatch)
+	mux.HandleFunc("GET /triggers/schedules", h.schedules)
+	mux.HandleFunc("GET /triggers/runs", h.runs)
+}
+
+const (
+	defaultUpcomingRuns = 5
+	defaultRunsLimit    = 100
+)
+
+type triggerHandler struct {
+	service triggerservice.Service
+}
+
+type triggerRequest struct {
+	Input string `json:"input"`
+}
+
+func (h *triggerHandler) match(w http.ResponseWriter, r *http.Request) {
+	req, err := serverops.Decode[triggerRequest](r)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ExecuteOperation)
+		return
+	}
+	if req.Input == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("input is required: %w", serverops.ErrMissingParameter), serverops.ExecuteOperation)
+		return
+	}
+
+	matches, err := h.service.Match(r.Context(), req.Input)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ExecuteOperation)
+		return
+	}
+	_ = serverops.Encode(w, r, http.StatusOK, matches)
+}
+
+func (h *triggerHandler) dispatch(w http.ResponseWriter, r *http.Request) {
+	req, err := serverops.Decode[triggerRequest](r)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ExecuteOperation)
+		return
+	}
+	if req.Input == "" {
+		_ = serverops.Error(w, r, fmt.Errorf("input is required: %w", serverops.ErrMissingParameter), serverops.ExecuteOperation)
+		return
+	}
+
+	firings, err := h.service.Dispatch(r.Context(), req.Input)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ExecuteOperation)
+		return
+	}
+	_ = serverops.Encode(w, r, http.StatusOK, firings)
+}
+
+func (h *triggerHandler) schedules(w http.ResponseWriter, r *http.Request) {
+	count, err := positiveQueryInt(r, "count", defaultUpcomingRuns)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ListOperation)
+		return
+	}
+
+	schedules, err := h.service.ListSchedules(r.Context(), count)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ListOperation)
+		return
+	}
+	_ = serverops.Encode(w, r, http.StatusOK, schedules)
+}
+
+func (h *triggerHandler) runs(w http.ResponseWriter, r *http.Request) {
+	limit, err := positiveQueryInt(r, "limit", defaultRunsLimit)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ListOperation)
+		return
+	}
+
+	runs, err := h.service.ListRuns(r.Context(), r.URL.Query().Get("chainId"), limit)
+	if err != nil {
+		_ = serverops.Error(w, r, err, serverops.ListOperation)
+		return
+	}
+	_ = serverops.Encode(w, r, http.StatusOK, runs)
+}
+
+func positiveQueryInt(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer: %w", name, serverops.ErrInvalidParameterValue)
+	}
+	return n, nil
+}
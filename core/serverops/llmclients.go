@@ -21,5 +21,58 @@ type LLMStreamClient interface {
 }
 
 type LLMPromptExecClient interface {
-	Prompt(ctx context.Context, prompt string) (string, error)
+	Prompt(ctx context.Context, prompt string, opts ...PromptOption) (string, error)
+}
+
+// PromptConfig holds the generation parameters of a prompt.
+// Unset fields leave the choice to the client.
+type PromptConfig struct {
+	Temperature *float64
+	TopP        *float64
+	Seed        *int
+	Stop        []string
+	MaxTokens   int
+}
+
+// PromptOption sets a generation parameter of a prompt.
+type PromptOption func(*PromptConfig)
+
+func WithTemperature(temperature float64) PromptOption {
+	return func(c *PromptConfig) {
+		c.Temperature = &temperature
+	}
+}
+
+func WithTopP(topP float64) PromptOption {
+	return func(c *PromptConfig) {
+		c.TopP = &topP
+	}
+}
+
+func WithSeed(seed int) PromptOption {
+	return func(c *PromptConfig) {
+		c.Seed = &seed
+	}
+}
+
+func WithStop(stop ...string) PromptOption {
+	return func(c *PromptConfig) {
+		c.Stop = stop
+	}
+}
+
+// WithMaxTokens limits the length of the response, a response cut at the limit is not an error.
+func WithMaxTokens(maxTokens int) PromptOption {
+	return func(c *PromptConfig) {
+		c.MaxTokens = maxTokens
+	}
+}
+
+// NewPromptConfig applies the options in order.
+func NewPromptConfig(opts ...PromptOption) PromptConfig {
+	var config PromptConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
 }
@@ -19,12 +19,13 @@ func (s *store) CreateBackend(ctx context.Context, backend *Backend) error {
 
 	_, err := s.Exec.ExecContext(ctx, `
 		INSERT INTO llm_backends
-		(id, name, base_url, type, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`,
+		(id, name, base_url, type, api_key_encrypted, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
 		backend.ID,
 		backend.Name,
 		backend.BaseURL,
 		backend.Type,
+		backend.EncryptedAPIKey,
 		backend.CreatedAt,
 		backend.UpdatedAt,
 	)
@@ -34,7 +35,7 @@ func (s *store) CreateBackend(ctx context.Context, backend *Backend) error {
 func (s *store) GetBackend(ctx context.Context, id string) (*Backend, error) {
 	var backend Backend
 	err := s.Exec.QueryRowContext(ctx, `
-		SELECT id, name, base_url, type, created_at, updated_at
+		SELECT id, name, base_url, type, api_key_encrypted, created_at, updated_at
 		FROM llm_backends
 		WHERE id = $1`,
 		id,
@@ -43,6 +44,7 @@ func (s *store) GetBackend(ctx context.Context, id string) (*Backend, error) {
 		&backend.Name,
 		&backend.BaseURL,
 		&backend.Type,
+		&backend.EncryptedAPIKey,
 		&backend.CreatedAt,
 		&backend.UpdatedAt,
 	)
@@ -61,12 +63,14 @@ func (s *store) UpdateBackend(ctx context.Context, backend *Backend) error {
 		SET name = $2,
 			base_url = $3,
 			type = $4,
-			updated_at = $5
+			api_key_encrypted = $5,
+			updated_at = $6
 		WHERE id = $1`,
 		backend.ID,
 		backend.Name,
 		backend.BaseURL,
 		backend.Type,
+		backend.EncryptedAPIKey,
 		backend.UpdatedAt,
 	)
 
@@ -93,7 +97,7 @@ func (s *store) DeleteBackend(ctx context.Context, id string) error {
 
 func (s *store) ListBackends(ctx context.Context) ([]*Backend, error) {
 	rows, err := s.Exec.QueryContext(ctx, `
-		SELECT id, name, base_url, type, created_at, updated_at
+		SELECT id, name, base_url, type, api_key_encrypted, created_at, updated_at
 		FROM llm_backends
 		ORDER BY created_at DESC`,
 	)
@@ -110,6 +114,7 @@ func (s *store) ListBackends(ctx context.Context) ([]*Backend, error) {
 			&backend.Name,
 			&backend.BaseURL,
 			&backend.Type,
+			&backend.EncryptedAPIKey,
 			&backend.CreatedAt,
 			&backend.UpdatedAt,
 		); err != nil {
@@ -128,7 +133,7 @@ func (s *store) ListBackends(ctx context.Context) ([]*Backend, error) {
 func (s *store) GetBackendByName(ctx context.Context, name string) (*Backend, error) {
 	var backend Backend
 	err := s.Exec.QueryRowContext(ctx, `
-		SELECT id, name, base_url, type, created_at, updated_at
+		SELECT id, name, base_url, type, api_key_encrypted, created_at, updated_at
 		FROM llm_backends
 		WHERE name = $1`,
 		name,
@@ -137,6 +142,7 @@ func (s *store) GetBackendByName(ctx context.Context, name string) (*Backend, er
 		&backend.Name,
 		&backend.BaseURL,
 		&backend.Type,
+		&backend.EncryptedAPIKey,
 		&backend.CreatedAt,
 		&backend.UpdatedAt,
 	)
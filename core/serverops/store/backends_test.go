@@ -4,9 +4,9 @@ import (
 	"testing"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/contenox/contenox/core/serverops/store"
 	"github.com/contenox/contenox/libs/libdb"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -14,10 +14,11 @@ func TestCreateAndGetBackend(t *testing.T) {
 	ctx, s := store.SetupStore(t)
 
 	backend := &store.Backend{
-		ID:      uuid.NewString(),
-		Name:    "TestBackend",
-		BaseURL: "http://localhost:8080",
-		Type:    "Ollama",
+		ID:              uuid.NewString(),
+		Name:            "TestBackend",
+		BaseURL:         "http://localhost:8080",
+		Type:            "OpenAI",
+		EncryptedAPIKey: []byte("sealed-key"),
 	}
 
 	// Create the backend.
@@ -31,6 +32,7 @@ func TestCreateAndGetBackend(t *testing.T) {
 	require.Equal(t, backend.Name, got.Name)
 	require.Equal(t, backend.BaseURL, got.BaseURL)
 	require.Equal(t, backend.Type, got.Type)
+	require.Equal(t, backend.EncryptedAPIKey, got.EncryptedAPIKey)
 	require.WithinDuration(t, backend.CreatedAt, got.CreatedAt, time.Second)
 	require.WithinDuration(t, backend.UpdatedAt, got.UpdatedAt, time.Second)
 }
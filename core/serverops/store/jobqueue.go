@@ -95,6 +95,27 @@ func (s *store) PopJobForType(ctx context.Context, taskType string) (*Job, error
 	return &job, nil
 }
 
+// PopDueJobForType removes and returns the oldest job of taskType that is not scheduled for later.
+func (s *store) PopDueJobForType(ctx context.Context, taskType string) (*Job, error) {
+	query := `
+	DELETE FROM job_queue_v2
+	WHERE id = (
+		SELECT id FROM job_queue_v2
+		WHERE task_type = $1 AND COALESCE(scheduled_for, 0) <= $2
+		ORDER BY created_at LIMIT 1
+	)
+	RETURNING id, task_type, operation, subject, entity_id, entity_type, payload, scheduled_for, valid_until, retry_count, created_at;
+	`
+	row := s.Exec.QueryRowContext(ctx, query, taskType, time.Now().UTC().Unix())
+
+	var job Job
+	if err := row.Scan(&job.ID, &job.TaskType, &job.Operation, &job.Subject, &job.EntityID, &job.EntityType, &job.Payload, &job.ScheduledFor, &job.ValidUntil, &job.RetryCount, &job.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
 func (s *store) GetJobsForType(ctx context.Context, taskType string) ([]*Job, error) {
 	query := `
 		SELECT id, task_type, operation, subject, entity_id, entity_type, payload, scheduled_for, valid_until, retry_count, created_at
@@ -202,6 +223,30 @@ func (s *store) DeleteLeasedJob(ctx context.Context, id string) error {
 	return nil
 }
 
+// PopExpiredLeasedJobs removes and returns the leased jobs of taskType whose lease expired.
+func (s *store) PopExpiredLeasedJobs(ctx context.Context, taskType string) ([]*Job, error) {
+	query := `
+	DELETE FROM leased_jobs
+	WHERE task_type = $1 AND lease_expiration < $2
+	RETURNING id, task_type, operation, subject, entity_id, entity_type, payload, scheduled_for, valid_until, retry_count, created_at;
+	`
+	rows, err := s.Exec.QueryContext(ctx, query, taskType, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		var job Job
+		if err := rows.Scan(&job.ID, &job.TaskType, &job.Operation, &job.Subject, &job.EntityID, &job.EntityType, &job.Payload, &job.ScheduledFor, &job.ValidUntil, &job.RetryCount, &job.CreatedAt); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, rows.Err()
+}
+
 func (s *store) ListLeasedJobs(ctx context.Context, createdAtCursor *time.Time, limit int) ([]*LeasedJob, error) {
 	cursor := time.Now().UTC()
 	if createdAtCursor != nil {
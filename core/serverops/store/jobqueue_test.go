@@ -437,3 +437,50 @@ func TestEmptyListOperations(t *testing.T) {
 		require.Empty(t, jobs)
 	})
 }
+
+func TestPopDueJobForType(t *testing.T) {
+	ctx, s := store.SetupStore(t)
+
+	later := store.Job{ID: uuid.New().String(), TaskType: "due-test", Payload: []byte("{}"), ScheduledFor: time.Now().Add(time.Hour).Unix()}
+	due := store.Job{ID: uuid.New().String(), TaskType: "due-test", Payload: []byte("{}"), ScheduledFor: time.Now().Add(-time.Minute).Unix()}
+	require.NoError(t, s.AppendJob(ctx, later))
+	time.Sleep(10 * time.Millisecond) // Ensure ordering by created_at.
+	require.NoError(t, s.AppendJob(ctx, due))
+
+	// The older job is scheduled for later and skipped.
+	popped, err := s.PopDueJobForType(ctx, "due-test")
+	require.NoError(t, err)
+	require.Equal(t, due.ID, popped.ID)
+
+	_, err = s.PopDueJobForType(ctx, "due-test")
+	require.Error(t, err)
+
+	jobs, err := s.GetJobsForType(ctx, "due-test")
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Equal(t, later.ID, jobs[0].ID)
+}
+
+func TestPopExpiredLeasedJobs(t *testing.T) {
+	ctx, s := store.SetupStore(t)
+
+	expired := store.Job{ID: uuid.New().String(), TaskType: "expiry-test", Payload: []byte("{}"), RetryCount: 1}
+	active := store.Job{ID: uuid.New().String(), TaskType: "expiry-test", Payload: []byte("{}")}
+	require.NoError(t, s.AppendLeasedJob(ctx, expired, -time.Minute, "crashed-worker"))
+	require.NoError(t, s.AppendLeasedJob(ctx, active, time.Hour, "running-worker"))
+
+	jobs, err := s.PopExpiredLeasedJobs(ctx, "expiry-test")
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Equal(t, expired.ID, jobs[0].ID)
+	require.Equal(t, 1, jobs[0].RetryCount)
+
+	_, err = s.GetLeasedJob(ctx, expired.ID)
+	require.Error(t, err)
+	_, err = s.GetLeasedJob(ctx, active.ID)
+	require.NoError(t, err)
+
+	jobs, err = s.PopExpiredLeasedJobs(ctx, "expiry-test")
+	require.NoError(t, err)
+	require.Empty(t, jobs)
+}
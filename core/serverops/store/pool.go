@@ -137,7 +137,7 @@ func (s *store) RemoveBackendFromPool(ctx context.Context, poolID, backendID str
 
 func (s *store) ListBackendsForPool(ctx context.Context, poolID string) ([]*Backend, error) {
 	rows, err := s.Exec.QueryContext(ctx, `
-		SELECT b.id, b.name, b.base_url, b.type, b.created_at, b.updated_at
+		SELECT b.id, b.name, b.base_url, b.type, b.api_key_encrypted, b.created_at, b.updated_at
 		FROM llm_backends b
 		INNER JOIN llm_pool_backend_assignments a ON b.id = a.backend_id
 		WHERE a.pool_id = $1
@@ -150,7 +150,7 @@ func (s *store) ListBackendsForPool(ctx context.Context, poolID string) ([]*Back
 	var backends []*Backend
 	for rows.Next() {
 		var b Backend
-		if err := rows.Scan(&b.ID, &b.Name, &b.BaseURL, &b.Type, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		if err := rows.Scan(&b.ID, &b.Name, &b.BaseURL, &b.Type, &b.EncryptedAPIKey, &b.CreatedAt, &b.UpdatedAt); err != nil {
 			return nil, err
 		}
 		backends = append(backends, &b)
@@ -20,11 +20,14 @@ CREATE TABLE IF NOT EXISTS llm_backends (
     name VARCHAR(512) NOT NULL UNIQUE,
     base_url VARCHAR(512) NOT NULL UNIQUE,
     type VARCHAR(512) NOT NULL,
+    api_key_encrypted BYTEA,
 
     created_at TIMESTAMP NOT NULL,
     updated_at TIMESTAMP NOT NULL
 );
 
+ALTER TABLE llm_backends ADD COLUMN IF NOT EXISTS api_key_encrypted BYTEA;
+
 CREATE TABLE IF NOT EXISTS llm_pool_backend_assignments (
     pool_id VARCHAR(255) NOT NULL REFERENCES llm_pool(id) ON DELETE CASCADE,
     backend_id VARCHAR(255) NOT NULL REFERENCES llm_backends(id) ON DELETE CASCADE,
@@ -171,6 +174,110 @@ CREATE TABLE IF NOT EXISTS messages (
     added_at TIMESTAMP NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS task_chains (
+    id VARCHAR(255) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    active_version INT NOT NULL,
+
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS task_chain_versions (
+    chain_id VARCHAR(255) NOT NULL REFERENCES task_chains(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    definition JSONB NOT NULL,
+
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chain_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS task_executions (
+    id VARCHAR(255) PRIMARY KEY,
+    chain_id VARCHAR(255) NOT NULL DEFAULT '',
+    chain JSONB NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    current_task VARCHAR(255) NOT NULL DEFAULT '',
+    state JSONB NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS task_execution_traces (
+    id VARCHAR(255) PRIMARY KEY,
+    execution_id VARCHAR(255) NOT NULL,
+    operation VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    args JSONB NOT NULL,
+    change_id VARCHAR(255) NOT NULL DEFAULT '',
+    change JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL,
+    duration_ms BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS task_approvals (
+    id VARCHAR(255) PRIMARY KEY,
+    execution_id VARCHAR(255) NOT NULL,
+    chain_id VARCHAR(255) NOT NULL DEFAULT '',
+    task_id VARCHAR(255) NOT NULL,
+    prompt TEXT NOT NULL DEFAULT '',
+    value JSONB,
+    vars JSONB NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    decision JSONB,
+    expires_at TIMESTAMP,
+
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS task_hooks (
+    id VARCHAR(255) PRIMARY KEY,
+    execution_id VARCHAR(255) NOT NULL,
+    chain_id VARCHAR(255) NOT NULL DEFAULT '',
+    task_id VARCHAR(255) NOT NULL,
+    hook VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    output JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    attempts INT NOT NULL DEFAULT 0,
+
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS trigger_runs (
+    id VARCHAR(255) PRIMARY KEY,
+    chain_id VARCHAR(255) NOT NULL,
+    schedule VARCHAR(512) NOT NULL,
+    scheduled_at TIMESTAMP NOT NULL,
+    execution_id VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (chain_id, schedule, scheduled_at)
+);
+
+CREATE TABLE IF NOT EXISTS secrets (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    encrypted_value BYTEA NOT NULL,
+
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_trigger_runs_chain_id ON trigger_runs USING hash(chain_id);
+CREATE INDEX IF NOT EXISTS idx_task_approvals_status ON task_approvals USING hash(status);
+CREATE INDEX IF NOT EXISTS idx_task_hooks_execution_id ON task_hooks USING hash(execution_id);
+CREATE INDEX IF NOT EXISTS idx_task_execution_traces_execution_id ON task_execution_traces USING hash(execution_id);
+CREATE INDEX IF NOT EXISTS idx_task_executions_status ON task_executions USING hash(status);
 CREATE INDEX IF NOT EXISTS idx_job_queue_v2_task_type ON job_queue_v2 USING hash(task_type);
 CREATE INDEX IF NOT EXISTS idx_accesslists_identity ON accesslists USING hash(identity);
 CREATE INDEX IF NOT EXISTS idx_users_email ON users USING hash(email);
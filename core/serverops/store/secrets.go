@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/contenox/contenox/libs/libdb"
+)
+
+func (s *store) CreateSecret(ctx context.Context, secret *Secret) error {
+	now := time.Now().UTC()
+	secret.CreatedAt = now
+	secret.UpdatedAt = now
+
+	_, err := s.Exec.ExecContext(ctx, `
+		INSERT INTO secrets
+		(id, name, description, encrypted_value, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		secret.ID, secret.Name, secret.Description, secret.EncryptedValue, secret.CreatedAt, secret.UpdatedAt,
+	)
+	return err
+}
+
+func (s *store) GetSecretByName(ctx context.Context, name string) (*Secret, error) {
+	var secret Secret
+	err := s.Exec.QueryRowContext(ctx, `
+		SELECT id, name, description, encrypted_value, created_at, updated_at
+		FROM secrets WHERE name = $1`, name,
+	).Scan(&secret.ID, &secret.Name, &secret.Description, &secret.EncryptedValue, &secret.CreatedAt, &secret.UpdatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, libdb.ErrNotFound
+	}
+	return &secret, err
+}
+
+func (s *store) UpdateSecret(ctx context.Context, secret *Secret) error {
+	secret.UpdatedAt = time.Now().UTC()
+
+	result, err := s.Exec.ExecContext(ctx, `
+		UPDATE secrets SET
+		description = $2, encrypted_value = $3, updated_at = $4
+		WHERE name = $1`,
+		secret.Name, secret.Description, secret.EncryptedValue, secret.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+	return checkRowsAffected(result)
+}
+
+func (s *store) DeleteSecret(ctx context.Context, name string) error {
+	result, err := s.Exec.ExecContext(ctx, `
+		DELETE FROM secrets WHERE name = $1`, name,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+	return checkRowsAffected(result)
+}
+
+// ListSecrets returns all secrets ordered by name, including their encrypted values.
+func (s *store) ListSecrets(ctx context.Context) ([]*Secret, error) {
+	rows, err := s.Exec.QueryContext(ctx, `
+		SELECT id, name, description, encrypted_value, created_at, updated_at
+		FROM secrets ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query secrets: %w", err)
+	}
+	defer rows.Close()
+
+	secrets := []*Secret{}
+	for rows.Next() {
+		var secret Secret
+		if err := rows.Scan(&secret.ID, &secret.Name, &secret.Description, &secret.EncryptedValue, &secret.CreatedAt, &secret.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan secret: %w", err)
+		}
+		secrets = append(secrets, &secret)
+	}
+	return secrets, rows.Err()
+}
@@ -0,0 +1,45 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretCRUD(t *testing.T) {
+	ctx, s := store.SetupStore(t)
+
+	secret := &store.Secret{ID: "secret-1", Name: "crm_token", Description: "CRM API key", EncryptedValue: []byte{1, 2, 3}}
+	require.NoError(t, s.CreateSecret(ctx, secret))
+	require.NotZero(t, secret.CreatedAt)
+
+	duplicate := &store.Secret{ID: "secret-2", Name: "crm_token", EncryptedValue: []byte{4}}
+	require.ErrorIs(t, s.CreateSecret(ctx, duplicate), libdb.ErrUniqueViolation)
+
+	got, err := s.GetSecretByName(ctx, "crm_token")
+	require.NoError(t, err)
+	require.Equal(t, "secret-1", got.ID)
+	require.Equal(t, []byte{1, 2, 3}, got.EncryptedValue)
+
+	got.EncryptedValue = []byte{5, 6}
+	got.Description = "rotated"
+	require.NoError(t, s.UpdateSecret(ctx, got))
+	got, err = s.GetSecretByName(ctx, "crm_token")
+	require.NoError(t, err)
+	require.Equal(t, []byte{5, 6}, got.EncryptedValue)
+	require.Equal(t, "rotated", got.Description)
+
+	require.NoError(t, s.CreateSecret(ctx, &store.Secret{ID: "secret-3", Name: "api_key", EncryptedValue: []byte{7}}))
+	secrets, err := s.ListSecrets(ctx)
+	require.NoError(t, err)
+	require.Len(t, secrets, 2)
+	require.Equal(t, "api_key", secrets[0].Name)
+	require.Equal(t, "crm_token", secrets[1].Name)
+
+	require.NoError(t, s.DeleteSecret(ctx, "crm_token"))
+	_, err = s.GetSecretByName(ctx, "crm_token")
+	require.ErrorIs(t, err, libdb.ErrNotFound)
+	require.ErrorIs(t, s.DeleteSecret(ctx, "crm_token"), libdb.ErrNotFound)
+}
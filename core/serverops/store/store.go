@@ -29,12 +29,25 @@ type QueueItem struct {
 	Model string `json:"model"`
 }
 
+// Backend types supported by the runtime state.
+const (
+	BackendTypeOllama = "Ollama"
+	BackendTypeOpenAI = "OpenAI" // Servers speaking the OpenAI API, e.g. vLLM, llama.cpp server or LocalAI.
+)
+
 type Backend struct {
 	ID      string `json:"id"`
 	Name    string `json:"name"`
 	BaseURL string `json:"baseUrl"`
 	Type    string `json:"type"`
 
+	// APIKey is sent as bearer token to backends of BackendTypeOpenAI, if set.
+	// It is never encoded and only stored as EncryptedAPIKey, see backendservice.APIKeyCipher.
+	APIKey string `json:"-"`
+
+	// EncryptedAPIKey is the stored, encrypted APIKey.
+	EncryptedAPIKey []byte `json:"-"`
+
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -108,6 +121,9 @@ const (
 	ResourceTypeFile   = "file"
 	ResourceTypeBlobs  = "blobs"
 	ResourceTypeChunks = "chunks"
+
+	// ResourceTypeTrigger marks chunk indices of semantic chain triggers.
+	ResourceTypeTrigger = "trigger"
 )
 
 var ResourceTypes = []string{
@@ -144,6 +160,100 @@ type ChunkIndex struct {
 	EmbeddingModel string `json:"embeddingModel"`
 }
 
+type TaskChain struct {
+	ID            string    `json:"id"`
+	Description   string    `json:"description"`
+	ActiveVersion int       `json:"activeVersion"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+type TaskChainVersion struct {
+	ChainID    string    `json:"chainId"`
+	Version    int       `json:"version"`
+	Definition []byte    `json:"definition"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type TaskExecution struct {
+	ID          string    `json:"id"`
+	ChainID     string    `json:"chainId"`
+	Chain       []byte    `json:"chain"`
+	Status      string    `json:"status"`
+	CurrentTask string    `json:"currentTask"`
+	State       []byte    `json:"state"`
+	Error       string    `json:"error"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type TaskApproval struct {
+	ID          string     `json:"id"`
+	ExecutionID string     `json:"executionId"`
+	ChainID     string     `json:"chainId"`
+	TaskID      string     `json:"taskId"`
+	Prompt      string     `json:"prompt"`
+	Value       []byte     `json:"value"`
+	Vars        []byte     `json:"vars"`
+	Status      string     `json:"status"`
+	Decision    []byte     `json:"decision"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// TaskHook is a hook call of a non-blocking Hook task and its outcome once it ran.
+type TaskHook struct {
+	ID          string    `json:"id"`
+	ExecutionID string    `json:"executionId"`
+	ChainID     string    `json:"chainId"`
+	TaskID      string    `json:"taskId"`
+	Hook        string    `json:"hook"`
+	Status      string    `json:"status"`
+	Output      []byte    `json:"output"`
+	Error       string    `json:"error"`
+	Attempts    int       `json:"attempts"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// TriggerRun is a firing of a schedule trigger. A run is unique per chain, schedule and scheduled time,
+// which lets only one replica claim it.
+type TriggerRun struct {
+	ID          string    `json:"id"`
+	ChainID     string    `json:"chainId"`
+	Schedule    string    `json:"schedule"`
+	ScheduledAt time.Time `json:"scheduledAt"`
+	ExecutionID string    `json:"executionId"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Secret is a named value encrypted at rest. EncryptedValue is never decrypted by the store.
+type Secret struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	EncryptedValue []byte    `json:"-"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+type TaskTraceEntry struct {
+	ID          string    `json:"id"`
+	ExecutionID string    `json:"executionId"`
+	Operation   string    `json:"operation"`
+	Subject     string    `json:"subject"`
+	Args        []byte    `json:"args"`
+	ChangeID    string    `json:"changeId"`
+	Change      []byte    `json:"change"`
+	Error       string    `json:"error"`
+	StartedAt   time.Time `json:"startedAt"`
+	DurationMS  int64     `json:"durationMs"`
+}
+
 type Permission int
 
 const (
@@ -249,6 +359,7 @@ type Store interface {
 	PopAllJobs(ctx context.Context) ([]*Job, error)
 	PopJobsForType(ctx context.Context, taskType string) ([]*Job, error)
 	PopJobForType(ctx context.Context, taskType string) (*Job, error)
+	PopDueJobForType(ctx context.Context, taskType string) (*Job, error)
 	GetJobsForType(ctx context.Context, taskType string) ([]*Job, error)
 	ListJobs(ctx context.Context, createdAtCursor *time.Time, limit int) ([]*Job, error)
 	DeleteJobsByEntity(ctx context.Context, entityID, entityType string) error
@@ -257,6 +368,7 @@ type Store interface {
 	GetLeasedJob(ctx context.Context, id string) (*LeasedJob, error)
 	DeleteLeasedJob(ctx context.Context, id string) error
 	ListLeasedJobs(ctx context.Context, createdAtCursor *time.Time, limit int) ([]*LeasedJob, error)
+	PopExpiredLeasedJobs(ctx context.Context, taskType string) ([]*Job, error)
 	DeleteLeasedJobs(ctx context.Context, entityID, entityType string) error
 
 	CreateAccessEntry(ctx context.Context, entry *AccessEntry) error
@@ -313,6 +425,46 @@ type Store interface {
 	DeleteChunkIndex(ctx context.Context, id string) error
 	ListChunkIndicesByVectorID(ctx context.Context, vectorID string) ([]*ChunkIndex, error)
 	ListChunkIndicesByResource(ctx context.Context, resourceID, resourceType string) ([]*ChunkIndex, error)
+
+	CreateTaskChain(ctx context.Context, chain *TaskChain) error
+	GetTaskChain(ctx context.Context, id string) (*TaskChain, error)
+	UpdateTaskChain(ctx context.Context, chain *TaskChain) error
+	DeleteTaskChain(ctx context.Context, id string) error
+	ListTaskChains(ctx context.Context) ([]*TaskChain, error)
+
+	AppendTaskChainVersion(ctx context.Context, version *TaskChainVersion) error
+	GetTaskChainVersion(ctx context.Context, chainID string, version int) (*TaskChainVersion, error)
+	ListTaskChainVersions(ctx context.Context, chainID string) ([]*TaskChainVersion, error)
+
+	CreateTaskExecution(ctx context.Context, execution *TaskExecution) error
+	GetTaskExecution(ctx context.Context, id string) (*TaskExecution, error)
+	UpdateTaskExecution(ctx context.Context, execution *TaskExecution) error
+	ClaimTaskExecution(ctx context.Context, id string, staleBefore time.Time) (*TaskExecution, error)
+	ListTaskExecutions(ctx context.Context, createdAtCursor *time.Time, limit int) ([]*TaskExecution, error)
+
+	AppendTaskTraceEntry(ctx context.Context, entry *TaskTraceEntry) error
+	ListTaskTraceEntries(ctx context.Context, executionID string) ([]*TaskTraceEntry, error)
+
+	CreateTaskApproval(ctx context.Context, approval *TaskApproval) error
+	GetTaskApproval(ctx context.Context, id string) (*TaskApproval, error)
+	DecideTaskApproval(ctx context.Context, id string, status string, decision []byte) error
+	ListPendingTaskApprovals(ctx context.Context) ([]*TaskApproval, error)
+	ListExpiredTaskApprovals(ctx context.Context, now time.Time) ([]*TaskApproval, error)
+
+	CreateTaskHook(ctx context.Context, hook *TaskHook) error
+	GetTaskHook(ctx context.Context, id string) (*TaskHook, error)
+	UpdateTaskHook(ctx context.Context, hook *TaskHook) error
+	ListTaskHooks(ctx context.Context, executionID string) ([]*TaskHook, error)
+
+	ClaimTriggerRun(ctx context.Context, run *TriggerRun) error
+	FinishTriggerRun(ctx context.Context, id string, status string, errMsg string) error
+	ListTriggerRuns(ctx context.Context, chainID string, limit int) ([]*TriggerRun, error)
+
+	CreateSecret(ctx context.Context, secret *Secret) error
+	GetSecretByName(ctx context.Context, name string) (*Secret, error)
+	UpdateSecret(ctx context.Context, secret *Secret) error
+	DeleteSecret(ctx context.Context, name string) error
+	ListSecrets(ctx context.Context) ([]*Secret, error)
 }
 
 //go:embed schema.sql
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/contenox/contenox/libs/libdb"
+)
+
+// ApprovalPending is the status of approvals that were not decided yet.
+const ApprovalPending = "pending"
+
+func (s *store) CreateTaskApproval(ctx context.Context, approval *TaskApproval) error {
+	now := time.Now().UTC()
+	approval.CreatedAt = now
+	approval.UpdatedAt = now
+	if approval.Status == "" {
+		approval.Status = ApprovalPending
+	}
+
+	_, err := s.Exec.ExecContext(ctx, `
+		INSERT INTO task_approvals
+		(id, execution_id, chain_id, task_id, prompt, value, vars, status, decision, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		approval.ID, approval.ExecutionID, approval.ChainID, approval.TaskID, approval.Prompt,
+		approval.Value, approval.Vars, approval.Status, approval.Decision, approval.ExpiresAt,
+		approval.CreatedAt, approval.UpdatedAt,
+	)
+	return err
+}
+
+func (s *store) GetTaskApproval(ctx context.Context, id string) (*TaskApproval, error) {
+	var approval TaskApproval
+	err := s.Exec.QueryRowContext(ctx, `
+		SELECT id, execution_id, chain_id, task_id, prompt, value, vars, status, decision, expires_at, created_at, updated_at
+		FROM task_approvals WHERE id = $1`, id,
+	).Scan(
+		&approval.ID, &approval.ExecutionID, &approval.ChainID, &approval.TaskID, &approval.Prompt,
+		&approval.Value, &approval.Vars, &approval.Status, &approval.Decision, &approval.ExpiresAt,
+		&approval.CreatedAt, &approval.UpdatedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, libdb.ErrNotFound
+	}
+	return &approval, err
+}
+
+// DecideTaskApproval stores the decision of a pending approval.
+// It returns libdb.ErrNotFound if the approval does not exist or was already decided,
+// so concurrent decisions cannot both succeed.
+func (s *store) DecideTaskApproval(ctx context.Context, id string, status string, decision []byte) error {
+	result, err := s.Exec.ExecContext(ctx, `
+		UPDATE task_approvals SET
+		status = $2, decision = $3, updated_at = $4
+		WHERE id = $1 AND status = $5`,
+		id, status, decision, time.Now().UTC(), ApprovalPending,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to decide task approval: %w", err)
+	}
+	return checkRowsAffected(result)
+}
+
+func (s *store) ListPendingTaskApprovals(ctx context.Context) ([]*TaskApproval, error) {
+	return s.listTaskApprovals(ctx, `WHERE status = $1 ORDER BY created_at ASC`, ApprovalPending)
+}
+
+// ListExpiredTaskApprovals returns the pending approvals that expired before now.
+func (s *store) ListExpiredTaskApprovals(ctx context.Context, now time.Time) ([]*TaskApproval, error) {
+	return s.listTaskApprovals(ctx, `WHERE status = $1 AND expires_at IS NOT NULL AND expires_at < $2 ORDER BY expires_at ASC`, ApprovalPending, now)
+}
+
+func (s *store) listTaskApprovals(ctx context.Context, where string, args ...any) ([]*TaskApproval, error) {
+	rows, err := s.Exec.QueryContext(ctx, `
+		SELECT id, execution_id, chain_id, task_id, prompt, value, vars, status, decision, expires_at, created_at, updated_at
+		FROM task_approvals `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []*TaskApproval{}
+	for rows.Next() {
+		var approval TaskApproval
+		if err := rows.Scan(
+			&approval.ID, &approval.ExecutionID, &approval.ChainID, &approval.TaskID, &approval.Prompt,
+			&approval.Value, &approval.Vars, &approval.Status, &approval.Decision, &approval.ExpiresAt,
+			&approval.CreatedAt, &approval.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, &approval)
+	}
+	return approvals, rows.Err()
+}
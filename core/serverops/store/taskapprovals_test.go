@@ -0,0 +1,48 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskApprovalLifecycle(t *testing.T) {
+	ctx, s := store.SetupStore(t)
+
+	expired := time.Now().UTC().Add(-time.Minute)
+	approvals := []*store.TaskApproval{
+		{ID: "approval-1", ExecutionID: "exec-1", TaskID: "approve", Value: []byte(`"draft"`), Vars: []byte(`{}`)},
+		{ID: "approval-2", ExecutionID: "exec-2", TaskID: "approve", Vars: []byte(`{}`), ExpiresAt: &expired},
+	}
+	for _, approval := range approvals {
+		require.NoError(t, s.CreateTaskApproval(ctx, approval))
+	}
+
+	pending, err := s.ListPendingTaskApprovals(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+
+	overdue, err := s.ListExpiredTaskApprovals(ctx, time.Now().UTC())
+	require.NoError(t, err)
+	require.Len(t, overdue, 1)
+	require.Equal(t, "approval-2", overdue[0].ID)
+
+	require.NoError(t, s.DecideTaskApproval(ctx, "approval-1", "approve", []byte(`{"action":"approve"}`)))
+	require.ErrorIs(t, s.DecideTaskApproval(ctx, "approval-1", "reject", []byte(`{"action":"reject"}`)), libdb.ErrNotFound)
+
+	got, err := s.GetTaskApproval(ctx, "approval-1")
+	require.NoError(t, err)
+	require.Equal(t, "approve", got.Status)
+	require.JSONEq(t, `"draft"`, string(got.Value))
+	require.JSONEq(t, `{"action":"approve"}`, string(got.Decision))
+
+	pending, err = s.ListPendingTaskApprovals(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	_, err = s.GetTaskApproval(ctx, "missing")
+	require.ErrorIs(t, err, libdb.ErrNotFound)
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/contenox/contenox/libs/libdb"
+)
+
+func (s *store) CreateTaskChain(ctx context.Context, chain *TaskChain) error {
+	now := time.Now().UTC()
+	chain.CreatedAt = now
+	chain.UpdatedAt = now
+
+	_, err := s.Exec.ExecContext(ctx, `
+		INSERT INTO task_chains
+		(id, description, active_version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		chain.ID, chain.Description, chain.ActiveVersion, chain.CreatedAt, chain.UpdatedAt,
+	)
+	return err
+}
+
+func (s *store) GetTaskChain(ctx context.Context, id string) (*TaskChain, error) {
+	var chain TaskChain
+	err := s.Exec.QueryRowContext(ctx, `
+		SELECT id, description, active_version, created_at, updated_at
+		FROM task_chains WHERE id = $1`, id,
+	).Scan(&chain.ID, &chain.Description, &chain.ActiveVersion, &chain.CreatedAt, &chain.UpdatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, libdb.ErrNotFound
+	}
+	return &chain, err
+}
+
+func (s *store) UpdateTaskChain(ctx context.Context, chain *TaskChain) error {
+	chain.UpdatedAt = time.Now().UTC()
+
+	result, err := s.Exec.ExecContext(ctx, `
+		UPDATE task_chains SET
+		description = $2, active_version = $3, updated_at = $4
+		WHERE id = $1`,
+		chain.ID, chain.Description, chain.ActiveVersion, chain.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task chain: %w", err)
+	}
+	return checkRowsAffected(result)
+}
+
+func (s *store) DeleteTaskChain(ctx context.Context, id string) error {
+	result, err := s.Exec.ExecContext(ctx, `
+		DELETE FROM task_chains WHERE id = $1`, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete task chain: %w", err)
+	}
+	return checkRowsAffected(result)
+}
+
+func (s *store) ListTaskChains(ctx context.Context) ([]*TaskChain, error) {
+	rows, err := s.Exec.QueryContext(ctx, `
+		SELECT id, description, active_version, created_at, updated_at
+		FROM task_chains ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	chains := []*TaskChain{}
+	for rows.Next() {
+		var chain TaskChain
+		if err := rows.Scan(&chain.ID, &chain.Description, &chain.ActiveVersion, &chain.CreatedAt, &chain.UpdatedAt); err != nil {
+			return nil, err
+		}
+		chains = append(chains, &chain)
+	}
+	return chains, rows.Err()
+}
+
+func (s *store) AppendTaskChainVersion(ctx context.Context, version *TaskChainVersion) error {
+	version.CreatedAt = time.Now().UTC()
+
+	_, err := s.Exec.ExecContext(ctx, `
+		INSERT INTO task_chain_versions
+		(chain_id, version, definition, created_at)
+		VALUES ($1, $2, $3, $4)`,
+		version.ChainID, version.Version, version.Definition, version.CreatedAt,
+	)
+	return err
+}
+
+func (s *store) GetTaskChainVersion(ctx context.Context, chainID string, version int) (*TaskChainVersion, error) {
+	var v TaskChainVersion
+	err := s.Exec.QueryRowContext(ctx, `
+		SELECT chain_id, version, definition, created_at
+		FROM task_chain_versions WHERE chain_id = $1 AND version = $2`, chainID, version,
+	).Scan(&v.ChainID, &v.Version, &v.Definition, &v.CreatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, libdb.ErrNotFound
+	}
+	return &v, err
+}
+
+// ListTaskChainVersions returns all versions of a chain, newest first.
+func (s *store) ListTaskChainVersions(ctx context.Context, chainID string) ([]*TaskChainVersion, error) {
+	rows, err := s.Exec.QueryContext(ctx, `
+		SELECT chain_id, version, definition, created_at
+		FROM task_chain_versions WHERE chain_id = $1
+		ORDER BY version DESC`, chainID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []*TaskChainVersion{}
+	for rows.Next() {
+		var v TaskChainVersion
+		if err := rows.Scan(&v.ChainID, &v.Version, &v.Definition, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, &v)
+	}
+	return versions, rows.Err()
+}
@@ -0,0 +1,82 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAndGetTaskChain(t *testing.T) {
+	ctx, s := store.SetupStore(t)
+
+	chain := &store.TaskChain{
+		ID:            "article-generator",
+		Description:   "Generates articles",
+		ActiveVersion: 1,
+	}
+	require.NoError(t, s.CreateTaskChain(ctx, chain))
+	require.NoError(t, s.AppendTaskChainVersion(ctx, &store.TaskChainVersion{
+		ChainID:    chain.ID,
+		Version:    1,
+		Definition: []byte(`{"id":"article-generator"}`),
+	}))
+
+	got, err := s.GetTaskChain(ctx, chain.ID)
+	require.NoError(t, err)
+	require.Equal(t, chain.Description, got.Description)
+	require.Equal(t, 1, got.ActiveVersion)
+
+	version, err := s.GetTaskChainVersion(ctx, chain.ID, 1)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"article-generator"}`, string(version.Definition))
+}
+
+func TestTaskChainVersions(t *testing.T) {
+	ctx, s := store.SetupStore(t)
+
+	chain := &store.TaskChain{ID: "versioned", ActiveVersion: 1}
+	require.NoError(t, s.CreateTaskChain(ctx, chain))
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, s.AppendTaskChainVersion(ctx, &store.TaskChainVersion{
+			ChainID:    chain.ID,
+			Version:    i,
+			Definition: []byte(`{}`),
+		}))
+	}
+
+	// Versions are immutable, appending the same version twice must fail.
+	err := s.AppendTaskChainVersion(ctx, &store.TaskChainVersion{ChainID: chain.ID, Version: 2, Definition: []byte(`{}`)})
+	require.Error(t, err)
+
+	versions, err := s.ListTaskChainVersions(ctx, chain.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	require.Equal(t, 3, versions[0].Version)
+
+	chain.ActiveVersion = 2
+	require.NoError(t, s.UpdateTaskChain(ctx, chain))
+	got, err := s.GetTaskChain(ctx, chain.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, got.ActiveVersion)
+}
+
+func TestDeleteTaskChainCascadesVersions(t *testing.T) {
+	ctx, s := store.SetupStore(t)
+
+	chain := &store.TaskChain{ID: "to-delete", ActiveVersion: 1}
+	require.NoError(t, s.CreateTaskChain(ctx, chain))
+	require.NoError(t, s.AppendTaskChainVersion(ctx, &store.TaskChainVersion{ChainID: chain.ID, Version: 1, Definition: []byte(`{}`)}))
+
+	require.NoError(t, s.DeleteTaskChain(ctx, chain.ID))
+
+	_, err := s.GetTaskChain(ctx, chain.ID)
+	require.ErrorIs(t, err, libdb.ErrNotFound)
+	_, err = s.GetTaskChainVersion(ctx, chain.ID, 1)
+	require.ErrorIs(t, err, libdb.ErrNotFound)
+
+	chains, err := s.ListTaskChains(ctx)
+	require.NoError(t, err)
+	require.Empty(t, chains)
+}
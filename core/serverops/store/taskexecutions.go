@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/contenox/contenox/libs/libdb"
+)
+
+func (s *store) CreateTaskExecution(ctx context.Context, execution *TaskExecution) error {
+	now := time.Now().UTC()
+	execution.CreatedAt = now
+	execution.UpdatedAt = now
+
+	_, err := s.Exec.ExecContext(ctx, `
+		INSERT INTO task_executions
+		(id, chain_id, chain, status, current_task, state, error, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		execution.ID, execution.ChainID, execution.Chain, execution.Status, execution.CurrentTask,
+		execution.State, execution.Error, execution.CreatedAt, execution.UpdatedAt,
+	)
+	return err
+}
+
+func (s *store) GetTaskExecution(ctx context.Context, id string) (*TaskExecution, error) {
+	var execution TaskExecution
+	err := s.Exec.QueryRowContext(ctx, `
+		SELECT id, chain_id, chain, status, current_task, state, error, created_at, updated_at
+		FROM task_executions WHERE id = $1`, id,
+	).Scan(
+		&execution.ID, &execution.ChainID, &execution.Chain, &execution.Status, &execution.CurrentTask,
+		&execution.State, &execution.Error, &execution.CreatedAt, &execution.UpdatedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, libdb.ErrNotFound
+	}
+	return &execution, err
+}
+
+// UpdateTaskExecution stores the progress of an execution, the chain definition is immutable.
+func (s *store) UpdateTaskExecution(ctx context.Context, execution *TaskExecution) error {
+	execution.UpdatedAt = time.Now().UTC()
+
+	result, err := s.Exec.ExecContext(ctx, `
+		UPDATE task_executions SET
+		status = $2, current_task = $3, state = $4, error = $5, updated_at = $6
+		WHERE id = $1`,
+		execution.ID, execution.Status, execution.CurrentTask, execution.State, execution.Error, execution.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task execution: %w", err)
+	}
+	return checkRowsAffected(result)
+}
+
+// ClaimTaskExecution marks a failed execution, or a running one that was not updated since staleBefore,
+// as running again and returns it. It returns libdb.ErrNotFound if the execution is not claimable,
+// so of concurrent claims only one succeeds.
+func (s *store) ClaimTaskExecution(ctx context.Context, id string, staleBefore time.Time) (*TaskExecution, error) {
+	var execution TaskExecution
+	err := s.Exec.QueryRowContext(ctx, `
+		UPDATE task_executions SET
+		status = 'running', updated_at = $3
+		WHERE id = $1 AND (status = 'failed' OR (status = 'running' AND updated_at < $2))
+		RETURNING id, chain_id, chain, status, current_task, state, error, created_at, updated_at`,
+		id, staleBefore.UTC(), time.Now().UTC(),
+	).Scan(
+		&execution.ID, &execution.ChainID, &execution.Chain, &execution.Status, &execution.CurrentTask,
+		&execution.State, &execution.Error, &execution.CreatedAt, &execution.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, libdb.ErrNotFound
+	}
+	return &execution, err
+}
+
+func (s *store) ListTaskExecutions(ctx context.Context, createdAtCursor *time.Time, limit int) ([]*TaskExecution, error) {
+	cursor := time.Now().UTC()
+	if createdAtCursor != nil {
+		cursor = *createdAtCursor
+	}
+	rows, err := s.Exec.QueryContext(ctx, `
+		SELECT id, chain_id, chain, status, current_task, state, error, created_at, updated_at
+		FROM task_executions
+		WHERE created_at < $1
+		ORDER BY created_at DESC
+		LIMIT $2`, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	executions := []*TaskExecution{}
+	for rows.Next() {
+		var execution TaskExecution
+		if err := rows.Scan(
+			&execution.ID, &execution.ChainID, &execution.Chain, &execution.Status, &execution.CurrentTask,
+			&execution.State, &execution.Error, &execution.CreatedAt, &execution.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		executions = append(executions, &execution)
+	}
+	return executions, rows.Err()
+}
+
+func (s *store) AppendTaskTraceEntry(ctx context.Context, entry *TaskTraceEntry) error {
+	_, err := s.Exec.ExecContext(ctx, `
+		INSERT INTO task_execution_traces
+		(id, execution_id, operation, subject, args, change_id, change, error, started_at, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		entry.ID, entry.ExecutionID, entry.Operation, entry.Subject, entry.Args,
+		entry.ChangeID, entry.Change, entry.Error, entry.StartedAt, entry.DurationMS,
+	)
+	return err
+}
+
+// ListTaskTraceEntries returns the trace of an execution in the order the operations started.
+func (s *store) ListTaskTraceEntries(ctx context.Context, executionID string) ([]*TaskTraceEntry, error) {
+	rows, err := s.Exec.QueryContext(ctx, `
+		SELECT id, execution_id, operation, subject, args, change_id, change, error, started_at, duration_ms
+		FROM task_execution_traces
+		WHERE execution_id = $1
+		ORDER BY started_at ASC`, executionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*TaskTraceEntry{}
+	for rows.Next() {
+		var entry TaskTraceEntry
+		if err := rows.Scan(
+			&entry.ID, &entry.ExecutionID, &entry.Operation, &entry.Subject, &entry.Args,
+			&entry.ChangeID, &entry.Change, &entry.Error, &entry.StartedAt, &entry.DurationMS,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,106 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskExecutionLifecycle(t *testing.T) {
+	ctx, s := store.SetupStore(t)
+
+	execution := &store.TaskExecution{
+		ID:          "exec-1",
+		ChainID:     "article-generator",
+		Chain:       []byte(`{"id":"article-generator"}`),
+		Status:      "running",
+		CurrentTask: "get_length",
+		State:       []byte(`{"vars":{"input":"go"}}`),
+	}
+	require.NoError(t, s.CreateTaskExecution(ctx, execution))
+
+	execution.Status = "completed"
+	execution.CurrentTask = ""
+	execution.State = []byte(`{"vars":{"input":"go","get_length":300}}`)
+	require.NoError(t, s.UpdateTaskExecution(ctx, execution))
+
+	got, err := s.GetTaskExecution(ctx, execution.ID)
+	require.NoError(t, err)
+	require.Equal(t, "completed", got.Status)
+	require.Empty(t, got.CurrentTask)
+	require.JSONEq(t, `{"vars":{"input":"go","get_length":300}}`, string(got.State))
+	require.JSONEq(t, `{"id":"article-generator"}`, string(got.Chain))
+
+	executions, err := s.ListTaskExecutions(ctx, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, executions, 1)
+
+	_, err = s.GetTaskExecution(ctx, "missing")
+	require.ErrorIs(t, err, libdb.ErrNotFound)
+	require.ErrorIs(t, s.UpdateTaskExecution(ctx, &store.TaskExecution{ID: "missing", State: []byte(`{}`)}), libdb.ErrNotFound)
+}
+
+func TestClaimTaskExecution(t *testing.T) {
+	ctx, s := store.SetupStore(t)
+
+	for id, status := range map[string]string{"failed": "failed", "running": "running", "completed": "completed"} {
+		require.NoError(t, s.CreateTaskExecution(ctx, &store.TaskExecution{
+			ID:     id,
+			Chain:  []byte(`{}`),
+			Status: status,
+			State:  []byte(`{}`),
+		}))
+	}
+
+	claimed, err := s.ClaimTaskExecution(ctx, "failed", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, "running", claimed.Status)
+	_, err = s.ClaimTaskExecution(ctx, "failed", time.Now().Add(-time.Minute))
+	require.ErrorIs(t, err, libdb.ErrNotFound, "a claimed execution must not be claimed twice")
+
+	_, err = s.ClaimTaskExecution(ctx, "running", time.Now().Add(-time.Minute))
+	require.ErrorIs(t, err, libdb.ErrNotFound)
+	_, err = s.ClaimTaskExecution(ctx, "running", time.Now().Add(time.Minute))
+	require.NoError(t, err, "a stale running execution is claimable")
+
+	_, err = s.ClaimTaskExecution(ctx, "completed", time.Now().Add(time.Minute))
+	require.ErrorIs(t, err, libdb.ErrNotFound)
+}
+
+func TestTaskTraceEntries(t *testing.T) {
+	ctx, s := store.SetupStore(t)
+
+	start := time.Now().UTC()
+	for i, op := range []string{"task_attempt", "transition"} {
+		require.NoError(t, s.AppendTaskTraceEntry(ctx, &store.TaskTraceEntry{
+			ID:          uuid.NewString(),
+			ExecutionID: "exec-1",
+			Operation:   op,
+			Subject:     "task1",
+			Args:        []byte(`{"retry":0}`),
+			StartedAt:   start.Add(time.Duration(i) * time.Millisecond),
+			DurationMS:  12,
+		}))
+	}
+	require.NoError(t, s.AppendTaskTraceEntry(ctx, &store.TaskTraceEntry{
+		ID:          uuid.NewString(),
+		ExecutionID: "exec-2",
+		Operation:   "task_attempt",
+		Subject:     "task1",
+		Args:        []byte(`{}`),
+		Change:      []byte(`{"output":"hi"}`),
+		StartedAt:   start,
+	}))
+
+	entries, err := s.ListTaskTraceEntries(ctx, "exec-1")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "task_attempt", entries[0].Operation)
+	require.Equal(t, "transition", entries[1].Operation)
+	require.Nil(t, entries[0].Change)
+	require.Equal(t, int64(12), entries[0].DurationMS)
+}
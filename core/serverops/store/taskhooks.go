@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/contenox/contenox/libs/libdb"
+)
+
+// Statuses of TaskHooks.
+const (
+	HookPending   = "pending"
+	HookSucceeded = "succeeded"
+	HookFailed    = "failed"
+)
+
+func (s *store) CreateTaskHook(ctx context.Context, hook *TaskHook) error {
+	now := time.Now().UTC()
+	hook.CreatedAt = now
+	hook.UpdatedAt = now
+	if hook.Status == "" {
+		hook.Status = HookPending
+	}
+
+	_, err := s.Exec.ExecContext(ctx, `
+		INSERT INTO task_hooks
+		(id, execution_id, chain_id, task_id, hook, status, output, error, attempts, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		hook.ID, hook.ExecutionID, hook.ChainID, hook.TaskID, hook.Hook,
+		hook.Status, hook.Output, hook.Error, hook.Attempts,
+		hook.CreatedAt, hook.UpdatedAt,
+	)
+	return err
+}
+
+func (s *store) GetTaskHook(ctx context.Context, id string) (*TaskHook, error) {
+	var hook TaskHook
+	err := s.Exec.QueryRowContext(ctx, `
+		SELECT id, execution_id, chain_id, task_id, hook, status, output, error, attempts, created_at, updated_at
+		FROM task_hooks WHERE id = $1`, id,
+	).Scan(
+		&hook.ID, &hook.ExecutionID, &hook.ChainID, &hook.TaskID, &hook.Hook,
+		&hook.Status, &hook.Output, &hook.Error, &hook.Attempts,
+		&hook.CreatedAt, &hook.UpdatedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, libdb.ErrNotFound
+	}
+	return &hook, err
+}
+
+// UpdateTaskHook stores the status, output, error and attempts of hook.
+func (s *store) UpdateTaskHook(ctx context.Context, hook *TaskHook) error {
+	hook.UpdatedAt = time.Now().UTC()
+	result, err := s.Exec.ExecContext(ctx, `
+		UPDATE task_hooks SET
+		status = $2, output = $3, error = $4, attempts = $5, updated_at = $6
+		WHERE id = $1`,
+		hook.ID, hook.Status, hook.Output, hook.Error, hook.Attempts, hook.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task hook: %w", err)
+	}
+	return checkRowsAffected(result)
+}
+
+// ListTaskHooks returns the hooks of an execution in the order they were queued.
+func (s *store) ListTaskHooks(ctx context.Context, executionID string) ([]*TaskHook, error) {
+	rows, err := s.Exec.QueryContext(ctx, `
+		SELECT id, execution_id, chain_id, task_id, hook, status, output, error, attempts, created_at, updated_at
+		FROM task_hooks WHERE execution_id = $1 ORDER BY created_at ASC`, executionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []*TaskHook{}
+	for rows.Next() {
+		var hook TaskHook
+		if err := rows.Scan(
+			&hook.ID, &hook.ExecutionID, &hook.ChainID, &hook.TaskID, &hook.Hook,
+			&hook.Status, &hook.Output, &hook.Error, &hook.Attempts,
+			&hook.CreatedAt, &hook.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, &hook)
+	}
+	return hooks, rows.Err()
+}
@@ -0,0 +1,48 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskHookLifecycle(t *testing.T) {
+	ctx, s := store.SetupStore(t)
+
+	hooks := []*store.TaskHook{
+		{ID: "hook-1", ExecutionID: "exec-1", TaskID: "notify", Hook: "webhook"},
+		{ID: "hook-2", ExecutionID: "exec-1", TaskID: "index", Hook: "rag"},
+		{ID: "hook-3", ExecutionID: "exec-2", TaskID: "notify", Hook: "webhook"},
+	}
+	for _, hook := range hooks {
+		require.NoError(t, s.CreateTaskHook(ctx, hook))
+	}
+
+	got, err := s.GetTaskHook(ctx, "hook-1")
+	require.NoError(t, err)
+	require.Equal(t, store.HookPending, got.Status)
+	require.Nil(t, got.Output)
+
+	got.Status = store.HookSucceeded
+	got.Output = []byte(`{"delivered":true}`)
+	got.Attempts = 2
+	require.NoError(t, s.UpdateTaskHook(ctx, got))
+
+	got, err = s.GetTaskHook(ctx, "hook-1")
+	require.NoError(t, err)
+	require.Equal(t, store.HookSucceeded, got.Status)
+	require.JSONEq(t, `{"delivered":true}`, string(got.Output))
+	require.Equal(t, 2, got.Attempts)
+
+	listed, err := s.ListTaskHooks(ctx, "exec-1")
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	require.Equal(t, "hook-1", listed[0].ID)
+	require.Equal(t, "hook-2", listed[1].ID)
+
+	_, err = s.GetTaskHook(ctx, "missing")
+	require.ErrorIs(t, err, libdb.ErrNotFound)
+	require.ErrorIs(t, s.UpdateTaskHook(ctx, &store.TaskHook{ID: "missing"}), libdb.ErrNotFound)
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Statuses of a TriggerRun.
+const (
+	TriggerRunRunning   = "running"
+	TriggerRunCompleted = "completed"
+	TriggerRunFailed    = "failed"
+)
+
+// ClaimTriggerRun records a run of a schedule trigger.
+// It returns libdb.ErrUniqueViolation if the run was already claimed, usually by another replica.
+func (s *store) ClaimTriggerRun(ctx context.Context, run *TriggerRun) error {
+	now := time.Now().UTC()
+	run.CreatedAt = now
+	run.UpdatedAt = now
+	run.ScheduledAt = run.ScheduledAt.UTC()
+	if run.Status == "" {
+		run.Status = TriggerRunRunning
+	}
+
+	_, err := s.Exec.ExecContext(ctx, `
+		INSERT INTO trigger_runs
+		(id, chain_id, schedule, scheduled_at, execution_id, status, error, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		run.ID, run.ChainID, run.Schedule, run.ScheduledAt, run.ExecutionID,
+		run.Status, run.Error, run.CreatedAt, run.UpdatedAt,
+	)
+	return err
+}
+
+func (s *store) FinishTriggerRun(ctx context.Context, id string, status string, errMsg string) error {
+	result, err := s.Exec.ExecContext(ctx, `
+		UPDATE trigger_runs SET
+		status = $2, error = $3, updated_at = $4
+		WHERE id = $1`,
+		id, status, errMsg, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish trigger run: %w", err)
+	}
+	return checkRowsAffected(result)
+}
+
+// ListTriggerRuns returns the latest runs, newest first. If chainID is empty the runs of all chains are returned.
+func (s *store) ListTriggerRuns(ctx context.Context, chainID string, limit int) ([]*TriggerRun, error) {
+	rows, err := s.Exec.QueryContext(ctx, `
+		SELECT id, chain_id, schedule, scheduled_at, execution_id, status, error, created_at, updated_at
+		FROM trigger_runs
+		WHERE $1 = '' OR chain_id = $1
+		ORDER BY scheduled_at DESC
+		LIMIT $2`, chainID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trigger runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []*TriggerRun{}
+	for rows.Next() {
+		var run TriggerRun
+		if err := rows.Scan(
+			&run.ID, &run.ChainID, &run.Schedule, &run.ScheduledAt, &run.ExecutionID,
+			&run.Status, &run.Error, &run.CreatedAt, &run.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan trigger run: %w", err)
+		}
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
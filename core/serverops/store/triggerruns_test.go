@@ -0,0 +1,42 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTriggerRunClaims(t *testing.T) {
+	ctx, s := store.SetupStore(t)
+
+	at := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	run := &store.TriggerRun{ID: "run-1", ChainID: "digest", Schedule: "0 9 * * *", ScheduledAt: at, ExecutionID: "exec-1"}
+	require.NoError(t, s.ClaimTriggerRun(ctx, run))
+	require.Equal(t, store.TriggerRunRunning, run.Status)
+
+	duplicate := &store.TriggerRun{ID: "run-2", ChainID: "digest", Schedule: "0 9 * * *", ScheduledAt: at, ExecutionID: "exec-2"}
+	require.ErrorIs(t, s.ClaimTriggerRun(ctx, duplicate), libdb.ErrUniqueViolation)
+
+	later := &store.TriggerRun{ID: "run-3", ChainID: "digest", Schedule: "0 9 * * *", ScheduledAt: at.Add(24 * time.Hour), ExecutionID: "exec-3"}
+	require.NoError(t, s.ClaimTriggerRun(ctx, later))
+	other := &store.TriggerRun{ID: "run-4", ChainID: "report", Schedule: "0 9 * * *", ScheduledAt: at, ExecutionID: "exec-4"}
+	require.NoError(t, s.ClaimTriggerRun(ctx, other))
+
+	require.NoError(t, s.FinishTriggerRun(ctx, "run-1", store.TriggerRunFailed, "boom"))
+	require.ErrorIs(t, s.FinishTriggerRun(ctx, "missing", store.TriggerRunCompleted, ""), libdb.ErrNotFound)
+
+	runs, err := s.ListTriggerRuns(ctx, "digest", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, "run-3", runs[0].ID)
+	require.Equal(t, "run-1", runs[1].ID)
+	require.Equal(t, store.TriggerRunFailed, runs[1].Status)
+	require.Equal(t, "boom", runs[1].Error)
+
+	runs, err = s.ListTriggerRuns(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+}
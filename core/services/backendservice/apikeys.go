@@ -0,0 +1,62 @@
+package backendservice
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/libs/libcipher"
+)
+
+// APIKeyCipher encrypts the API keys of backends at rest with AES-GCM.
+// Every key is bound to the ID of its backend.
+type APIKeyCipher struct {
+	encryptor libcipher.Encryptor
+	decryptor libcipher.Decryptor
+}
+
+// NewAPIKeyCipher returns the cipher of API keys under a key derived from encryptionKey.
+func NewAPIKeyCipher(encryptionKey string) (*APIKeyCipher, error) {
+	key := sha256.Sum256([]byte(encryptionKey))
+	encryptor, err := libcipher.NewGCMEncryptor(key[:], rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize api key encryption: %w", err)
+	}
+	decryptor, err := libcipher.NewGCMDecryptor(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize api key decryption: %w", err)
+	}
+	return &APIKeyCipher{encryptor: encryptor, decryptor: decryptor}, nil
+}
+
+// Seal encrypts the APIKey of backend into its EncryptedAPIKey, no key is stored for an empty one.
+func (c *APIKeyCipher) Seal(backend *store.Backend) error {
+	if backend.APIKey == "" {
+		backend.EncryptedAPIKey = nil
+		return nil
+	}
+	encrypted, err := c.encryptor.Crypt([]byte(backend.APIKey), []byte(backend.ID))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt api key: %w", err)
+	}
+	backend.EncryptedAPIKey = encrypted
+	return nil
+}
+
+// Open decrypts the EncryptedAPIKey of backend into its APIKey.
+func (c *APIKeyCipher) Open(backend *store.Backend) error {
+	if len(backend.EncryptedAPIKey) == 0 {
+		backend.APIKey = ""
+		return nil
+	}
+	key, additionalData, err := c.decryptor.Crypt(backend.EncryptedAPIKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt api key of backend %s: %w", backend.ID, err)
+	}
+	if string(additionalData) != backend.ID {
+		return fmt.Errorf("api key of backend %s was encrypted for another backend", backend.ID)
+	}
+	backend.APIKey = string(key)
+	return nil
+}
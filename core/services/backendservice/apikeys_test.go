@@ -0,0 +1,42 @@
+package backendservice_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/core/services/backendservice"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyCipher(t *testing.T) {
+	apiKeys, err := backendservice.NewAPIKeyCipher("0123456789abcdef")
+	require.NoError(t, err)
+
+	backend := &store.Backend{ID: "vllm", Type: store.BackendTypeOpenAI, APIKey: "secret-key"}
+	require.NoError(t, apiKeys.Seal(backend))
+	require.NotEmpty(t, backend.EncryptedAPIKey)
+	require.NotContains(t, string(backend.EncryptedAPIKey), "secret-key")
+
+	// Neither the key nor its encrypted form is ever encoded.
+	encoded, err := json.Marshal(backend)
+	require.NoError(t, err)
+	require.NotContains(t, string(encoded), "secret-key")
+	require.NotContains(t, string(encoded), "apiKey")
+
+	stored := &store.Backend{ID: "vllm", EncryptedAPIKey: backend.EncryptedAPIKey}
+	require.NoError(t, apiKeys.Open(stored))
+	require.Equal(t, "secret-key", stored.APIKey)
+
+	// A key copied to another backend does not decrypt.
+	copied := &store.Backend{ID: "other", EncryptedAPIKey: backend.EncryptedAPIKey}
+	require.ErrorContains(t, apiKeys.Open(copied), "encrypted for another backend")
+
+	other, err := backendservice.NewAPIKeyCipher("fedcba9876543210")
+	require.NoError(t, err)
+	require.Error(t, other.Open(stored))
+
+	backend.APIKey = ""
+	require.NoError(t, apiKeys.Seal(backend))
+	require.Nil(t, backend.EncryptedAPIKey)
+}
@@ -19,6 +19,7 @@ type Service interface {
 	Get(ctx context.Context, id string) (*store.Backend, error)
 	Update(ctx context.Context, backend *store.Backend) error
 	Delete(ctx context.Context, id string) error
+	ClearAPIKey(ctx context.Context, id string) error
 	List(ctx context.Context) ([]*store.Backend, error)
 	GetServiceName() string
 	GetServiceGroup() string
@@ -26,12 +27,14 @@ type Service interface {
 
 type service struct {
 	dbInstance      libdb.DBManager
+	apiKeys         *APIKeyCipher
 	securityEnabled bool
 	jwtSecret       string
 }
 
-func New(db libdb.DBManager) Service {
-	return &service{dbInstance: db}
+// New returns the backend service, API keys of backends are stored encrypted with apiKeys.
+func New(db libdb.DBManager, apiKeys *APIKeyCipher) Service {
+	return &service{dbInstance: db, apiKeys: apiKeys}
 }
 
 func (s *service) Create(ctx context.Context, backend *store.Backend) error {
@@ -42,6 +45,9 @@ func (s *service) Create(ctx context.Context, backend *store.Backend) error {
 	if err := validate(backend); err != nil {
 		return err
 	}
+	if err := s.apiKeys.Seal(backend); err != nil {
+		return err
+	}
 	return store.New(tx).CreateBackend(ctx, backend)
 }
 
@@ -61,6 +67,31 @@ func (s *service) Update(ctx context.Context, backend *store.Backend) error {
 	if err := serverops.CheckServiceAuthorization(ctx, store.New(tx), s, store.PermissionManage); err != nil {
 		return err
 	}
+	// The API does not return API keys, so an update without one keeps the stored key,
+	// see ClearAPIKey.
+	if backend.APIKey == "" {
+		current, err := store.New(tx).GetBackend(ctx, backend.ID)
+		if err != nil {
+			return err
+		}
+		backend.EncryptedAPIKey = current.EncryptedAPIKey
+	} else if err := s.apiKeys.Seal(backend); err != nil {
+		return err
+	}
+	return store.New(tx).UpdateBackend(ctx, backend)
+}
+
+// ClearAPIKey removes the API key of the backend id.
+func (s *service) ClearAPIKey(ctx context.Context, id string) error {
+	tx := s.dbInstance.WithoutTransaction()
+	if err := serverops.CheckServiceAuthorization(ctx, store.New(tx), s, store.PermissionManage); err != nil {
+		return err
+	}
+	backend, err := store.New(tx).GetBackend(ctx, id)
+	if err != nil {
+		return err
+	}
+	backend.EncryptedAPIKey = nil
 	return store.New(tx).UpdateBackend(ctx, backend)
 }
 
@@ -87,8 +118,8 @@ func validate(backend *store.Backend) error {
 	if backend.BaseURL == "" {
 		return fmt.Errorf("%w: baseURL is required", ErrInvalidBackend)
 	}
-	if backend.Type != "Ollama" {
-		return fmt.Errorf("%w: Type is required to be Ollama", ErrInvalidBackend)
+	if backend.Type != store.BackendTypeOllama && backend.Type != store.BackendTypeOpenAI {
+		return fmt.Errorf("%w: Type is required to be %s or %s", ErrInvalidBackend, store.BackendTypeOllama, store.BackendTypeOpenAI)
 	}
 
 	return nil
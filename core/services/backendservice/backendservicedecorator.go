@@ -97,6 +97,25 @@ func (d *activityTrackerDecorator) Delete(ctx context.Context, id string) error
 	return err
 }
 
+func (d *activityTrackerDecorator) ClearAPIKey(ctx context.Context, id string) error {
+	reportErrFn, reportChangeFn, endFn := d.tracker.Start(
+		ctx,
+		"clear_api_key",
+		"backend",
+		"backendID", id,
+	)
+	defer endFn()
+
+	err := d.service.ClearAPIKey(ctx, id)
+	if err != nil {
+		reportErrFn(err)
+	} else {
+		reportChangeFn(id, nil)
+	}
+
+	return err
+}
+
 func (d *activityTrackerDecorator) List(ctx context.Context) ([]*store.Backend, error) {
 	reportErrFn, _, endFn := d.tracker.Start(ctx, "list", "backends")
 	defer endFn()
@@ -0,0 +1,300 @@
+package chainservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libdb"
+)
+
+var (
+	ErrInvalidChain = errors.New("invalid chain definition")
+	ErrNotFound     = libdb.ErrNotFound
+)
+
+// Service is the registry for stored chain definitions.
+// Every change to a chain appends a new immutable version, the chain itself
+// only points to the version that is currently active.
+type Service interface {
+	Create(ctx context.Context, chain *taskengine.ChainDefinition) (*store.TaskChain, error)
+	Get(ctx context.Context, id string) (*taskengine.ChainDefinition, error)
+	GetVersion(ctx context.Context, id string, version int) (*taskengine.ChainDefinition, error)
+	Update(ctx context.Context, chain *taskengine.ChainDefinition) (*store.TaskChain, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]*store.TaskChain, error)
+	ListVersions(ctx context.Context, id string) ([]*ChainVersion, error)
+	Rollback(ctx context.Context, id string, version int) (*store.TaskChain, error)
+	Validate(ctx context.Context, chain *taskengine.ChainDefinition) (*taskengine.ValidationResult, error)
+	serverops.ServiceMeta
+}
+
+// ChainVersion is a single immutable revision of a stored chain.
+type ChainVersion struct {
+	Version   int                         `json:"version"`
+	Active    bool                        `json:"active"`
+	Chain     *taskengine.ChainDefinition `json:"chain"`
+	CreatedAt time.Time                   `json:"createdAt"`
+}
+
+type service struct {
+	dbInstance   libdb.DBManager
+	hookRegistry taskengine.HookRegistry
+}
+
+func New(db libdb.DBManager, hookRegistry taskengine.HookRegistry) Service {
+	return &service{
+		dbInstance:   db,
+		hookRegistry: hookRegistry,
+	}
+}
+
+func (s *service) Create(ctx context.Context, chain *taskengine.ChainDefinition) (*store.TaskChain, error) {
+	if err := s.check(ctx, chain); err != nil {
+		return nil, err
+	}
+	definition, err := json.Marshal(chain)
+	if err != nil {
+		return nil, err
+	}
+	tx, com, end, err := s.dbInstance.WithTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionManage); err != nil {
+		return nil, err
+	}
+	record := &store.TaskChain{
+		ID:            chain.ID,
+		Description:   chain.Description,
+		ActiveVersion: 1,
+	}
+	if err := storeInstance.CreateTaskChain(ctx, record); err != nil {
+		return nil, err
+	}
+	if err := storeInstance.AppendTaskChainVersion(ctx, &store.TaskChainVersion{
+		ChainID:    chain.ID,
+		Version:    record.ActiveVersion,
+		Definition: definition,
+	}); err != nil {
+		return nil, err
+	}
+	return record, com(ctx)
+}
+
+func (s *service) Get(ctx context.Context, id string) (*taskengine.ChainDefinition, error) {
+	tx := s.dbInstance.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	record, err := storeInstance.GetTaskChain(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	version, err := storeInstance.GetTaskChainVersion(ctx, id, record.ActiveVersion)
+	if err != nil {
+		return nil, err
+	}
+	return decode(version)
+}
+
+func (s *service) GetVersion(ctx context.Context, id string, version int) (*taskengine.ChainDefinition, error) {
+	tx := s.dbInstance.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	v, err := storeInstance.GetTaskChainVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	return decode(v)
+}
+
+// Update stores the given definition as a new version and makes it the active one.
+func (s *service) Update(ctx context.Context, chain *taskengine.ChainDefinition) (*store.TaskChain, error) {
+	if err := s.check(ctx, chain); err != nil {
+		return nil, err
+	}
+	definition, err := json.Marshal(chain)
+	if err != nil {
+		return nil, err
+	}
+	tx, com, end, err := s.dbInstance.WithTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionManage); err != nil {
+		return nil, err
+	}
+	record, err := storeInstance.GetTaskChain(ctx, chain.ID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := storeInstance.ListTaskChainVersions(ctx, chain.ID)
+	if err != nil {
+		return nil, err
+	}
+	next := 1
+	if len(versions) > 0 {
+		next = versions[0].Version + 1
+	}
+	if err := storeInstance.AppendTaskChainVersion(ctx, &store.TaskChainVersion{
+		ChainID:    chain.ID,
+		Version:    next,
+		Definition: definition,
+	}); err != nil {
+		return nil, err
+	}
+	record.ActiveVersion = next
+	record.Description = chain.Description
+	if err := storeInstance.UpdateTaskChain(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, com(ctx)
+}
+
+func (s *service) Delete(ctx context.Context, id string) error {
+	tx := s.dbInstance.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionManage); err != nil {
+		return err
+	}
+	return storeInstance.DeleteTaskChain(ctx, id)
+}
+
+func (s *service) List(ctx context.Context) ([]*store.TaskChain, error) {
+	tx := s.dbInstance.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	return storeInstance.ListTaskChains(ctx)
+}
+
+func (s *service) ListVersions(ctx context.Context, id string) ([]*ChainVersion, error) {
+	tx := s.dbInstance.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	record, err := storeInstance.GetTaskChain(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := storeInstance.ListTaskChainVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*ChainVersion, 0, len(versions))
+	for _, v := range versions {
+		chain, err := decode(v)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &ChainVersion{
+			Version:   v.Version,
+			Active:    v.Version == record.ActiveVersion,
+			Chain:     chain,
+			CreatedAt: v.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// Rollback makes a previously stored version the active one again.
+// No new version is created, the history stays untouched.
+func (s *service) Rollback(ctx context.Context, id string, version int) (*store.TaskChain, error) {
+	tx, com, end, err := s.dbInstance.WithTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionManage); err != nil {
+		return nil, err
+	}
+	record, err := storeInstance.GetTaskChain(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	v, err := storeInstance.GetTaskChainVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := decode(v)
+	if err != nil {
+		return nil, err
+	}
+	record.ActiveVersion = v.Version
+	record.Description = chain.Description
+	if err := storeInstance.UpdateTaskChain(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, com(ctx)
+}
+
+// Validate statically checks the chain graph without storing or executing it.
+func (s *service) Validate(ctx context.Context, chain *taskengine.ChainDefinition) (*taskengine.ValidationResult, error) {
+	tx := s.dbInstance.WithoutTransaction()
+	if err := serverops.CheckServiceAuthorization(ctx, store.New(tx), s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	return taskengine.Validate(ctx, chain, s.hookRegistry)
+}
+
+// check rejects chains that would fail at runtime, warnings are accepted.
+func (s *service) check(ctx context.Context, chain *taskengine.ChainDefinition) error {
+	if err := validate(chain); err != nil {
+		return err
+	}
+	result, err := taskengine.Validate(ctx, chain, s.hookRegistry)
+	if err != nil {
+		return err
+	}
+	for _, issue := range result.Issues {
+		if issue.Severity == taskengine.SeverityError {
+			return fmt.Errorf("%w: task %q: %s", ErrInvalidChain, issue.TaskID, issue.Message)
+		}
+	}
+	return nil
+}
+
+func (s *service) GetServiceName() string {
+	return "chainservice"
+}
+
+func (s *service) GetServiceGroup() string {
+	return serverops.DefaultDefaultServiceGroup
+}
+
+func validate(chain *taskengine.ChainDefinition) error {
+	if chain == nil {
+		return fmt.Errorf("%w: chain is required", ErrInvalidChain)
+	}
+	if chain.ID == "" {
+		return fmt.Errorf("%w: chain id is required: %w", ErrInvalidChain, serverops.ErrMissingParameter)
+	}
+	if len(chain.Tasks) == 0 {
+		return fmt.Errorf("%w: chain %s has no tasks", ErrInvalidChain, chain.ID)
+	}
+	return nil
+}
+
+func decode(version *store.TaskChainVersion) (*taskengine.ChainDefinition, error) {
+	var chain taskengine.ChainDefinition
+	if err := json.Unmarshal(version.Definition, &chain); err != nil {
+		return nil, fmt.Errorf("failed to decode chain %s version %d: %w", version.ChainID, version.Version, err)
+	}
+	return &chain, nil
+}
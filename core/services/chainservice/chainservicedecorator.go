@@ -0,0 +1,202 @@
+package chainservice
+
+import (
+	"context"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/core/taskengine"
+)
+
+type activityTrackerDecorator struct {
+	service Service
+	tracker serverops.ActivityTracker
+}
+
+func (d *activityTrackerDecorator) Create(ctx context.Context, chain *taskengine.ChainDefinition) (*store.TaskChain, error) {
+	reportErrFn, reportChangeFn, endFn := d.tracker.Start(
+		ctx,
+		"create",
+		"chain",
+		"chainID", chain.ID,
+		"tasks", len(chain.Tasks),
+	)
+	defer endFn()
+
+	record, err := d.service.Create(ctx, chain)
+	if err != nil {
+		reportErrFn(err)
+	} else {
+		reportChangeFn(record.ID, map[string]interface{}{
+			"version": record.ActiveVersion,
+		})
+	}
+
+	return record, err
+}
+
+func (d *activityTrackerDecorator) Get(ctx context.Context, id string) (*taskengine.ChainDefinition, error) {
+	reportErrFn, _, endFn := d.tracker.Start(
+		ctx,
+		"read",
+		"chain",
+		"chainID", id,
+	)
+	defer endFn()
+
+	chain, err := d.service.Get(ctx, id)
+	if err != nil {
+		reportErrFn(err)
+	}
+
+	return chain, err
+}
+
+func (d *activityTrackerDecorator) GetVersion(ctx context.Context, id string, version int) (*taskengine.ChainDefinition, error) {
+	reportErrFn, _, endFn := d.tracker.Start(
+		ctx,
+		"read",
+		"chain-version",
+		"chainID", id,
+		"version", version,
+	)
+	defer endFn()
+
+	chain, err := d.service.GetVersion(ctx, id, version)
+	if err != nil {
+		reportErrFn(err)
+	}
+
+	return chain, err
+}
+
+func (d *activityTrackerDecorator) Update(ctx context.Context, chain *taskengine.ChainDefinition) (*store.TaskChain, error) {
+	reportErrFn, reportChangeFn, endFn := d.tracker.Start(
+		ctx,
+		"update",
+		"chain",
+		"chainID", chain.ID,
+		"tasks", len(chain.Tasks),
+	)
+	defer endFn()
+
+	record, err := d.service.Update(ctx, chain)
+	if err != nil {
+		reportErrFn(err)
+	} else {
+		reportChangeFn(record.ID, map[string]interface{}{
+			"version": record.ActiveVersion,
+		})
+	}
+
+	return record, err
+}
+
+func (d *activityTrackerDecorator) Delete(ctx context.Context, id string) error {
+	reportErrFn, reportChangeFn, endFn := d.tracker.Start(
+		ctx,
+		"delete",
+		"chain",
+		"chainID", id,
+	)
+	defer endFn()
+
+	err := d.service.Delete(ctx, id)
+	if err != nil {
+		reportErrFn(err)
+	} else {
+		reportChangeFn(id, nil)
+	}
+
+	return err
+}
+
+func (d *activityTrackerDecorator) List(ctx context.Context) ([]*store.TaskChain, error) {
+	reportErrFn, _, endFn := d.tracker.Start(
+		ctx,
+		"list",
+		"chains",
+	)
+	defer endFn()
+
+	chains, err := d.service.List(ctx)
+	if err != nil {
+		reportErrFn(err)
+	}
+
+	return chains, err
+}
+
+func (d *activityTrackerDecorator) ListVersions(ctx context.Context, id string) ([]*ChainVersion, error) {
+	reportErrFn, _, endFn := d.tracker.Start(
+		ctx,
+		"list",
+		"chain-versions",
+		"chainID", id,
+	)
+	defer endFn()
+
+	versions, err := d.service.ListVersions(ctx, id)
+	if err != nil {
+		reportErrFn(err)
+	}
+
+	return versions, err
+}
+
+func (d *activityTrackerDecorator) Rollback(ctx context.Context, id string, version int) (*store.TaskChain, error) {
+	reportErrFn, reportChangeFn, endFn := d.tracker.Start(
+		ctx,
+		"rollback",
+		"chain",
+		"chainID", id,
+		"version", version,
+	)
+	defer endFn()
+
+	record, err := d.service.Rollback(ctx, id, version)
+	if err != nil {
+		reportErrFn(err)
+	} else {
+		reportChangeFn(record.ID, map[string]interface{}{
+			"version": record.ActiveVersion,
+		})
+	}
+
+	return record, err
+}
+
+func (d *activityTrackerDecorator) Validate(ctx context.Context, chain *taskengine.ChainDefinition) (*taskengine.ValidationResult, error) {
+	reportErrFn, _, endFn := d.tracker.Start(
+		ctx,
+		"validate",
+		"chain",
+		"chainID", chain.ID,
+		"tasks", len(chain.Tasks),
+	)
+	defer endFn()
+
+	result, err := d.service.Validate(ctx, chain)
+	if err != nil {
+		reportErrFn(err)
+	}
+
+	return result, err
+}
+
+func (d *activityTrackerDecorator) GetServiceName() string {
+	return d.service.GetServiceName()
+}
+
+func (d *activityTrackerDecorator) GetServiceGroup() string {
+	return d.service.GetServiceGroup()
+}
+
+func WithActivityTracker(service Service, tracker serverops.ActivityTracker) Service {
+	return &activityTrackerDecorator{
+		service: service,
+		tracker: tracker,
+	}
+}
+
+var _ Service = (*activityTrackerDecorator)(nil)
@@ -0,0 +1,32 @@
+package chainservice
+
+import (
+	"context"
+
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libdb"
+)
+
+type resolver struct {
+	db libdb.DBManager
+}
+
+// NewChainResolver returns a taskengine.ChainResolver that loads the active version of stored chains.
+// It performs no authorization checks, it only serves chains to executions that were already authorized.
+func NewChainResolver(db libdb.DBManager) taskengine.ChainResolver {
+	return &resolver{db: db}
+}
+
+func (r *resolver) GetChain(ctx context.Context, id string) (*taskengine.ChainDefinition, error) {
+	storeInstance := store.New(r.db.WithoutTransaction())
+	record, err := storeInstance.GetTaskChain(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	version, err := storeInstance.GetTaskChainVersion(ctx, id, record.ActiveVersion)
+	if err != nil {
+		return nil, err
+	}
+	return decode(version)
+}
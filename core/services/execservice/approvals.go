@@ -0,0 +1,177 @@
+package execservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libbus"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/google/uuid"
+)
+
+// ErrApprovalDecided is returned when deciding an approval that was already decided or expired.
+var ErrApprovalDecided = errors.New("approval already decided")
+
+// ApprovalRequestedSubject is the libbus subject on which new approvals are announced.
+// The payload is the JSON encoded Approval.
+const ApprovalRequestedSubject = "task.approval.requested"
+
+// Approval is an approval requested by an Approval task.
+type Approval struct {
+	ID          string                       `json:"id"`
+	ExecutionID string                       `json:"executionId"`
+	ChainID     string                       `json:"chainId"`
+	TaskID      string                       `json:"taskId"`
+	Prompt      string                       `json:"prompt"`
+	Value       any                          `json:"value"`
+	Vars        map[string]any               `json:"vars"`
+	Status      string                       `json:"status"`
+	Decision    *taskengine.ApprovalDecision `json:"decision,omitempty"`
+	ExpiresAt   *time.Time                   `json:"expiresAt,omitempty"`
+	CreatedAt   time.Time                    `json:"createdAt"`
+	UpdatedAt   time.Time                    `json:"updatedAt"`
+}
+
+type approvalGate struct {
+	db libdb.DBManager
+	ps libbus.Messenger
+}
+
+// NewApprovalGate returns a taskengine.ApprovalGate that stores pending approvals
+// in the task_approvals table and announces them on ApprovalRequestedSubject.
+// It performs no authorization checks, access to approvals is guarded by the TasksEnvService.
+func NewApprovalGate(db libdb.DBManager, ps libbus.Messenger) taskengine.ApprovalGate {
+	return &approvalGate{db: db, ps: ps}
+}
+
+func (g *approvalGate) RequestApproval(ctx context.Context, req *taskengine.ApprovalRequest) error {
+	value, err := json.Marshal(req.Value)
+	if err != nil {
+		return fmt.Errorf("failed to encode approval value: %w", err)
+	}
+	vars, err := json.Marshal(req.Vars)
+	if err != nil {
+		return fmt.Errorf("failed to encode approval vars: %w", err)
+	}
+	record := &store.TaskApproval{
+		ID:          uuid.NewString(),
+		ExecutionID: req.ExecutionID,
+		ChainID:     req.ChainID,
+		TaskID:      req.TaskID,
+		Prompt:      req.Prompt,
+		Value:       value,
+		Vars:        vars,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if err := store.New(g.db.WithoutTransaction()).CreateTaskApproval(ctx, record); err != nil {
+		return err
+	}
+	approval, err := toApproval(record)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(approval)
+	if err != nil {
+		return fmt.Errorf("failed to encode approval: %w", err)
+	}
+	// The approval is stored, a lost notification leaves it listed in GET /approvals.
+	if err := g.ps.Publish(ctx, ApprovalRequestedSubject, payload); err != nil {
+		log.Printf("failed to announce approval %s: %v", record.ID, err)
+	}
+	return nil
+}
+
+// NewApprovalExpiryCycle returns an operation for a libroutine loop that expires
+// overdue approvals, so their executions follow the on_error transition of the Approval task.
+func NewApprovalExpiryCycle(db libdb.DBManager, environmentExec taskengine.EnvExecutor) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		storeInstance := store.New(db.WithoutTransaction())
+		overdue, err := storeInstance.ListExpiredTaskApprovals(ctx, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		for _, approval := range overdue {
+			decision := &taskengine.ApprovalDecision{Action: taskengine.ApprovalExpire}
+			_, err := resumeApproval(ctx, storeInstance, environmentExec, approval, decision)
+			if err != nil && !errors.Is(err, taskengine.ErrExecutionPaused) {
+				log.Printf("approval %s expired, execution %s: %v", approval.ID, approval.ExecutionID, err)
+			}
+		}
+		return nil
+	}
+}
+
+// resumeApproval records the decision of an approval and resumes its execution.
+func resumeApproval(ctx context.Context, storeInstance store.Store, environmentExec taskengine.EnvExecutor, approval *store.TaskApproval, decision *taskengine.ApprovalDecision) (any, error) {
+	if approval.Status != store.ApprovalPending {
+		return nil, fmt.Errorf("approval %s: %w: %w", approval.ID, ErrApprovalDecided, serverops.ErrInvalidParameterValue)
+	}
+	execution, err := storeInstance.GetTaskExecution(ctx, approval.ExecutionID)
+	if err != nil {
+		return nil, err
+	}
+	state, err := fromExecution(execution)
+	if err != nil {
+		return nil, err
+	}
+	if state.Status != taskengine.ExecutionWaiting || state.CurrentTask != approval.TaskID {
+		return nil, fmt.Errorf("execution %s is not waiting for approval %s: %w", state.ID, approval.ID, serverops.ErrInvalidParameterValue)
+	}
+	var chain taskengine.ChainDefinition
+	if err := json.Unmarshal(execution.Chain, &chain); err != nil {
+		return nil, fmt.Errorf("failed to decode chain of execution %s: %w", state.ID, err)
+	}
+
+	encoded, err := json.Marshal(decision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode decision: %w", err)
+	}
+	// Only one decision can win, a concurrent one finds the approval no longer pending.
+	if err := storeInstance.DecideTaskApproval(ctx, approval.ID, string(decision.Action), encoded); err != nil {
+		if errors.Is(err, libdb.ErrNotFound) {
+			return nil, fmt.Errorf("approval %s: %w: %w", approval.ID, ErrApprovalDecided, serverops.ErrInvalidParameterValue)
+		}
+		return nil, err
+	}
+
+	state.Decision = decision
+	return environmentExec.ResumeEnv(ctx, &chain, state)
+}
+
+func toApproval(record *store.TaskApproval) (*Approval, error) {
+	approval := &Approval{
+		ID:          record.ID,
+		ExecutionID: record.ExecutionID,
+		ChainID:     record.ChainID,
+		TaskID:      record.TaskID,
+		Prompt:      record.Prompt,
+		Status:      record.Status,
+		ExpiresAt:   record.ExpiresAt,
+		CreatedAt:   record.CreatedAt,
+		UpdatedAt:   record.UpdatedAt,
+	}
+	if len(record.Value) > 0 {
+		if err := json.Unmarshal(record.Value, &approval.Value); err != nil {
+			return nil, fmt.Errorf("failed to decode value of approval %s: %w", record.ID, err)
+		}
+	}
+	if len(record.Vars) > 0 {
+		if err := json.Unmarshal(record.Vars, &approval.Vars); err != nil {
+			return nil, fmt.Errorf("failed to decode vars of approval %s: %w", record.ID, err)
+		}
+	}
+	if len(record.Decision) > 0 {
+		approval.Decision = &taskengine.ApprovalDecision{}
+		if err := json.Unmarshal(record.Decision, approval.Decision); err != nil {
+			return nil, fmt.Errorf("failed to decode decision of approval %s: %w", record.ID, err)
+		}
+	}
+	return approval, nil
+}
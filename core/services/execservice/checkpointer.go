@@ -0,0 +1,76 @@
+package execservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libdb"
+)
+
+type checkpointer struct {
+	db libdb.DBManager
+}
+
+// NewCheckpointer returns a taskengine.Checkpointer that persists executions in the task_executions table.
+// It performs no authorization checks, access to executions is guarded by the TasksEnvService.
+func NewCheckpointer(db libdb.DBManager) taskengine.Checkpointer {
+	return &checkpointer{db: db}
+}
+
+func (c *checkpointer) Begin(ctx context.Context, chain *taskengine.ChainDefinition, state *taskengine.ExecutionState) error {
+	definition, err := json.Marshal(chain)
+	if err != nil {
+		return fmt.Errorf("failed to encode chain: %w", err)
+	}
+	execution, err := toExecution(state)
+	if err != nil {
+		return err
+	}
+	execution.Chain = definition
+	if err := store.New(c.db.WithoutTransaction()).CreateTaskExecution(ctx, execution); err != nil {
+		return err
+	}
+	state.CreatedAt = execution.CreatedAt
+	state.UpdatedAt = execution.UpdatedAt
+	return nil
+}
+
+func (c *checkpointer) Checkpoint(ctx context.Context, state *taskengine.ExecutionState) error {
+	execution, err := toExecution(state)
+	if err != nil {
+		return err
+	}
+	if err := store.New(c.db.WithoutTransaction()).UpdateTaskExecution(ctx, execution); err != nil {
+		return err
+	}
+	state.UpdatedAt = execution.UpdatedAt
+	return nil
+}
+
+func toExecution(state *taskengine.ExecutionState) (*store.TaskExecution, error) {
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode execution state: %w", err)
+	}
+	return &store.TaskExecution{
+		ID:          state.ID,
+		ChainID:     state.ChainID,
+		Status:      string(state.Status),
+		CurrentTask: state.CurrentTask,
+		State:       encoded,
+		Error:       state.Error,
+	}, nil
+}
+
+func fromExecution(execution *store.TaskExecution) (*taskengine.ExecutionState, error) {
+	var state taskengine.ExecutionState
+	if err := json.Unmarshal(execution.State, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode execution %s: %w", execution.ID, err)
+	}
+	state.CreatedAt = execution.CreatedAt
+	state.UpdatedAt = execution.UpdatedAt
+	return &state, nil
+}
@@ -0,0 +1,255 @@
+package execservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/google/uuid"
+)
+
+// HookJobType is the job type of the hooks queued by non-blocking Hook tasks.
+const HookJobType = "task_hook"
+
+// DefaultHookRetries is how often a failed hook job is queued again before its hook is marked as failed.
+const DefaultHookRetries = 3
+
+// hookLeaseDuration is how long a hook worker leases a job.
+const hookLeaseDuration = time.Minute
+
+// hookRetryBackoff is how long a failed hook job waits before its first retry,
+// the wait doubles with every further retry.
+const hookRetryBackoff = 30 * time.Second
+
+// HookResult is a hook queued by a non-blocking Hook task.
+type HookResult struct {
+	ID          string    `json:"id"`
+	ExecutionID string    `json:"executionId"`
+	ChainID     string    `json:"chainId"`
+	TaskID      string    `json:"taskId"`
+	Hook        string    `json:"hook"`
+	Status      string    `json:"status"`
+	Output      any       `json:"output,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Attempts    int       `json:"attempts"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type hookQueue struct {
+	db libdb.DBManager
+}
+
+// NewHookQueue returns a taskengine.HookQueue that stores every hook as a pending task hook
+// and a job of HookJobType, which is run by the loop of NewHookWorkerCycle.
+func NewHookQueue(db libdb.DBManager) taskengine.HookQueue {
+	return &hookQueue{db: db}
+}
+
+func (q *hookQueue) Enqueue(ctx context.Context, job *taskengine.HookJob) (string, error) {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode hook job: %w", err)
+	}
+	tx, commit, end, err := q.db.WithTransaction(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer end()
+	storeInstance := store.New(tx)
+	hook := &store.TaskHook{
+		ID:          uuid.NewString(),
+		ExecutionID: job.ExecutionID,
+		ChainID:     job.ChainID,
+		TaskID:      job.TaskID,
+		Hook:        job.Hook.Type,
+	}
+	if err := storeInstance.CreateTaskHook(ctx, hook); err != nil {
+		return "", err
+	}
+	err = storeInstance.AppendJob(ctx, store.Job{
+		ID:         hook.ID,
+		TaskType:   HookJobType,
+		Operation:  "hook",
+		Subject:    job.Hook.Type,
+		EntityID:   job.ExecutionID,
+		EntityType: "task_execution",
+		Payload:    payload,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := commit(ctx); err != nil {
+		return "", err
+	}
+	return hook.ID, nil
+}
+
+// NewHookWorkerCycle returns an operation for a libroutine loop that runs the queued hooks with hooks.
+//
+// Jobs are leased like dispatched jobs. Jobs whose lease expired, because their worker stopped
+// while running them, are queued again at the start of every cycle. A failed job is queued again
+// with an increased retry count and an exponential backoff, see hookRetryBackoff, until it failed
+// DefaultHookRetries times, then its hook is marked as failed.
+// Every cycle attempts each job queued when it started at most once.
+// Every attempt is recorded with tracker as the "async_hook" operation of the execution that queued it.
+func NewHookWorkerCycle(db libdb.DBManager, hooks taskengine.HookRepo, secrets taskengine.SecretResolver, tracker serverops.ActivityTracker) func(ctx context.Context) error {
+	leaser := "hook-worker-" + uuid.NewString()
+	return func(ctx context.Context) error {
+		if err := requeueExpiredHookJobs(ctx, db); err != nil {
+			return err
+		}
+		// Jobs failing in this cycle are queued behind the pending ones,
+		// so every job is attempted at most once per cycle.
+		pending, err := store.New(db.WithoutTransaction()).GetJobsForType(ctx, HookJobType)
+		if err != nil {
+			return err
+		}
+		for range pending {
+			job, err := leaseHookJob(ctx, db, leaser)
+			if errors.Is(err, libdb.ErrNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := runHookJob(ctx, db, hooks, secrets, tracker, job); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// requeueExpiredHookJobs queues the hook jobs whose lease expired again, counting the lost attempt as a retry.
+func requeueExpiredHookJobs(ctx context.Context, db libdb.DBManager) error {
+	tx, commit, end, err := db.WithTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer end()
+	storeInstance := store.New(tx)
+	expired, err := storeInstance.PopExpiredLeasedJobs(ctx, HookJobType)
+	if err != nil {
+		return err
+	}
+	for _, job := range expired {
+		job.RetryCount++
+		if err := storeInstance.AppendJob(ctx, *job); err != nil {
+			return err
+		}
+	}
+	return commit(ctx)
+}
+
+// leaseHookJob takes the oldest hook job that is due from the queue and leases it to leaser.
+func leaseHookJob(ctx context.Context, db libdb.DBManager, leaser string) (*store.Job, error) {
+	tx, commit, end, err := db.WithTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	storeInstance := store.New(tx)
+	job, err := storeInstance.PopDueJobForType(ctx, HookJobType)
+	if err != nil {
+		return nil, err
+	}
+	if err := storeInstance.AppendLeasedJob(ctx, *job, hookLeaseDuration, leaser); err != nil {
+		return nil, err
+	}
+	if err := commit(ctx); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// runHookJob runs a leased hook job and records its outcome.
+func runHookJob(ctx context.Context, db libdb.DBManager, hooks taskengine.HookRepo, secrets taskengine.SecretResolver, tracker serverops.ActivityTracker, job *store.Job) error {
+	storeInstance := store.New(db.WithoutTransaction())
+	hook, err := storeInstance.GetTaskHook(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	hook.Attempts++
+
+	var hookJob taskengine.HookJob
+	var output any
+	runErr := json.Unmarshal(job.Payload, &hookJob)
+	if runErr == nil {
+		traceCtx := taskengine.WithExecutionID(ctx, hookJob.ExecutionID)
+		reportErr, reportChange, end := tracker.Start(traceCtx, "async_hook", hookJob.TaskID,
+			"hook", hookJob.Hook.Type, "hook_id", hook.ID, "attempt", hook.Attempts)
+		hookCtx, cancel := context.WithTimeout(ctx, hookLeaseDuration)
+		output, runErr = taskengine.RunHookJob(hookCtx, hooks, secrets, &hookJob)
+		cancel()
+		if runErr != nil {
+			reportErr(runErr)
+		} else {
+			reportChange(hook.ID, output)
+		}
+		end()
+	} else {
+		runErr = fmt.Errorf("invalid hook job: %w", runErr)
+	}
+
+	tx, commit, end, err := db.WithTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer end()
+	storeInstance = store.New(tx)
+	if err := storeInstance.DeleteLeasedJob(ctx, job.ID); err != nil {
+		return err
+	}
+	switch {
+	case runErr == nil:
+		hook.Status = store.HookSucceeded
+		hook.Error = ""
+		if hook.Output, err = json.Marshal(output); err != nil {
+			hook.Output, _ = json.Marshal(fmt.Sprint(output))
+		}
+	case job.RetryCount < DefaultHookRetries:
+		hook.Error = runErr.Error()
+		job.RetryCount++
+		job.ScheduledFor = time.Now().UTC().Add(hookRetryBackoff << (job.RetryCount - 1)).Unix()
+		if err := storeInstance.AppendJob(ctx, *job); err != nil {
+			return err
+		}
+	default:
+		hook.Status = store.HookFailed
+		hook.Error = runErr.Error()
+		log.Printf("hook %s of execution %s failed after %d attempts: %v", hook.ID, hook.ExecutionID, hook.Attempts, runErr)
+	}
+	if err := storeInstance.UpdateTaskHook(ctx, hook); err != nil {
+		return err
+	}
+	return commit(ctx)
+}
+
+func toHookResult(hook *store.TaskHook) (*HookResult, error) {
+	result := &HookResult{
+		ID:          hook.ID,
+		ExecutionID: hook.ExecutionID,
+		ChainID:     hook.ChainID,
+		TaskID:      hook.TaskID,
+		Hook:        hook.Hook,
+		Status:      hook.Status,
+		Error:       hook.Error,
+		Attempts:    hook.Attempts,
+		CreatedAt:   hook.CreatedAt,
+		UpdatedAt:   hook.UpdatedAt,
+	}
+	if len(hook.Output) > 0 {
+		if err := json.Unmarshal(hook.Output, &result.Output); err != nil {
+			return nil, fmt.Errorf("failed to decode hook output: %w", err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,31 @@
+package execservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libbus"
+)
+
+// TaskPrintSubject is the libbus subject on which the messages printed by executions are published.
+// The payload is the JSON encoded taskengine.PrintEvent.
+const TaskPrintSubject = "task.print"
+
+type busPrinter struct {
+	ps libbus.Messenger
+}
+
+// NewPrinter returns a taskengine.Printer that publishes printed messages on TaskPrintSubject.
+func NewPrinter(ps libbus.Messenger) taskengine.Printer {
+	return &busPrinter{ps: ps}
+}
+
+func (p *busPrinter) Print(ctx context.Context, event *taskengine.PrintEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode print event: %w", err)
+	}
+	return p.ps.Publish(ctx, TaskPrintSubject, payload)
+}
@@ -0,0 +1,64 @@
+package execservice
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libkv"
+)
+
+// ResponseCacheBucket is the libkv bucket holding the cached prompt responses of executions.
+const ResponseCacheBucket = "task_response_cache"
+
+// ResponseCacheMaxTTL is the TTL of the ResponseCacheBucket. Cache TTLs of chains beyond it are cut short.
+const ResponseCacheMaxTTL = 24 * time.Hour
+
+type kvResponseStore struct {
+	kv libkv.KVManager
+}
+
+// NewResponseStore returns a taskengine.ResponseStore backed by kv.
+// Entries are stored with their expiry and deleted once they are read after it, entries that are
+// never read again are left to the TTL of the bucket, see libkv.WithBucketTTL.
+func NewResponseStore(kv libkv.KVManager) taskengine.ResponseStore {
+	return &kvResponseStore{kv: kv}
+}
+
+func (s *kvResponseStore) Get(ctx context.Context, key []byte) ([]byte, error) {
+	exec, err := s.kv.Operation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := exec.Get(ctx, key)
+	if errors.Is(err, libkv.ErrNotFound) {
+		return nil, taskengine.ErrCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(stored) < 8 {
+		return nil, fmt.Errorf("corrupted cache entry %s", key)
+	}
+	expiresAt := time.UnixMilli(int64(binary.BigEndian.Uint64(stored[:8])))
+	if !time.Now().Before(expiresAt) {
+		if err := exec.Delete(ctx, key); err != nil && !errors.Is(err, libkv.ErrNotFound) {
+			return nil, fmt.Errorf("failed to delete expired cache entry: %w", err)
+		}
+		return nil, taskengine.ErrCacheMiss
+	}
+	return stored[8:], nil
+}
+
+func (s *kvResponseStore) Set(ctx context.Context, key []byte, value []byte, ttl time.Duration) error {
+	exec, err := s.kv.Operation(ctx)
+	if err != nil {
+		return err
+	}
+	stored := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint64(stored, uint64(time.Now().Add(ttl).UnixMilli()))
+	return exec.Set(ctx, libkv.KeyValue{Key: key, Value: append(stored, value...)})
+}
@@ -0,0 +1,89 @@
+package execservice_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/contenox/contenox/core/services/execservice"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libkv"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryKV is a libkv.KVManager keeping its values in memory.
+type memoryKV struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func (m *memoryKV) Operation(context.Context) (libkv.KVExec, error) { return m, nil }
+
+func (m *memoryKV) Close() error { return nil }
+
+func (m *memoryKV) Get(_ context.Context, key []byte) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.values[string(key)]
+	if !ok {
+		return nil, libkv.ErrNotFound
+	}
+	return value, nil
+}
+
+func (m *memoryKV) Set(_ context.Context, kv libkv.KeyValue) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[string(kv.Key)] = kv.Value
+	return nil
+}
+
+func (m *memoryKV) Delete(_ context.Context, key []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.values[string(key)]; !ok {
+		return libkv.ErrNotFound
+	}
+	delete(m.values, string(key))
+	return nil
+}
+
+func (m *memoryKV) Exists(_ context.Context, key []byte) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.values[string(key)]
+	return ok, nil
+}
+
+func (m *memoryKV) List(context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for key := range m.values {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func TestResponseStore(t *testing.T) {
+	ctx := context.Background()
+	kv := &memoryKV{values: map[string][]byte{}}
+	responses := execservice.NewResponseStore(kv)
+
+	_, err := responses.Get(ctx, []byte("taskengine/responses/a"))
+	require.ErrorIs(t, err, taskengine.ErrCacheMiss)
+
+	require.NoError(t, responses.Set(ctx, []byte("taskengine/responses/a"), []byte("cached"), time.Hour))
+	value, err := responses.Get(ctx, []byte("taskengine/responses/a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("cached"), value)
+
+	require.NoError(t, responses.Set(ctx, []byte("taskengine/responses/b"), []byte("stale"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, err = responses.Get(ctx, []byte("taskengine/responses/b"))
+	require.ErrorIs(t, err, taskengine.ErrCacheMiss)
+	keys, err := kv.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"taskengine/responses/a"}, keys, "expired entries are deleted when read")
+}
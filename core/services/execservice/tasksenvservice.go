@@ -2,6 +2,10 @@ package execservice
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/contenox/contenox/core/serverops"
 	"github.com/contenox/contenox/core/serverops/store"
@@ -9,12 +13,57 @@ import (
 	"github.com/contenox/contenox/libs/libdb"
 )
 
+var (
+	// ErrExecutionCompleted is returned when resuming an execution that already finished.
+	ErrExecutionCompleted = errors.New("execution already completed")
+
+	// ErrExecutionRunning is returned when resuming an execution that is still running
+	// or was resumed concurrently.
+	ErrExecutionRunning = errors.New("execution is running")
+)
+
+// resumeStaleAfter is how long a running execution must not have been checkpointed
+// before Resume treats it as interrupted, e.g. by a crashed replica.
+const resumeStaleAfter = 10 * time.Minute
+
+const executionsPageSize = 100
+
 type TasksEnvService interface {
 	Execute(ctx context.Context, chain *taskengine.ChainDefinition, input string) (any, error)
+	DryRun(ctx context.Context, chain *taskengine.ChainDefinition, input string, responses taskengine.DryRunResponses) (*DryRunResult, error)
+	GetExecution(ctx context.Context, id string) (*Execution, error)
+	ListExecutions(ctx context.Context, createdAtCursor *time.Time) ([]*taskengine.ExecutionState, error)
+	Resume(ctx context.Context, id string) (any, error)
+	GetApproval(ctx context.Context, id string) (*Approval, error)
+	ListPendingApprovals(ctx context.Context) ([]*Approval, error)
+	DecideApproval(ctx context.Context, id string, decision *taskengine.ApprovalDecision) (any, error)
+	GetHook(ctx context.Context, id string) (*HookResult, error)
 	serverops.ServiceMeta
 	taskengine.HookRegistry
 }
 
+// Execution is the persisted state of a chain execution together with its trace
+// and the hooks queued by its non-blocking Hook tasks.
+type Execution struct {
+	*taskengine.ExecutionState
+	Trace []*TraceEntry `json:"trace"`
+	Hooks []*HookResult `json:"hooks"`
+}
+
+// DryRunResult is the outcome of a dry run.
+// A failing chain is reported in Error, together with the steps completed before it failed.
+type DryRunResult struct {
+	Output any    `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	// Path lists the completed tasks in order.
+	Path  []string                   `json:"path"`
+	Steps []taskengine.ExecutionStep `json:"steps"`
+
+	// Log lists the printed messages in order.
+	Log []taskengine.PrintEntry `json:"log"`
+}
+
 type tasksEnvService struct {
 	environmentExec taskengine.EnvExecutor
 	db              libdb.DBManager
@@ -41,6 +90,212 @@ func (s *tasksEnvService) Execute(ctx context.Context, chain *taskengine.ChainDe
 	return s.environmentExec.ExecEnv(ctx, chain, input)
 }
 
+// DryRun executes chain answering every task from responses, without calling models or hooks
+// and without persisting the execution.
+func (s *tasksEnvService) DryRun(ctx context.Context, chain *taskengine.ChainDefinition, input string, responses taskengine.DryRunResponses) (*DryRunResult, error) {
+	tx := s.db.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	if chain == nil {
+		return nil, fmt.Errorf("chain required: %w", serverops.ErrMissingParameter)
+	}
+
+	result := &DryRunResult{Path: []string{}, Steps: []taskengine.ExecutionStep{}, Log: []taskengine.PrintEntry{}}
+	ctx = taskengine.WithDryRun(ctx, responses)
+	ctx = taskengine.WithStepObserver(ctx, func(step taskengine.ExecutionStep) {
+		result.Path = append(result.Path, step.TaskID)
+		result.Steps = append(result.Steps, step)
+	})
+	ctx = taskengine.WithPrintObserver(ctx, func(entry taskengine.PrintEntry) {
+		result.Log = append(result.Log, entry)
+	})
+	output, err := s.environmentExec.ExecEnv(ctx, chain, input)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Output = output
+	return result, nil
+}
+
+func (s *tasksEnvService) GetExecution(ctx context.Context, id string) (*Execution, error) {
+	tx := s.db.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	execution, err := storeInstance.GetTaskExecution(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	state, err := fromExecution(execution)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := storeInstance.ListTaskTraceEntries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	trace := make([]*TraceEntry, 0, len(entries))
+	for _, entry := range entries {
+		trace = append(trace, toTraceEntry(entry))
+	}
+	records, err := storeInstance.ListTaskHooks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	hooks := make([]*HookResult, 0, len(records))
+	for _, record := range records {
+		hook, err := toHookResult(record)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return &Execution{
+		ExecutionState: state,
+		Trace:          trace,
+		Hooks:          hooks,
+	}, nil
+}
+
+func (s *tasksEnvService) ListExecutions(ctx context.Context, createdAtCursor *time.Time) ([]*taskengine.ExecutionState, error) {
+	tx := s.db.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	executions, err := storeInstance.ListTaskExecutions(ctx, createdAtCursor, executionsPageSize)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]*taskengine.ExecutionState, 0, len(executions))
+	for _, execution := range executions {
+		state, err := fromExecution(execution)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// Resume continues an interrupted or failed execution from its last checkpoint.
+// The execution is claimed first, so concurrent resumes cannot run its current task twice.
+// Running executions are only resumed once their last checkpoint is older than resumeStaleAfter.
+func (s *tasksEnvService) Resume(ctx context.Context, id string) (any, error) {
+	tx := s.db.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionEdit); err != nil {
+		return nil, err
+	}
+	execution, err := storeInstance.ClaimTaskExecution(ctx, id, time.Now().Add(-resumeStaleAfter))
+	if errors.Is(err, libdb.ErrNotFound) {
+		current, err := storeInstance.GetTaskExecution(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		switch taskengine.ExecutionStatus(current.Status) {
+		case taskengine.ExecutionCompleted:
+			return nil, fmt.Errorf("execution %s: %w: %w", id, ErrExecutionCompleted, serverops.ErrInvalidParameterValue)
+		case taskengine.ExecutionWaiting:
+			return nil, fmt.Errorf("execution %s is waiting for an approval decision: %w", id, serverops.ErrInvalidParameterValue)
+		default:
+			return nil, fmt.Errorf("execution %s: %w: %w", id, ErrExecutionRunning, serverops.ErrInvalidParameterValue)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	var chain taskengine.ChainDefinition
+	if err := json.Unmarshal(execution.Chain, &chain); err != nil {
+		return nil, fmt.Errorf("failed to decode chain of execution %s: %w", id, err)
+	}
+	state, err := fromExecution(execution)
+	if err != nil {
+		return nil, err
+	}
+	return s.environmentExec.ResumeEnv(ctx, &chain, state)
+}
+
+func (s *tasksEnvService) GetApproval(ctx context.Context, id string) (*Approval, error) {
+	tx := s.db.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	record, err := storeInstance.GetTaskApproval(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toApproval(record)
+}
+
+// GetHook returns a hook queued by a non-blocking Hook task, with its output once it ran.
+func (s *tasksEnvService) GetHook(ctx context.Context, id string) (*HookResult, error) {
+	tx := s.db.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	record, err := storeInstance.GetTaskHook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toHookResult(record)
+}
+
+func (s *tasksEnvService) ListPendingApprovals(ctx context.Context) ([]*Approval, error) {
+	tx := s.db.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	records, err := storeInstance.ListPendingTaskApprovals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	approvals := make([]*Approval, 0, len(records))
+	for _, record := range records {
+		approval, err := toApproval(record)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, approval)
+	}
+	return approvals, nil
+}
+
+// DecideApproval answers a pending approval and resumes the waiting execution
+// on the branch matching the decision's action.
+func (s *tasksEnvService) DecideApproval(ctx context.Context, id string, decision *taskengine.ApprovalDecision) (any, error) {
+	if decision == nil {
+		return nil, fmt.Errorf("decision required: %w", serverops.ErrMissingParameter)
+	}
+	switch decision.Action {
+	case taskengine.ApprovalApprove, taskengine.ApprovalReject, taskengine.ApprovalEdit:
+	default:
+		return nil, fmt.Errorf("unsupported action %q: %w", decision.Action, serverops.ErrInvalidParameterValue)
+	}
+	tx := s.db.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionEdit); err != nil {
+		return nil, err
+	}
+	identity, err := serverops.GetIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	decision.DecidedBy = identity
+	record, err := storeInstance.GetTaskApproval(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return resumeApproval(ctx, storeInstance, s.environmentExec, record, decision)
+}
+
 func (s *tasksEnvService) GetServiceName() string {
 	return "taskenviromentservice"
 }
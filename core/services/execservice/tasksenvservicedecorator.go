@@ -2,6 +2,7 @@ package execservice
 
 import (
 	"context"
+	"time"
 
 	"github.com/contenox/contenox/core/serverops"
 	"github.com/contenox/contenox/core/taskengine"
@@ -44,6 +45,159 @@ func (d *activityTrackerTaskEnvDecorator) Execute(ctx context.Context, chain *ta
 	return result, err
 }
 
+func (d *activityTrackerTaskEnvDecorator) DryRun(ctx context.Context, chain *taskengine.ChainDefinition, input string, responses taskengine.DryRunResponses) (*DryRunResult, error) {
+	chainID := ""
+	if chain != nil {
+		chainID = chain.ID
+	}
+	reportErrFn, reportChangeFn, endFn := d.tracker.Start(
+		ctx,
+		"dry_run",
+		"task-chain",
+		"chainID", chainID,
+		"inputLength", len(input),
+	)
+	defer endFn()
+
+	result, err := d.service.DryRun(ctx, chain, input, responses)
+	if err != nil {
+		reportErrFn(err)
+	} else {
+		reportChangeFn(chainID, map[string]interface{}{
+			"path":  result.Path,
+			"error": result.Error,
+		})
+	}
+
+	return result, err
+}
+
+func (d *activityTrackerTaskEnvDecorator) GetExecution(ctx context.Context, id string) (*Execution, error) {
+	reportErrFn, _, endFn := d.tracker.Start(
+		ctx,
+		"read",
+		"task-execution",
+		"executionID", id,
+	)
+	defer endFn()
+
+	execution, err := d.service.GetExecution(ctx, id)
+	if err != nil {
+		reportErrFn(err)
+	}
+
+	return execution, err
+}
+
+func (d *activityTrackerTaskEnvDecorator) ListExecutions(ctx context.Context, createdAtCursor *time.Time) ([]*taskengine.ExecutionState, error) {
+	reportErrFn, _, endFn := d.tracker.Start(
+		ctx,
+		"list",
+		"task-executions",
+	)
+	defer endFn()
+
+	states, err := d.service.ListExecutions(ctx, createdAtCursor)
+	if err != nil {
+		reportErrFn(err)
+	}
+
+	return states, err
+}
+
+func (d *activityTrackerTaskEnvDecorator) Resume(ctx context.Context, id string) (any, error) {
+	reportErrFn, reportChangeFn, endFn := d.tracker.Start(
+		ctx,
+		"resume",
+		"task-execution",
+		"executionID", id,
+	)
+	defer endFn()
+
+	result, err := d.service.Resume(ctx, id)
+	if err != nil {
+		reportErrFn(err)
+	} else {
+		reportChangeFn(id, map[string]interface{}{
+			"result": result,
+		})
+	}
+
+	return result, err
+}
+
+func (d *activityTrackerTaskEnvDecorator) GetApproval(ctx context.Context, id string) (*Approval, error) {
+	reportErrFn, _, endFn := d.tracker.Start(
+		ctx,
+		"read",
+		"task-approval",
+		"approvalID", id,
+	)
+	defer endFn()
+
+	approval, err := d.service.GetApproval(ctx, id)
+	if err != nil {
+		reportErrFn(err)
+	}
+
+	return approval, err
+}
+
+func (d *activityTrackerTaskEnvDecorator) GetHook(ctx context.Context, id string) (*HookResult, error) {
+	reportErrFn, _, endFn := d.tracker.Start(
+		ctx,
+		"read",
+		"task-hook",
+		"hookID", id,
+	)
+	defer endFn()
+
+	hook, err := d.service.GetHook(ctx, id)
+	if err != nil {
+		reportErrFn(err)
+	}
+
+	return hook, err
+}
+
+func (d *activityTrackerTaskEnvDecorator) ListPendingApprovals(ctx context.Context) ([]*Approval, error) {
+	reportErrFn, _, endFn := d.tracker.Start(
+		ctx,
+		"list",
+		"task-approvals",
+	)
+	defer endFn()
+
+	approvals, err := d.service.ListPendingApprovals(ctx)
+	if err != nil {
+		reportErrFn(err)
+	}
+
+	return approvals, err
+}
+
+func (d *activityTrackerTaskEnvDecorator) DecideApproval(ctx context.Context, id string, decision *taskengine.ApprovalDecision) (any, error) {
+	reportErrFn, reportChangeFn, endFn := d.tracker.Start(
+		ctx,
+		"decide",
+		"task-approval",
+		"approvalID", id,
+	)
+	defer endFn()
+
+	result, err := d.service.DecideApproval(ctx, id, decision)
+	if err != nil {
+		reportErrFn(err)
+	} else {
+		reportChangeFn(id, map[string]interface{}{
+			"action": decision.Action,
+			"result": result,
+		})
+	}
+
+	return result, err
+}
+
 func (d *activityTrackerTaskEnvDecorator) GetServiceName() string {
 	return d.service.GetServiceName()
 }
@@ -0,0 +1,143 @@
+package execservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/google/uuid"
+)
+
+// TraceEntry is a single recorded operation of a chain execution.
+type TraceEntry struct {
+	Operation string          `json:"operation"`
+	Subject   string          `json:"subject"`
+	Args      json.RawMessage `json:"args"`
+	ChangeID  string          `json:"changeId,omitempty"`
+	Change    json.RawMessage `json:"change,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	StartedAt time.Time       `json:"startedAt"`
+	Duration  time.Duration   `json:"duration"`
+}
+
+type traceTracker struct {
+	db libdb.DBManager
+}
+
+// NewTraceTracker returns an ActivityTracker that stores every operation reported
+// during a chain execution as a trace entry of that execution.
+// Operations outside of an execution (see taskengine.WithExecutionID) are ignored.
+func NewTraceTracker(db libdb.DBManager) serverops.ActivityTracker {
+	return &traceTracker{db: db}
+}
+
+func (t *traceTracker) Start(
+	ctx context.Context,
+	operation string,
+	subject string,
+	kvArgs ...any,
+) (func(error), func(string, any), func()) {
+	executionID, ok := taskengine.ExecutionIDFromContext(ctx)
+	if !ok {
+		return serverops.NoopTracker{}.Start(ctx, operation, subject, kvArgs...)
+	}
+
+	var mu sync.Mutex
+	entry := &store.TaskTraceEntry{
+		ID:          uuid.NewString(),
+		ExecutionID: executionID,
+		Operation:   operation,
+		Subject:     subject,
+		Args:        encodeTraceValue(traceArgs(kvArgs)),
+		StartedAt:   time.Now().UTC(),
+	}
+	var once sync.Once
+
+	reportErr := func(err error) {
+		if err == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		entry.Error = err.Error()
+	}
+	reportChange := func(id string, data any) {
+		mu.Lock()
+		defer mu.Unlock()
+		entry.ChangeID = id
+		entry.Change = encodeTraceValue(data)
+	}
+	end := func() {
+		once.Do(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			entry.DurationMS = time.Since(entry.StartedAt).Milliseconds()
+			// The trace must be stored even if the execution was cancelled.
+			storeInstance := store.New(t.db.WithoutTransaction())
+			if err := storeInstance.AppendTaskTraceEntry(context.WithoutCancel(ctx), entry); err != nil {
+				log.Printf("failed to store trace entry for execution %s: %v", executionID, err)
+			}
+		})
+	}
+	return reportErr, reportChange, end
+}
+
+// traceArgs converts the key-value pairs passed to Start into a map.
+func traceArgs(kvArgs []any) map[string]any {
+	args := make(map[string]any, len(kvArgs)/2)
+	for i := 0; i+1 < len(kvArgs); i += 2 {
+		args[fmt.Sprint(kvArgs[i])] = kvArgs[i+1]
+	}
+	if len(kvArgs)%2 == 1 {
+		args["_extra"] = kvArgs[len(kvArgs)-1]
+	}
+	return args
+}
+
+// encodeTraceValue encodes data as JSON. Errors are stored as their message,
+// values that cannot be encoded are stored as their string representation.
+func encodeTraceValue(data any) []byte {
+	if data == nil {
+		return nil
+	}
+	if err, ok := data.(error); ok {
+		data = err.Error()
+	}
+	if m, ok := data.(map[string]any); ok {
+		converted := make(map[string]any, len(m))
+		for k, v := range m {
+			if err, ok := v.(error); ok {
+				v = err.Error()
+			}
+			converted[k] = v
+		}
+		data = converted
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(data))
+	}
+	return encoded
+}
+
+func toTraceEntry(entry *store.TaskTraceEntry) *TraceEntry {
+	return &TraceEntry{
+		Operation: entry.Operation,
+		Subject:   entry.Subject,
+		Args:      entry.Args,
+		ChangeID:  entry.ChangeID,
+		Change:    entry.Change,
+		Error:     entry.Error,
+		StartedAt: entry.StartedAt,
+		Duration:  time.Duration(entry.DurationMS) * time.Millisecond,
+	}
+}
+
+var _ serverops.ActivityTracker = (*traceTracker)(nil)
@@ -0,0 +1,76 @@
+package secretservice
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libcipher"
+	"github.com/contenox/contenox/libs/libdb"
+)
+
+// Resolver decrypts stored secrets for executions.
+type Resolver struct {
+	db        libdb.DBManager
+	decryptor libcipher.Decryptor
+}
+
+var _ taskengine.SecretResolver = (*Resolver)(nil)
+
+// NewResolver returns the resolver of the secrets referenced by hook args.
+func NewResolver(db libdb.DBManager, encryptionKey string) (*Resolver, error) {
+	decryptor, err := libcipher.NewGCMDecryptor(deriveKey(encryptionKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize secret decryption: %w", err)
+	}
+	return &Resolver{db: db, decryptor: decryptor}, nil
+}
+
+// GetSecret returns the value of the secret name. The caller running the execution
+// must be allowed to manage secrets, reading one is as sensitive as changing it.
+func (r *Resolver) GetSecret(ctx context.Context, name string) (string, error) {
+	storeInstance := store.New(r.db.WithoutTransaction())
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, r, store.PermissionManage); err != nil {
+		return "", err
+	}
+	return r.decrypt(ctx, storeInstance, name)
+}
+
+// Trusted returns a resolver that skips the authorization check. It serves background
+// workers running jobs whose secret references were resolved when the job was queued.
+func (r *Resolver) Trusted() taskengine.SecretResolver {
+	return trustedResolver{r}
+}
+
+type trustedResolver struct {
+	*Resolver
+}
+
+func (r trustedResolver) GetSecret(ctx context.Context, name string) (string, error) {
+	return r.decrypt(ctx, store.New(r.db.WithoutTransaction()), name)
+}
+
+func (r *Resolver) decrypt(ctx context.Context, storeInstance store.Store, name string) (string, error) {
+	record, err := storeInstance.GetSecretByName(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	value, additionalData, err := r.decryptor.Crypt(record.EncryptedValue)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %s: %w", name, err)
+	}
+	if string(additionalData) != record.Name {
+		return "", fmt.Errorf("secret %s was encrypted for another secret", name)
+	}
+	return string(value), nil
+}
+
+func (r *Resolver) GetServiceName() string {
+	return "secretservice"
+}
+
+func (r *Resolver) GetServiceGroup() string {
+	return serverops.DefaultDefaultServiceGroup
+}
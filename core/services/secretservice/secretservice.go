@@ -0,0 +1,203 @@
+package secretservice
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/libs/libcipher"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/google/uuid"
+)
+
+// Service manages secrets referenced by hook args. Values are encrypted at rest
+// and never returned, they are only decrypted for executions through NewResolver.
+type Service interface {
+	Create(ctx context.Context, input *Input) (*Secret, error)
+	Get(ctx context.Context, name string) (*Secret, error)
+	Update(ctx context.Context, input *Input) (*Secret, error)
+	Delete(ctx context.Context, name string) error
+	List(ctx context.Context) ([]*Secret, error)
+	serverops.ServiceMeta
+}
+
+// Input sets the value of a secret.
+type Input struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Value       string `json:"value"`
+}
+
+// Secret describes a stored secret without its value.
+type Secret struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,255}$`)
+
+type service struct {
+	dbInstance libdb.DBManager
+	encryptor  libcipher.Encryptor
+}
+
+// New creates the service. Values are encrypted with AES-GCM under a key derived from encryptionKey.
+func New(db libdb.DBManager, encryptionKey string) (Service, error) {
+	encryptor, err := libcipher.NewGCMEncryptor(deriveKey(encryptionKey), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize secret encryption: %w", err)
+	}
+	return &service{
+		dbInstance: db,
+		encryptor:  encryptor,
+	}, nil
+}
+
+// deriveKey turns the configured encryption key into a valid AES-256 key.
+func deriveKey(encryptionKey string) []byte {
+	key := sha256.Sum256([]byte(encryptionKey))
+	return key[:]
+}
+
+func (s *service) Create(ctx context.Context, input *Input) (*Secret, error) {
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+	encrypted, err := s.encrypt(input)
+	if err != nil {
+		return nil, err
+	}
+	tx := s.dbInstance.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionManage); err != nil {
+		return nil, err
+	}
+	record := &store.Secret{
+		ID:             uuid.NewString(),
+		Name:           input.Name,
+		Description:    input.Description,
+		EncryptedValue: encrypted,
+	}
+	if err := storeInstance.CreateSecret(ctx, record); err != nil {
+		return nil, err
+	}
+	return toSecret(record), nil
+}
+
+func (s *service) Get(ctx context.Context, name string) (*Secret, error) {
+	tx := s.dbInstance.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	record, err := storeInstance.GetSecretByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return toSecret(record), nil
+}
+
+// Update replaces the value and description of an existing secret.
+func (s *service) Update(ctx context.Context, input *Input) (*Secret, error) {
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+	encrypted, err := s.encrypt(input)
+	if err != nil {
+		return nil, err
+	}
+	tx, com, end, err := s.dbInstance.WithTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionManage); err != nil {
+		return nil, err
+	}
+	record, err := storeInstance.GetSecretByName(ctx, input.Name)
+	if err != nil {
+		return nil, err
+	}
+	record.Description = input.Description
+	record.EncryptedValue = encrypted
+	if err := storeInstance.UpdateSecret(ctx, record); err != nil {
+		return nil, err
+	}
+	return toSecret(record), com(ctx)
+}
+
+func (s *service) Delete(ctx context.Context, name string) error {
+	tx := s.dbInstance.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionManage); err != nil {
+		return err
+	}
+	return storeInstance.DeleteSecret(ctx, name)
+}
+
+func (s *service) List(ctx context.Context) ([]*Secret, error) {
+	tx := s.dbInstance.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	records, err := storeInstance.ListSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	secrets := make([]*Secret, 0, len(records))
+	for _, record := range records {
+		secrets = append(secrets, toSecret(record))
+	}
+	return secrets, nil
+}
+
+// encrypt seals the value with the secret name as additional data,
+// so an encrypted value cannot be moved to another secret.
+func (s *service) encrypt(input *Input) ([]byte, error) {
+	encrypted, err := s.encryptor.Crypt([]byte(input.Value), []byte(input.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret %s: %w", input.Name, err)
+	}
+	return encrypted, nil
+}
+
+func (s *service) GetServiceName() string {
+	return "secretservice"
+}
+
+func (s *service) GetServiceGroup() string {
+	return serverops.DefaultDefaultServiceGroup
+}
+
+func validate(input *Input) error {
+	if input == nil || input.Name == "" {
+		return fmt.Errorf("secret name is required: %w", serverops.ErrMissingParameter)
+	}
+	if !namePattern.MatchString(input.Name) {
+		return fmt.Errorf("secret name %q may only contain letters, digits, '_', '.' and '-': %w", input.Name, serverops.ErrInvalidParameterValue)
+	}
+	if input.Value == "" {
+		return fmt.Errorf("secret value is required: %w", serverops.ErrMissingParameter)
+	}
+	return nil
+}
+
+func toSecret(record *store.Secret) *Secret {
+	return &Secret{
+		ID:          record.ID,
+		Name:        record.Name,
+		Description: record.Description,
+		CreatedAt:   record.CreatedAt,
+		UpdatedAt:   record.UpdatedAt,
+	}
+}
@@ -0,0 +1,102 @@
+package secretservice_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/core/services/secretservice"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+const encryptionKey = "test-encryption-key-0123456789"
+
+func TestSecretService_EncryptsAndResolves(t *testing.T) {
+	ctx := context.Background()
+	dbConn, _, dbCleanup, err := libdb.SetupLocalInstance(ctx, uuid.NewString(), "test", "test")
+	require.NoError(t, err)
+	defer dbCleanup()
+	db, err := libdb.NewPostgresDBManager(ctx, dbConn, store.Schema)
+	require.NoError(t, err)
+	require.NoError(t, serverops.NewServiceManager(&serverops.Config{
+		JWTExpiry:       "1h",
+		SecurityEnabled: "false",
+	}))
+
+	service, err := secretservice.New(db, encryptionKey)
+	require.NoError(t, err)
+	resolver, err := secretservice.NewResolver(db, encryptionKey)
+	require.NoError(t, err)
+
+	created, err := service.Create(ctx, &secretservice.Input{Name: "crm_token", Description: "CRM", Value: "s3cr3t"})
+	require.NoError(t, err)
+	require.Equal(t, "crm_token", created.Name)
+
+	_, err = service.Create(ctx, &secretservice.Input{Name: "crm token", Value: "x"})
+	require.ErrorIs(t, err, serverops.ErrInvalidParameterValue)
+	_, err = service.Create(ctx, &secretservice.Input{Name: "empty"})
+	require.ErrorIs(t, err, serverops.ErrMissingParameter)
+
+	record, err := store.New(db.WithoutTransaction()).GetSecretByName(ctx, "crm_token")
+	require.NoError(t, err)
+	require.NotContains(t, string(record.EncryptedValue), "s3cr3t")
+
+	value, err := resolver.GetSecret(ctx, "crm_token")
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", value)
+
+	_, err = service.Update(ctx, &secretservice.Input{Name: "crm_token", Value: "rotated"})
+	require.NoError(t, err)
+	value, err = resolver.GetSecret(ctx, "crm_token")
+	require.NoError(t, err)
+	require.Equal(t, "rotated", value)
+
+	secrets, err := service.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	require.Equal(t, created.ID, secrets[0].ID)
+
+	otherKey, err := secretservice.NewResolver(db, "another-encryption-key-42")
+	require.NoError(t, err)
+	_, err = otherKey.GetSecret(ctx, "crm_token")
+	require.Error(t, err)
+
+	require.NoError(t, service.Delete(ctx, "crm_token"))
+	_, err = resolver.GetSecret(ctx, "crm_token")
+	require.ErrorIs(t, err, libdb.ErrNotFound)
+}
+
+func TestSecretResolver_RequiresAuthorization(t *testing.T) {
+	ctx := context.Background()
+	dbConn, _, dbCleanup, err := libdb.SetupLocalInstance(ctx, uuid.NewString(), "test", "test")
+	require.NoError(t, err)
+	defer dbCleanup()
+	db, err := libdb.NewPostgresDBManager(ctx, dbConn, store.Schema)
+	require.NoError(t, err)
+	require.NoError(t, serverops.NewServiceManager(&serverops.Config{
+		JWTExpiry:       "1h",
+		SecurityEnabled: "false",
+	}))
+	service, err := secretservice.New(db, encryptionKey)
+	require.NoError(t, err)
+	_, err = service.Create(ctx, &secretservice.Input{Name: "crm_token", Value: "s3cr3t"})
+	require.NoError(t, err)
+
+	require.NoError(t, serverops.NewServiceManager(&serverops.Config{
+		JWTExpiry:       "1h",
+		JWTSecret:       "test-jwt-secret",
+		SecurityEnabled: "true",
+	}))
+	resolver, err := secretservice.NewResolver(db, encryptionKey)
+	require.NoError(t, err)
+
+	_, err = resolver.GetSecret(ctx, "crm_token")
+	require.Error(t, err, "executions without an identity may not read secrets")
+
+	value, err := resolver.Trusted().GetSecret(ctx, "crm_token")
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", value)
+}
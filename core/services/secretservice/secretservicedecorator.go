@@ -0,0 +1,95 @@
+package secretservice
+
+import (
+	"context"
+
+	"github.com/contenox/contenox/core/serverops"
+)
+
+// activityTrackerDecorator tracks changes to secrets. Secret values are never reported.
+type activityTrackerDecorator struct {
+	service Service
+	tracker serverops.ActivityTracker
+}
+
+func (d *activityTrackerDecorator) Create(ctx context.Context, input *Input) (*Secret, error) {
+	reportErrFn, reportChangeFn, endFn := d.tracker.Start(
+		ctx,
+		"create",
+		"secret",
+		"name", input.Name,
+	)
+	defer endFn()
+
+	secret, err := d.service.Create(ctx, input)
+	if err != nil {
+		reportErrFn(err)
+	} else {
+		reportChangeFn(secret.ID, nil)
+	}
+
+	return secret, err
+}
+
+func (d *activityTrackerDecorator) Get(ctx context.Context, name string) (*Secret, error) {
+	return d.service.Get(ctx, name)
+}
+
+func (d *activityTrackerDecorator) Update(ctx context.Context, input *Input) (*Secret, error) {
+	reportErrFn, reportChangeFn, endFn := d.tracker.Start(
+		ctx,
+		"update",
+		"secret",
+		"name", input.Name,
+	)
+	defer endFn()
+
+	secret, err := d.service.Update(ctx, input)
+	if err != nil {
+		reportErrFn(err)
+	} else {
+		reportChangeFn(secret.ID, nil)
+	}
+
+	return secret, err
+}
+
+func (d *activityTrackerDecorator) Delete(ctx context.Context, name string) error {
+	reportErrFn, reportChangeFn, endFn := d.tracker.Start(
+		ctx,
+		"delete",
+		"secret",
+		"name", name,
+	)
+	defer endFn()
+
+	err := d.service.Delete(ctx, name)
+	if err != nil {
+		reportErrFn(err)
+	} else {
+		reportChangeFn(name, nil)
+	}
+
+	return err
+}
+
+func (d *activityTrackerDecorator) List(ctx context.Context) ([]*Secret, error) {
+	return d.service.List(ctx)
+}
+
+func (d *activityTrackerDecorator) GetServiceName() string {
+	return d.service.GetServiceName()
+}
+
+func (d *activityTrackerDecorator) GetServiceGroup() string {
+	return d.service.GetServiceGroup()
+}
+
+func WithActivityTracker(service Service, tracker serverops.ActivityTracker) Service {
+	return &activityTrackerDecorator{
+		service: service,
+		tracker: tracker,
+	}
+}
+
+var _ Service = (*activityTrackerDecorator)(nil)
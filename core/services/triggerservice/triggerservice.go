@@ -0,0 +1,664 @@
+package triggerservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/contenox/contenox/core/llmrepo"
+	"github.com/contenox/contenox/core/llmresolver"
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/core/serverops/vectors"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libbus"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/google/uuid"
+)
+
+// TriggerFiredSubject is the libbus subject on which every Firing is published.
+const TriggerFiredSubject = "task.trigger.fired"
+
+// semanticSearchSize is how many nearest vectors are inspected for semantic triggers.
+// The vector store is shared with indexed files, so it is larger than the number of expected matches.
+const semanticSearchSize = 50
+
+// scheduleLookback is how far back the schedule cycle looks for due runs, so runs are not lost
+// when a cycle is delayed. Runs missed while no replica was running are not caught up.
+const scheduleLookback = 5 * time.Minute
+
+// eventQueue is the libbus queue of event subscriptions, so that each event
+// fires its chains on only one replica.
+const eventQueue = "triggerservice"
+
+// maxEventFirings bounds the chains fired by events that run at the same time on a replica.
+// Further events wait in the subscription until a firing finishes.
+const maxEventFirings = 16
+
+// Service evaluates the triggers of stored chains and starts the chains they match.
+type Service interface {
+	// Match reports the chains whose keyword or semantic triggers match the input, without running them.
+	Match(ctx context.Context, input string) ([]*Match, error)
+
+	// Dispatch runs every chain whose keyword or semantic triggers match the input.
+	Dispatch(ctx context.Context, input string) ([]*Firing, error)
+
+	// Sync reloads the triggers of all stored chains, indexes semantic triggers
+	// and subscribes to the subjects of event triggers. It is run by the trigger cycle.
+	Sync(ctx context.Context) error
+
+	// RunSchedules starts the chains whose schedule triggers are due. It is run by the schedule cycle
+	// on every replica, each run is claimed in the store so that only one replica starts it.
+	RunSchedules(ctx context.Context) error
+
+	// ListSchedules returns the schedule triggers with their next count run times.
+	ListSchedules(ctx context.Context, count int) ([]*ScheduledTrigger, error)
+
+	// ListRuns returns the latest runs of schedule triggers, optionally only those of one chain.
+	ListRuns(ctx context.Context, chainID string, limit int) ([]*store.TriggerRun, error)
+
+	serverops.ServiceMeta
+}
+
+// Match is a trigger that matched an input.
+type Match struct {
+	ChainID string             `json:"chainId"`
+	Trigger taskengine.Trigger `json:"trigger"`
+
+	// Reason explains why the trigger matched.
+	Reason string `json:"reason"`
+
+	// Distance is the vector distance for semantic triggers.
+	Distance float32 `json:"distance,omitempty"`
+}
+
+// Firing is a chain started by a trigger.
+type Firing struct {
+	Match
+	ExecutionID string `json:"executionId"`
+	Output      any    `json:"output,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ScheduledTrigger is a schedule trigger and its upcoming runs.
+type ScheduledTrigger struct {
+	ChainID  string             `json:"chainId"`
+	Trigger  taskengine.Trigger `json:"trigger"`
+	Upcoming []time.Time        `json:"upcoming"`
+}
+
+// registeredTrigger is a trigger of a stored chain, prepared for matching.
+type registeredTrigger struct {
+	chain    *taskengine.ChainDefinition
+	trigger  taskengine.Trigger
+	keyword  *regexp.Regexp
+	vectorID string
+	text     string
+	schedule *taskengine.Schedule
+}
+
+type service struct {
+	// ctx bounds the lifetime of event subscriptions, which outlive the Sync call that creates them.
+	ctx             context.Context
+	db              libdb.DBManager
+	embedder        llmrepo.ModelRepo
+	vectors         vectors.Store
+	ps              libbus.Messenger
+	environmentExec taskengine.EnvExecutor
+	started         time.Time
+	eventFirings    chan struct{} // semaphore of running event firings
+
+	mu            sync.Mutex
+	synced        bool
+	triggers      []*registeredTrigger
+	indexed       map[string]string             // vector ID to indexed text
+	subscriptions map[string]context.CancelFunc // subject to subscription cancel
+}
+
+// New returns the trigger service. Chains fired by events and schedules run with ctx and
+// resolve their secrets with secrets, as no user started them.
+func New(
+	ctx context.Context,
+	db libdb.DBManager,
+	embedder llmrepo.ModelRepo,
+	vectorStore vectors.Store,
+	ps libbus.Messenger,
+	environmentExec taskengine.EnvExecutor,
+	secrets taskengine.SecretResolver,
+) Service {
+	if secrets != nil {
+		ctx = taskengine.WithSecretResolver(ctx, secrets)
+	}
+	return &service{
+		ctx:             ctx,
+		db:              db,
+		embedder:        embedder,
+		vectors:         vectorStore,
+		ps:              ps,
+		environmentExec: environmentExec,
+		started:         time.Now().UTC(),
+		eventFirings:    make(chan struct{}, maxEventFirings),
+		indexed:         map[string]string{},
+		subscriptions:   map[string]context.CancelFunc{},
+	}
+}
+
+func (s *service) Match(ctx context.Context, input string) ([]*Match, error) {
+	tx := s.db.WithoutTransaction()
+	if err := serverops.CheckServiceAuthorization(ctx, store.New(tx), s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	return s.match(ctx, input)
+}
+
+func (s *service) Dispatch(ctx context.Context, input string) ([]*Firing, error) {
+	tx := s.db.WithoutTransaction()
+	if err := serverops.CheckServiceAuthorization(ctx, store.New(tx), s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	matches, err := s.match(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	firings := make([]*Firing, 0, len(matches))
+	for _, match := range matches {
+		firings = append(firings, s.fire(ctx, match, input, uuid.NewString()))
+	}
+	return firings, nil
+}
+
+// match evaluates keyword and semantic triggers, a chain matches at most once.
+func (s *service) match(ctx context.Context, input string) ([]*Match, error) {
+	if err := s.ensureSynced(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	triggers := s.triggers
+	s.mu.Unlock()
+
+	matched := map[string]*Match{}
+	semantic := map[string]*registeredTrigger{}
+	for _, rt := range triggers {
+		switch rt.trigger.Type {
+		case taskengine.TriggerKeyword:
+			if _, ok := matched[rt.chain.ID]; ok {
+				continue
+			}
+			if found := rt.keyword.FindString(input); found != "" {
+				matched[rt.chain.ID] = &Match{
+					ChainID: rt.chain.ID,
+					Trigger: rt.trigger,
+					Reason:  fmt.Sprintf("keyword pattern %q matched %q", rt.trigger.Pattern, found),
+				}
+			}
+		case taskengine.TriggerSemantic:
+			semantic[rt.vectorID] = rt
+		}
+	}
+
+	if len(semantic) > 0 {
+		results, err := s.search(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, res := range results {
+			rt, ok := semantic[res.ID]
+			if !ok {
+				continue
+			}
+			if _, ok := matched[rt.chain.ID]; ok {
+				continue
+			}
+			maxDistance := rt.trigger.MaxDistance
+			if maxDistance <= 0 {
+				maxDistance = taskengine.DefaultTriggerMaxDistance
+			}
+			if res.Distance > maxDistance {
+				continue
+			}
+			matched[rt.chain.ID] = &Match{
+				ChainID:  rt.chain.ID,
+				Trigger:  rt.trigger,
+				Reason:   fmt.Sprintf("semantically close to %q (distance %.3f <= %.3f)", rt.text, res.Distance, maxDistance),
+				Distance: res.Distance,
+			}
+		}
+	}
+
+	matches := make([]*Match, 0, len(matched))
+	for _, match := range matched {
+		matches = append(matches, match)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ChainID < matches[j].ChainID })
+	return matches, nil
+}
+
+// fire runs the chain of a match and reports the firing on TriggerFiredSubject.
+func (s *service) fire(ctx context.Context, match *Match, input string, executionID string) *Firing {
+	firing := &Firing{
+		Match:       *match,
+		ExecutionID: executionID,
+	}
+	chain := s.chain(match.ChainID)
+	if chain == nil {
+		firing.Error = fmt.Sprintf("chain %s is no longer registered", match.ChainID)
+	} else {
+		output, err := s.environmentExec.ExecEnv(taskengine.WithExecutionID(ctx, firing.ExecutionID), chain, input)
+		if err != nil {
+			firing.Error = err.Error()
+		} else {
+			firing.Output = output
+		}
+	}
+
+	payload, err := json.Marshal(firing)
+	if err == nil {
+		err = s.ps.Publish(ctx, TriggerFiredSubject, payload)
+	}
+	if err != nil {
+		log.Printf("failed to report firing of chain %s: %v", match.ChainID, err)
+	}
+	return firing
+}
+
+func (s *service) chain(id string) *taskengine.ChainDefinition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rt := range s.triggers {
+		if rt.chain.ID == id {
+			return rt.chain
+		}
+	}
+	return nil
+}
+
+func (s *service) ensureSynced(ctx context.Context) error {
+	s.mu.Lock()
+	synced := s.synced
+	s.mu.Unlock()
+	if synced {
+		return nil
+	}
+	return s.Sync(ctx)
+}
+
+func (s *service) Sync(ctx context.Context) error {
+	chains, err := s.loadChains(ctx)
+	if err != nil {
+		return err
+	}
+
+	var triggers []*registeredTrigger
+	subjects := map[string]struct{}{}
+	for _, chain := range chains {
+		for i, trigger := range chain.Triggers {
+			rt := &registeredTrigger{chain: chain, trigger: trigger}
+			switch trigger.Type {
+			case taskengine.TriggerKeyword:
+				re, err := regexp.Compile("(?i)" + trigger.Pattern)
+				if err != nil || trigger.Pattern == "" {
+					log.Printf("chain %s: skipping keyword trigger with invalid pattern %q: %v", chain.ID, trigger.Pattern, err)
+					continue
+				}
+				rt.keyword = re
+			case taskengine.TriggerSemantic:
+				rt.text = trigger.Pattern
+				if rt.text == "" {
+					rt.text = trigger.Description
+				}
+				if rt.text == "" {
+					log.Printf("chain %s: skipping semantic trigger without pattern", chain.ID)
+					continue
+				}
+				rt.vectorID = fmt.Sprintf("trigger-%s-%d", chain.ID, i)
+			case taskengine.TriggerEvent:
+				if trigger.Pattern == "" {
+					log.Printf("chain %s: skipping event trigger without subject", chain.ID)
+					continue
+				}
+				subjects[trigger.Pattern] = struct{}{}
+			case taskengine.TriggerSchedule:
+				schedule, err := taskengine.ParseSchedule(trigger.Pattern, trigger.TimeZone)
+				if err != nil {
+					log.Printf("chain %s: skipping schedule trigger: %v", chain.ID, err)
+					continue
+				}
+				rt.schedule = schedule
+			default:
+				continue
+			}
+			triggers = append(triggers, rt)
+		}
+	}
+
+	var errs []error
+	if err := s.index(ctx, triggers); err != nil {
+		errs = append(errs, err)
+	}
+	if err := s.subscribe(subjects); err != nil {
+		errs = append(errs, err)
+	}
+
+	s.mu.Lock()
+	s.triggers = triggers
+	s.synced = true
+	s.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// loadChains returns the active version of every stored chain that declares triggers.
+func (s *service) loadChains(ctx context.Context) ([]*taskengine.ChainDefinition, error) {
+	storeInstance := store.New(s.db.WithoutTransaction())
+	records, err := storeInstance.ListTaskChains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chains: %w", err)
+	}
+	chains := make([]*taskengine.ChainDefinition, 0, len(records))
+	for _, record := range records {
+		version, err := storeInstance.GetTaskChainVersion(ctx, record.ID, record.ActiveVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load chain %s: %w", record.ID, err)
+		}
+		var chain taskengine.ChainDefinition
+		if err := json.Unmarshal(version.Definition, &chain); err != nil {
+			return nil, fmt.Errorf("failed to decode chain %s version %d: %w", version.ChainID, version.Version, err)
+		}
+		if len(chain.Triggers) > 0 {
+			chains = append(chains, &chain)
+		}
+	}
+	return chains, nil
+}
+
+// index stores the embeddings of semantic triggers in the vector store and removes
+// the ones of triggers that no longer exist. Unchanged triggers are not embedded again.
+func (s *service) index(ctx context.Context, triggers []*registeredTrigger) error {
+	storeInstance := store.New(s.db.WithoutTransaction())
+	current := map[string]struct{}{}
+	for _, rt := range triggers {
+		if rt.trigger.Type != taskengine.TriggerSemantic {
+			continue
+		}
+		current[rt.vectorID] = struct{}{}
+		s.mu.Lock()
+		indexedText, ok := s.indexed[rt.vectorID]
+		s.mu.Unlock()
+		if ok && indexedText == rt.text {
+			continue
+		}
+		data, model, err := s.embed(ctx, rt.text)
+		if err != nil {
+			return err
+		}
+		if err := s.vectors.Upsert(ctx, vectors.Vector{ID: rt.vectorID, Data: data}); err != nil {
+			return fmt.Errorf("failed to index trigger of chain %s: %w", rt.chain.ID, err)
+		}
+		// Registering the vector keeps it from being cleaned up as orphaned by searches.
+		_, err = storeInstance.GetChunkIndexByID(ctx, rt.vectorID)
+		if errors.Is(err, libdb.ErrNotFound) {
+			err = storeInstance.CreateChunkIndex(ctx, &store.ChunkIndex{
+				ID:             rt.vectorID,
+				VectorID:       rt.vectorID,
+				VectorStore:    "vald",
+				ResourceID:     rt.chain.ID,
+				ResourceType:   store.ResourceTypeTrigger,
+				EmbeddingModel: model,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to register trigger vector %s: %w", rt.vectorID, err)
+		}
+		s.mu.Lock()
+		s.indexed[rt.vectorID] = rt.text
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	var stale []string
+	for id := range s.indexed {
+		if _, ok := current[id]; !ok {
+			stale = append(stale, id)
+		}
+	}
+	s.mu.Unlock()
+	for _, id := range stale {
+		if err := s.vectors.Delete(ctx, id); err != nil {
+			return fmt.Errorf("failed to remove trigger vector %s: %w", id, err)
+		}
+		if err := storeInstance.DeleteChunkIndex(ctx, id); err != nil && !errors.Is(err, libdb.ErrNotFound) {
+			return fmt.Errorf("failed to unregister trigger vector %s: %w", id, err)
+		}
+		s.mu.Lock()
+		delete(s.indexed, id)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// subscribe keeps one libbus queue subscription per event trigger subject.
+func (s *service) subscribe(subjects map[string]struct{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for subject, cancel := range s.subscriptions {
+		if _, ok := subjects[subject]; ok {
+			continue
+		}
+		// Cancelling the stream context also ends the libbus subscription.
+		cancel()
+		delete(s.subscriptions, subject)
+	}
+	var errs []error
+	for subject := range subjects {
+		if _, ok := s.subscriptions[subject]; ok {
+			continue
+		}
+		subCtx, cancel := context.WithCancel(s.ctx)
+		ch := make(chan []byte, 16)
+		if _, err := s.ps.QueueStream(subCtx, subject, eventQueue, ch); err != nil {
+			cancel()
+			errs = append(errs, fmt.Errorf("failed to subscribe to %s: %w", subject, err))
+			continue
+		}
+		s.subscriptions[subject] = cancel
+		go s.listen(subCtx, subject, ch)
+	}
+	return errors.Join(errs...)
+}
+
+// listen starts the chains with an event trigger for subject, using each message as input.
+// Each chain runs on its own goroutine, at most maxEventFirings at a time.
+func (s *service) listen(ctx context.Context, subject string, ch <-chan []byte) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case data := <-ch:
+			s.mu.Lock()
+			triggers := s.triggers
+			s.mu.Unlock()
+			fired := map[string]struct{}{}
+			for _, rt := range triggers {
+				if rt.trigger.Type != taskengine.TriggerEvent || rt.trigger.Pattern != subject {
+					continue
+				}
+				if _, ok := fired[rt.chain.ID]; ok {
+					continue
+				}
+				fired[rt.chain.ID] = struct{}{}
+				select {
+				case s.eventFirings <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				match := &Match{
+					ChainID: rt.chain.ID,
+					Trigger: rt.trigger,
+					Reason:  fmt.Sprintf("event received on subject %q", subject),
+				}
+				go func() {
+					defer func() { <-s.eventFirings }()
+					firing := s.fire(ctx, match, string(data), uuid.NewString())
+					if firing.Error != "" {
+						log.Printf("chain %s fired by event on %s failed: %s", match.ChainID, subject, firing.Error)
+					}
+				}()
+			}
+		}
+	}
+}
+
+func (s *service) ListSchedules(ctx context.Context, count int) ([]*ScheduledTrigger, error) {
+	tx := s.db.WithoutTransaction()
+	if err := serverops.CheckServiceAuthorization(ctx, store.New(tx), s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	if err := s.ensureSynced(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	triggers := s.triggers
+	s.mu.Unlock()
+
+	now := time.Now().UTC()
+	scheduled := []*ScheduledTrigger{}
+	for _, rt := range triggers {
+		if rt.schedule == nil {
+			continue
+		}
+		upcoming := []time.Time{}
+		for next := rt.schedule.Next(now); !next.IsZero() && len(upcoming) < count; next = rt.schedule.Next(next) {
+			upcoming = append(upcoming, next)
+		}
+		scheduled = append(scheduled, &ScheduledTrigger{
+			ChainID:  rt.chain.ID,
+			Trigger:  rt.trigger,
+			Upcoming: upcoming,
+		})
+	}
+	return scheduled, nil
+}
+
+func (s *service) ListRuns(ctx context.Context, chainID string, limit int) ([]*store.TriggerRun, error) {
+	tx := s.db.WithoutTransaction()
+	storeInstance := store.New(tx)
+	if err := serverops.CheckServiceAuthorization(ctx, storeInstance, s, store.PermissionView); err != nil {
+		return nil, err
+	}
+	return storeInstance.ListTriggerRuns(ctx, chainID, limit)
+}
+
+func (s *service) RunSchedules(ctx context.Context) error {
+	if err := s.ensureSynced(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	triggers := s.triggers
+	s.mu.Unlock()
+
+	now := time.Now().UTC()
+	from := now.Add(-scheduleLookback)
+	if from.Before(s.started) {
+		from = s.started
+	}
+	storeInstance := store.New(s.db.WithoutTransaction())
+	var errs []error
+	for _, rt := range triggers {
+		if rt.schedule == nil {
+			continue
+		}
+		// Schedules have a resolution of one minute, which bounds the number of due runs.
+		for _, at := range rt.schedule.Between(from, now, int(scheduleLookback/time.Minute)+1) {
+			run := &store.TriggerRun{
+				ID:          uuid.NewString(),
+				ChainID:     rt.chain.ID,
+				Schedule:    scheduleKey(rt.trigger),
+				ScheduledAt: at,
+				ExecutionID: uuid.NewString(),
+			}
+			err := storeInstance.ClaimTriggerRun(ctx, run)
+			if errors.Is(err, libdb.ErrUniqueViolation) {
+				continue
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to claim run of chain %s at %s: %w", rt.chain.ID, at, err))
+				continue
+			}
+			match := &Match{
+				ChainID: rt.chain.ID,
+				Trigger: rt.trigger,
+				Reason:  fmt.Sprintf("scheduled by %q at %s", run.Schedule, at.Format(time.RFC3339)),
+			}
+			// Runs outlive the cycle that started them.
+			go s.runScheduled(s.ctx, run, match, rt.trigger.Input)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *service) runScheduled(ctx context.Context, run *store.TriggerRun, match *Match, input string) {
+	firing := s.fire(ctx, match, input, run.ExecutionID)
+	status := store.TriggerRunCompleted
+	if firing.Error != "" {
+		status = store.TriggerRunFailed
+		log.Printf("scheduled run of chain %s failed: %s", run.ChainID, firing.Error)
+	}
+	if err := store.New(s.db.WithoutTransaction()).FinishTriggerRun(ctx, run.ID, status, firing.Error); err != nil {
+		log.Printf("failed to record run of chain %s: %v", run.ChainID, err)
+	}
+}
+
+// scheduleKey identifies a schedule in trigger runs, using the CRON_TZ prefix for time zones.
+func scheduleKey(trigger taskengine.Trigger) string {
+	if trigger.TimeZone == "" {
+		return trigger.Pattern
+	}
+	return "CRON_TZ=" + trigger.TimeZone + " " + trigger.Pattern
+}
+
+func (s *service) search(ctx context.Context, input string) ([]vectors.VectorSearchResult, error) {
+	data, _, err := s.embed(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.vectors.Search(ctx, data, semanticSearchSize, 1, nil)
+	if err != nil {
+		return nil, fmt.Errorf("vector search failed: %w", err)
+	}
+	return results, nil
+}
+
+func (s *service) embed(ctx context.Context, text string) ([]float32, string, error) {
+	provider, err := s.embedder.GetProvider(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get embedder provider: %w", err)
+	}
+	embedClient, err := llmresolver.Embed(ctx, llmresolver.EmbedRequest{
+		ModelName: provider.ModelName(),
+	}, s.embedder.GetRuntime(ctx), llmresolver.Randomly)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve embed client: %w", err)
+	}
+	vectorData, err := embedClient.Embed(ctx, text)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to embed text: %w", err)
+	}
+	vectorData32 := make([]float32, len(vectorData))
+	for i, v := range vectorData {
+		vectorData32[i] = float32(v)
+	}
+	return vectorData32, provider.ModelName(), nil
+}
+
+func (s *service) GetServiceName() string {
+	return "triggerservice"
+}
+
+func (s *service) GetServiceGroup() string {
+	return serverops.DefaultDefaultServiceGroup
+}
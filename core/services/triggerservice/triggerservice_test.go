@@ -0,0 +1,234 @@
+package triggerservice_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/contenox/contenox/core/llmrepo"
+	"github.com/contenox/contenox/core/modelprovider"
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/serverops/store"
+	"github.com/contenox/contenox/core/services/secretservice"
+	"github.com/contenox/contenox/core/services/triggerservice"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libbus"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingEnv struct {
+	mu     sync.Mutex
+	inputs map[string][]string
+}
+
+func (e *recordingEnv) ExecEnv(_ context.Context, chain *taskengine.ChainDefinition, input string) (any, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.inputs[chain.ID] = append(e.inputs[chain.ID], input)
+	return "ran " + chain.ID, nil
+}
+
+func (e *recordingEnv) ResumeEnv(_ context.Context, chain *taskengine.ChainDefinition, _ *taskengine.ExecutionState) (any, error) {
+	return "ran " + chain.ID, nil
+}
+
+func (e *recordingEnv) calls(chainID string) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.inputs[chainID]...)
+}
+
+func storeChain(ctx context.Context, t *testing.T, db libdb.DBManager, chain *taskengine.ChainDefinition) {
+	t.Helper()
+	definition, err := json.Marshal(chain)
+	require.NoError(t, err)
+	storeInstance := store.New(db.WithoutTransaction())
+	require.NoError(t, storeInstance.CreateTaskChain(ctx, &store.TaskChain{ID: chain.ID, ActiveVersion: 1}))
+	require.NoError(t, storeInstance.AppendTaskChainVersion(ctx, &store.TaskChainVersion{ChainID: chain.ID, Version: 1, Definition: definition}))
+}
+
+func TestTriggerService_KeywordAndEventTriggers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dbConn, _, dbCleanup, err := libdb.SetupLocalInstance(ctx, uuid.NewString(), "test", "test")
+	require.NoError(t, err)
+	defer dbCleanup()
+	db, err := libdb.NewPostgresDBManager(ctx, dbConn, store.Schema)
+	require.NoError(t, err)
+	ps, psCleanup, err := libbus.NewTestPubSub()
+	require.NoError(t, err)
+	defer psCleanup()
+	require.NoError(t, serverops.NewServiceManager(&serverops.Config{
+		JWTExpiry:       "1h",
+		SecurityEnabled: "false",
+	}))
+
+	storeChain(ctx, t, db, &taskengine.ChainDefinition{
+		ID: "refunds",
+		Triggers: []taskengine.Trigger{
+			{Type: taskengine.TriggerKeyword, Pattern: `\brefund\b`},
+		},
+	})
+	storeChain(ctx, t, db, &taskengine.ChainDefinition{
+		ID: "signup",
+		Triggers: []taskengine.Trigger{
+			{Type: taskengine.TriggerEvent, Pattern: "user.created"},
+		},
+	})
+
+	storeChain(ctx, t, db, &taskengine.ChainDefinition{
+		ID: "digest",
+		Triggers: []taskengine.Trigger{
+			{Type: taskengine.TriggerSchedule, Pattern: "0 7 * * mon-fri", TimeZone: "Europe/Berlin"},
+		},
+	})
+
+	env := &recordingEnv{inputs: map[string][]string{}}
+	service := triggerservice.New(ctx, db, nil, nil, ps, env, nil)
+	require.NoError(t, service.Sync(ctx))
+
+	matches, err := service.Match(ctx, "I want a REFUND please")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	require.Equal(t, "refunds", matches[0].ChainID)
+	require.Contains(t, matches[0].Reason, `matched "REFUND"`)
+	require.Empty(t, env.calls("refunds"))
+
+	matches, err = service.Match(ctx, "refunded")
+	require.NoError(t, err)
+	require.Empty(t, matches)
+
+	firings, err := service.Dispatch(ctx, "refund")
+	require.NoError(t, err)
+	require.Len(t, firings, 1)
+	require.Equal(t, "ran refunds", firings[0].Output)
+	require.NotEmpty(t, firings[0].ExecutionID)
+	require.Equal(t, []string{"refund"}, env.calls("refunds"))
+
+	require.NoError(t, ps.Publish(ctx, "user.created", []byte(`{"id":"42"}`)))
+	require.Eventually(t, func() bool {
+		return len(env.calls("signup")) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+	require.Equal(t, `{"id":"42"}`, env.calls("signup")[0])
+
+	// A second replica shares the event subscriptions, so each event fires only once.
+	replicaEnv := &recordingEnv{inputs: map[string][]string{}}
+	replica := triggerservice.New(ctx, db, nil, nil, ps, replicaEnv, nil)
+	require.NoError(t, replica.Sync(ctx))
+	for range 10 {
+		require.NoError(t, ps.Publish(ctx, "user.created", []byte(`{"id":"43"}`)))
+	}
+	signups := func() int {
+		return len(env.calls("signup")) + len(replicaEnv.calls("signup"))
+	}
+	require.Eventually(t, func() bool {
+		return signups() == 11
+	}, 5*time.Second, 20*time.Millisecond)
+	require.Never(t, func() bool {
+		return signups() > 11
+	}, 200*time.Millisecond, 20*time.Millisecond)
+
+	schedules, err := service.ListSchedules(ctx, 3)
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	require.Equal(t, "digest", schedules[0].ChainID)
+	require.Len(t, schedules[0].Upcoming, 3)
+	for _, at := range schedules[0].Upcoming {
+		require.Equal(t, "Europe/Berlin", at.Location().String())
+		require.Equal(t, 7, at.Hour())
+		require.NotEqual(t, time.Saturday, at.Weekday())
+		require.NotEqual(t, time.Sunday, at.Weekday())
+	}
+
+	require.NoError(t, service.RunSchedules(ctx))
+	runs, err := service.ListRuns(ctx, "digest", 10)
+	require.NoError(t, err)
+	require.Empty(t, runs)
+}
+
+// doneEnv reports the outcome of every execution of its environment.
+type doneEnv struct {
+	taskengine.EnvExecutor
+	done chan error
+}
+
+func (e *doneEnv) ExecEnv(ctx context.Context, chain *taskengine.ChainDefinition, input string) (any, error) {
+	output, err := e.EnvExecutor.ExecEnv(ctx, chain, input)
+	e.done <- err
+	return output, err
+}
+
+func TestTriggerService_ScheduledChainResolvesSecrets(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dbConn, _, dbCleanup, err := libdb.SetupLocalInstance(ctx, uuid.NewString(), "test", "test")
+	require.NoError(t, err)
+	defer dbCleanup()
+	db, err := libdb.NewPostgresDBManager(ctx, dbConn, store.Schema)
+	require.NoError(t, err)
+	ps, psCleanup, err := libbus.NewTestPubSub()
+	require.NoError(t, err)
+	defer psCleanup()
+	require.NoError(t, serverops.NewServiceManager(&serverops.Config{
+		JWTExpiry:       "1h",
+		SecurityEnabled: "false",
+	}))
+	secrets, err := secretservice.New(db, "test-encryption-key-0123456789")
+	require.NoError(t, err)
+	_, err = secrets.Create(ctx, &secretservice.Input{Name: "crm_token", Value: "s3cr3t"})
+	require.NoError(t, err)
+	storeChain(ctx, t, db, &taskengine.ChainDefinition{
+		ID: "nightly",
+		Triggers: []taskengine.Trigger{
+			{Type: taskengine.TriggerSchedule, Pattern: "* * * * *"},
+		},
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:   "sync",
+				Type: taskengine.Hook,
+				Hook: &taskengine.HookCall{
+					Type: "mock",
+					Args: map[string]string{"headers": `{"Authorization": "Bearer {{ secret "crm_token" }}"}`},
+				},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	})
+
+	// Scheduled runs have no user identity to authorize reading secrets with.
+	require.NoError(t, serverops.NewServiceManager(&serverops.Config{
+		JWTExpiry:       "1h",
+		JWTSecret:       "test-jwt-secret",
+		SecurityEnabled: "true",
+	}))
+	resolver, err := secretservice.NewResolver(db, "test-encryption-key-0123456789")
+	require.NoError(t, err)
+	hooks := taskengine.NewMockHookRegistry()
+	exec, err := taskengine.NewExec(ctx, &llmrepo.MockModelRepo{Provider: &modelprovider.MockProvider{}}, hooks)
+	require.NoError(t, err)
+	env, err := taskengine.NewEnv(ctx, serverops.NoopTracker{}, exec, taskengine.WithSecrets(resolver))
+	require.NoError(t, err)
+	done := &doneEnv{EnvExecutor: env, done: make(chan error, 1)}
+	service := triggerservice.New(ctx, db, nil, nil, ps, done, resolver.Trusted())
+	require.NoError(t, service.Sync(ctx))
+
+	// Schedules have a resolution of one minute.
+	time.Sleep(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)))
+	require.NoError(t, service.RunSchedules(ctx))
+	select {
+	case err := <-done.done:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the scheduled run did not finish")
+	}
+	require.Len(t, hooks.Calls, 1)
+	require.Equal(t, `{"Authorization": "Bearer s3cr3t"}`, hooks.Calls[0].Args["headers"])
+}
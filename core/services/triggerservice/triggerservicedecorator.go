@@ -0,0 +1,86 @@
+package triggerservice
+
+import (
+	"context"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/serverops/store"
+)
+
+type activityTrackerDecorator struct {
+	service Service
+	tracker serverops.ActivityTracker
+}
+
+func (d *activityTrackerDecorator) Match(ctx context.Context, input string) ([]*Match, error) {
+	reportErrFn, _, endFn := d.tracker.Start(
+		ctx,
+		"match",
+		"trigger",
+		"inputLength", len(input),
+	)
+	defer endFn()
+
+	matches, err := d.service.Match(ctx, input)
+	if err != nil {
+		reportErrFn(err)
+	}
+
+	return matches, err
+}
+
+func (d *activityTrackerDecorator) Dispatch(ctx context.Context, input string) ([]*Firing, error) {
+	reportErrFn, reportChangeFn, endFn := d.tracker.Start(
+		ctx,
+		"dispatch",
+		"trigger",
+		"inputLength", len(input),
+	)
+	defer endFn()
+
+	firings, err := d.service.Dispatch(ctx, input)
+	if err != nil {
+		reportErrFn(err)
+	} else {
+		fired := make([]string, 0, len(firings))
+		for _, firing := range firings {
+			fired = append(fired, firing.ChainID)
+		}
+		reportChangeFn("trigger", map[string]interface{}{
+			"chains": fired,
+		})
+	}
+
+	return firings, err
+}
+
+func (d *activityTrackerDecorator) Sync(ctx context.Context) error {
+	return d.service.Sync(ctx)
+}
+
+func (d *activityTrackerDecorator) RunSchedules(ctx context.Context) error {
+	return d.service.RunSchedules(ctx)
+}
+
+func (d *activityTrackerDecorator) ListSchedules(ctx context.Context, count int) ([]*ScheduledTrigger, error) {
+	return d.service.ListSchedules(ctx, count)
+}
+
+func (d *activityTrackerDecorator) ListRuns(ctx context.Context, chainID string, limit int) ([]*store.TriggerRun, error) {
+	return d.service.ListRuns(ctx, chainID, limit)
+}
+
+func (d *activityTrackerDecorator) GetServiceName() string {
+	return d.service.GetServiceName()
+}
+
+func (d *activityTrackerDecorator) GetServiceGroup() string {
+	return d.service.GetServiceGroup()
+}
+
+func WithActivityTracker(service Service, tracker serverops.ActivityTracker) Service {
+	return &activityTrackerDecorator{
+		service: service,
+		tracker: tracker,
+	}
+}
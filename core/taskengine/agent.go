@@ -0,0 +1,226 @@
+package taskengine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/contenox/contenox/core/llmresolver"
+	"github.com/contenox/contenox/core/serverops"
+)
+
+// ToolSchema describes how a model calls a hook as a tool.
+type ToolSchema struct {
+	// Name is the registered hook name.
+	Name string `json:"name"`
+
+	// Description tells the model what the hook does.
+	Description string `json:"description,omitempty"`
+
+	// Input describes the HookCall.Input the hook expects, empty if it uses none.
+	Input string `json:"input,omitempty"`
+
+	// Args is the JSON Schema of the HookCall.Args object.
+	// Defaults to an object with arbitrary string properties.
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// ToolDescriber is implemented by hooks that describe how to call them as tools.
+// Hooks that do not implement it are offered with a generic schema.
+type ToolDescriber interface {
+	Tools(ctx context.Context) ([]ToolSchema, error)
+}
+
+// ToolExecutor is implemented by TaskExecutors that support Agent tasks.
+type ToolExecutor interface {
+	ChatExecutor
+	ToolDescriber
+
+	// CallTool runs a hook on behalf of a model and returns its result.
+	CallTool(ctx context.Context, call *HookCall) (any, error)
+}
+
+func defaultToolSchema(name string) ToolSchema {
+	return ToolSchema{
+		Name:  name,
+		Input: "Text passed to the hook.",
+		Args: map[string]any{
+			"type":                 "object",
+			"additionalProperties": map[string]any{"type": "string"},
+		},
+	}
+}
+
+// agentStepSchema is the JSON Schema of every model reply in an Agent task.
+var agentStepSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"tool":   map[string]any{"type": "string"},
+		"input":  map[string]any{"type": "string"},
+		"args":   map[string]any{"type": "object"},
+		"answer": map[string]any{"type": "string"},
+	},
+}
+
+// agent runs the reason-act loop of an Agent task and returns the final answer of the model.
+// Every tool call is tracked as a "tool_call" operation of the task.
+func (exe SimpleEnv) agent(ctx context.Context, resolver llmresolver.Policy, task *ChainTask, renderedPrompt string) (any, string, error) {
+	cfg := task.Agent
+	if cfg == nil || len(cfg.Tools) == 0 {
+		return nil, "", fmt.Errorf("agent task has no tools")
+	}
+	toolExec, ok := exe.exec.(ToolExecutor)
+	if !ok {
+		return nil, "", fmt.Errorf("agent tasks are not supported by this executor")
+	}
+	registered, err := toolExec.Tools(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list tools: %w", err)
+	}
+	tools := make(map[string]ToolSchema, len(cfg.Tools))
+	for _, name := range cfg.Tools {
+		for _, schema := range registered {
+			if schema.Name == name {
+				tools[name] = schema
+			}
+		}
+		if _, ok := tools[name]; !ok {
+			return nil, "", fmt.Errorf("tool %q is not registered", name)
+		}
+	}
+	maxIterations := cfg.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultAgentMaxIterations
+	}
+
+	messages := []serverops.Message{
+		{Role: "system", Content: agentInstructions(cfg.Tools, tools)},
+		{Role: "user", Content: renderedPrompt},
+	}
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		if err := chargeMessages(ctx, task, messages); err != nil {
+			return nil, "", err
+		}
+		reply, err := toolExec.ChatExec(ctx, resolver, task, messages)
+		if err != nil {
+			return nil, "", err
+		}
+		messages = append(messages, serverops.Message{Role: "assistant", Content: reply.Content})
+
+		parsed, problems := parseJSONResponse(reply.Content, agentStepSchema)
+		step, _ := parsed.(map[string]any)
+		if len(problems) > 0 {
+			messages = append(messages, serverops.Message{
+				Role:    "user",
+				Content: fmt.Sprintf("Invalid response: %s. Respond with a single JSON object calling a tool or giving the answer.", strings.Join(problems, "; ")),
+			})
+			continue
+		}
+		if answer, ok := step["answer"].(string); ok {
+			return answer, answer, nil
+		}
+		name, _ := step["tool"].(string)
+		schema, ok := tools[name]
+		if !ok {
+			messages = append(messages, serverops.Message{
+				Role:    "user",
+				Content: fmt.Sprintf("Tool %q is not available. Use one of: %s.", name, strings.Join(cfg.Tools, ", ")),
+			})
+			continue
+		}
+		messages = append(messages, serverops.Message{
+			Role:    "user",
+			Content: exe.callTool(ctx, toolExec, task, iteration, schema, step),
+		})
+	}
+	return nil, "", fmt.Errorf("agent gave no final answer within %d iterations", maxIterations)
+}
+
+// callTool runs a tool call requested by the model and returns the message reporting its outcome.
+func (exe SimpleEnv) callTool(ctx context.Context, toolExec ToolExecutor, task *ChainTask, iteration int, schema ToolSchema, step map[string]any) string {
+	input, _ := step["input"].(string)
+	rawArgs, _ := step["args"].(map[string]any)
+	if rawArgs == nil {
+		rawArgs = map[string]any{}
+	}
+	call := &HookCall{Type: schema.Name, Input: input, Args: make(map[string]string, len(rawArgs))}
+	for key, value := range rawArgs {
+		if s, ok := value.(string); ok {
+			call.Args[key] = s
+		} else {
+			call.Args[key] = compactJSON(value)
+		}
+	}
+
+	reportErr, reportChange, end := exe.tracker.Start(
+		ctx,
+		"tool_call",
+		task.ID,
+		"tool", schema.Name,
+		"iteration", iteration,
+		"input", call.Input,
+		"args", call.Args,
+	)
+	defer end()
+
+	if problems := validateSchema(schema.Args, rawArgs); len(problems) > 0 {
+		err := fmt.Errorf("invalid args: %s", strings.Join(problems, "; "))
+		reportErr(err)
+		return fmt.Sprintf("Tool %s was not called: %v", schema.Name, err)
+	}
+	result, err := toolExec.CallTool(ctx, call)
+	if err != nil {
+		reportErr(err)
+		return fmt.Sprintf("Tool %s failed: %v", schema.Name, err)
+	}
+	reportChange(task.ID, result)
+	return fmt.Sprintf("Tool %s returned:\n%s", schema.Name, formatToolResult(result))
+}
+
+func formatToolResult(result any) string {
+	if s, ok := result.(string); ok {
+		return s
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Sprintf("%v", result)
+	}
+	return string(encoded)
+}
+
+// agentInstructions is the system prompt of an Agent task listing the tools in the given order.
+func agentInstructions(names []string, tools map[string]ToolSchema) string {
+	var b strings.Builder
+	b.WriteString("Complete the task of the user. You can call the following tools:\n")
+	for _, name := range names {
+		schema := tools[name]
+		b.WriteString("\n- ")
+		b.WriteString(name)
+		if schema.Description != "" {
+			b.WriteString(": ")
+			b.WriteString(schema.Description)
+		}
+		if schema.Input != "" {
+			b.WriteString("\n  input: ")
+			b.WriteString(schema.Input)
+		}
+		if schema.Args != nil {
+			b.WriteString("\n  args: ")
+			b.WriteString(compactJSON(schema.Args))
+		}
+	}
+	b.WriteString("\n\nRespond with a single JSON object and nothing else. To call a tool respond with\n")
+	b.WriteString(`{"tool": "<name>", "input": "<input>", "args": {<args>}}`)
+	b.WriteString("\nand wait for its result. Once you can complete the task respond with\n")
+	b.WriteString(`{"answer": "<final answer>"}`)
+	return b.String()
+}
+
+// sortTools orders tool schemas by name, so the tools are offered in a stable order.
+func sortTools(tools []ToolSchema) {
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Name < tools[j].Name
+	})
+}
@@ -0,0 +1,106 @@
+package taskengine_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/contenox/contenox/core/llmrepo"
+	"github.com/contenox/contenox/core/modelprovider"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func agentSetup(t *testing.T, responses ...string) (*modelprovider.MockProvider, *taskengine.MockHookRepo, *taskengine.MockHookRepo, taskengine.TaskExecutor) {
+	t.Helper()
+	mockProvider := &modelprovider.MockProvider{
+		Name:          "agent-model",
+		CanChatFlag:   true,
+		ContextLength: 4096,
+		ID:            uuid.NewString(),
+		Backends:      []string{"backend1"},
+		ChatResponses: responses,
+	}
+	lookup := taskengine.NewMockHookRegistry()
+	lookup.ResponseMap["lookup"] = "shipped"
+	remove := taskengine.NewMockHookRegistry()
+	hooks := taskengine.NewSimpleHookProvider(map[string]taskengine.HookRepo{
+		"lookup": lookup,
+		"delete": remove,
+	})
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: mockProvider}, hooks)
+	require.NoError(t, err)
+	return mockProvider, lookup, remove, exec
+}
+
+func agentChain(maxIterations int) *taskengine.ChainDefinition {
+	return &taskengine.ChainDefinition{
+		ID: "support",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "resolve",
+				Type:           taskengine.Agent,
+				PromptTemplate: "Where is {{ .input }}?",
+				Agent: &taskengine.AgentConfig{
+					Tools:         []string{"lookup"},
+					MaxIterations: maxIterations,
+				},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+}
+
+func TestSimpleEnv_AgentCallsAllowedTools(t *testing.T) {
+	mockProvider, lookup, remove, exec := agentSetup(t,
+		`{"tool": "delete", "input": "order 42"}`,
+		"```json\n{\"tool\": \"lookup\", \"input\": \"order 42\", \"args\": {\"field\": \"status\"}}\n```",
+		`{"answer": "Order 42 has shipped."}`,
+	)
+	tracker := &recordingTracker{}
+	env, err := taskengine.NewEnv(context.Background(), tracker, exec)
+	require.NoError(t, err)
+
+	output, err := env.ExecEnv(context.Background(), agentChain(0), "order 42")
+	require.NoError(t, err)
+	require.Equal(t, "Order 42 has shipped.", output)
+
+	require.Empty(t, remove.Calls)
+	require.Equal(t, []taskengine.HookCall{{Type: "lookup", Input: "order 42", Args: map[string]string{"field": "status"}}}, lookup.Calls)
+
+	require.Len(t, mockProvider.Chats, 3)
+	system := mockProvider.Chats[0][0]
+	require.Equal(t, "system", system.Role)
+	require.Contains(t, system.Content, "- lookup")
+	require.NotContains(t, system.Content, "delete")
+	require.Equal(t, "Where is order 42?", mockProvider.Chats[0][1].Content)
+	require.Contains(t, mockProvider.Chats[1][3].Content, `Tool "delete" is not available`)
+	require.Equal(t, "Tool lookup returned:\nshipped", mockProvider.Chats[2][5].Content)
+
+	var toolCalls []*recordedOperation
+	for _, op := range tracker.operations {
+		if op.operation == "tool_call" {
+			toolCalls = append(toolCalls, op)
+		}
+	}
+	require.Len(t, toolCalls, 1)
+	require.Equal(t, "resolve", toolCalls[0].subject)
+	require.Equal(t, []any{"tool", "lookup", "iteration", 1, "input", "order 42", "args", map[string]string{"field": "status"}}, toolCalls[0].args)
+	require.Equal(t, "shipped", toolCalls[0].change)
+}
+
+func TestSimpleEnv_AgentIterationLimit(t *testing.T) {
+	_, lookup, _, exec := agentSetup(t,
+		`{"tool": "lookup", "input": "order 42"}`,
+		`{"tool": "lookup", "input": "order 42"}`,
+		`{"answer": "too late"}`,
+	)
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, exec)
+	require.NoError(t, err)
+
+	_, err = env.ExecEnv(context.Background(), agentChain(2), "order 42")
+	require.ErrorContains(t, err, "agent gave no final answer within 2 iterations")
+	require.Len(t, lookup.Calls, 2)
+}
@@ -0,0 +1,135 @@
+package taskengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+)
+
+// ErrExecutionPaused is returned when an execution stopped to wait for an approval.
+// The execution is checkpointed with status ExecutionWaiting and continues via ResumeEnv
+// once ExecutionState.Decision is set.
+var ErrExecutionPaused = errors.New("execution paused")
+
+// ErrApprovalExpired is the task error of an Approval task that was not decided in time.
+// Like any task error, it follows the task's on_error transition.
+var ErrApprovalExpired = errors.New("approval expired")
+
+// ApprovalAction is the answer given to an approval.
+type ApprovalAction string
+
+const (
+	// ApprovalApprove accepts the value as it is.
+	ApprovalApprove ApprovalAction = "approve"
+
+	// ApprovalReject declines the value.
+	ApprovalReject ApprovalAction = "reject"
+
+	// ApprovalEdit accepts the value replaced by ApprovalDecision.Value.
+	ApprovalEdit ApprovalAction = "edit"
+
+	// ApprovalExpire marks an approval that was not answered before it expired.
+	ApprovalExpire ApprovalAction = "expire"
+)
+
+// ApprovalDecision is the outcome of an approval.
+type ApprovalDecision struct {
+	Action ApprovalAction `json:"action"`
+
+	// Value replaces the approved value, only for ApprovalEdit.
+	Value any `json:"value,omitempty"`
+
+	Comment   string `json:"comment,omitempty"`
+	DecidedBy string `json:"decidedBy,omitempty"`
+}
+
+// ApprovalRequest describes an approval an execution waits for.
+type ApprovalRequest struct {
+	ExecutionID string
+	ChainID     string
+	TaskID      string
+
+	// Prompt is the rendered prompt template of the Approval task, shown to approvers.
+	Prompt string
+
+	// Value is the value to approve, the output of the previous task.
+	Value any
+
+	// Vars holds the template variables rendered so far.
+	Vars map[string]any
+
+	// ExpiresAt is set if the task has a timeout.
+	ExpiresAt *time.Time
+}
+
+// ApprovalGate records pending approvals and notifies approvers.
+type ApprovalGate interface {
+	RequestApproval(ctx context.Context, req *ApprovalRequest) error
+}
+
+// WithApprovalGate enables Approval tasks, which fail without a gate.
+func WithApprovalGate(gate ApprovalGate) EnvOption {
+	return func(env *SimpleEnv) {
+		env.approvals = gate
+	}
+}
+
+// approval runs an Approval task.
+//
+// Without a decision, it requests an approval and pauses the execution.
+// With a decision, the output is the approved (or edited) value and the raw response
+// is the action, so transitions can branch on "approve", "reject" or "edit".
+func (exe SimpleEnv) approval(ctx context.Context, state *ExecutionState, task *ChainTask, renderedPrompt string) (any, string, error) {
+	decision := state.Decision
+	if decision == nil && exe.script != nil {
+		action, err := exe.script.next(task.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		decision = &ApprovalDecision{Action: ApprovalAction(action)}
+	}
+	if decision == nil {
+		if exe.approvals == nil {
+			return nil, "", fmt.Errorf("approval tasks are not supported in this environment")
+		}
+		req := &ApprovalRequest{
+			ExecutionID: state.ID,
+			ChainID:     state.ChainID,
+			TaskID:      task.ID,
+			Prompt:      renderedPrompt,
+			Value:       state.Vars["previous_output"],
+			Vars:        maps.Clone(state.Vars),
+		}
+		if task.Timeout != "" {
+			timeout, err := time.ParseDuration(task.Timeout)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid timeout: %v", err)
+			}
+			expiresAt := time.Now().UTC().Add(timeout)
+			req.ExpiresAt = &expiresAt
+		}
+		// The execution must be visible as waiting before approvers can answer.
+		state.Status = ExecutionWaiting
+		if err := exe.checkpoint(ctx, state); err != nil {
+			return nil, "", err
+		}
+		if err := exe.approvals.RequestApproval(ctx, req); err != nil {
+			return nil, "", fmt.Errorf("failed to request approval: %w", err)
+		}
+		return nil, "", ErrExecutionPaused
+	}
+
+	state.Decision = nil
+	switch decision.Action {
+	case ApprovalApprove, ApprovalReject:
+		return state.Vars["previous_output"], string(decision.Action), nil
+	case ApprovalEdit:
+		return decision.Value, string(decision.Action), nil
+	case ApprovalExpire:
+		return nil, "", ErrApprovalExpired
+	default:
+		return nil, "", fmt.Errorf("unknown approval action %q", decision.Action)
+	}
+}
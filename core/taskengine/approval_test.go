@@ -0,0 +1,123 @@
+package taskengine_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryApprovalGate struct {
+	requests []*taskengine.ApprovalRequest
+}
+
+func (m *memoryApprovalGate) RequestApproval(_ context.Context, req *taskengine.ApprovalRequest) error {
+	m.requests = append(m.requests, req)
+	return nil
+}
+
+func approvalChain() *taskengine.ChainDefinition {
+	return &taskengine.ChainDefinition{
+		ID: "publish",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "draft",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "draft for {{ .input }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "signoff"}},
+				},
+			},
+			{
+				ID:             "signoff",
+				Type:           taskengine.Approval,
+				PromptTemplate: "Publish {{ .draft }}?",
+				Timeout:        "24h",
+				Transition: taskengine.Transition{
+					OnError: "escalate",
+					Next: []taskengine.ConditionalTransition{
+						{Operator: "equals", Value: "reject", ID: "escalate"},
+						{Value: "_default", ID: "send"},
+					},
+				},
+			},
+			{
+				ID:             "send",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "sent {{ .signoff }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+			{
+				ID:             "escalate",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "escalated",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+}
+
+func TestSimpleEnv_ApprovalPausesAndResumes(t *testing.T) {
+	tests := []struct {
+		name     string
+		decision *taskengine.ApprovalDecision
+		output   string
+	}{
+		{"approve", &taskengine.ApprovalDecision{Action: taskengine.ApprovalApprove}, "sent draft for news"},
+		{"edit", &taskengine.ApprovalDecision{Action: taskengine.ApprovalEdit, Value: "fixed draft"}, "sent fixed draft"},
+		{"reject", &taskengine.ApprovalDecision{Action: taskengine.ApprovalReject}, "escalated"},
+		{"expire", &taskengine.ApprovalDecision{Action: taskengine.ApprovalExpire}, "escalated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkpointer := &memoryCheckpointer{}
+			gate := &memoryApprovalGate{}
+			exec := &recordingExecutor{}
+			env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec,
+				taskengine.WithCheckpointer(checkpointer),
+				taskengine.WithApprovalGate(gate),
+			)
+			require.NoError(t, err)
+
+			chain := approvalChain()
+			_, err = env.ExecEnv(context.Background(), chain, "news")
+			require.ErrorIs(t, err, taskengine.ErrExecutionPaused)
+			require.Len(t, gate.requests, 1)
+			require.Equal(t, "signoff", gate.requests[0].TaskID)
+			require.Equal(t, "Publish draft for news?", gate.requests[0].Prompt)
+			require.Equal(t, "draft for news", gate.requests[0].Value)
+			require.NotNil(t, gate.requests[0].ExpiresAt)
+
+			state := checkpointer.checkpoints[len(checkpointer.checkpoints)-1]
+			require.Equal(t, taskengine.ExecutionWaiting, state.Status)
+			require.Equal(t, "signoff", state.CurrentTask)
+
+			_, err = env.ResumeEnv(context.Background(), chain, &state)
+			require.ErrorContains(t, err, "waiting for an approval decision")
+
+			state.Decision = tt.decision
+			output, err := env.ResumeEnv(context.Background(), chain, &state)
+			require.NoError(t, err)
+			require.Equal(t, tt.output, output)
+			require.Equal(t, taskengine.ExecutionCompleted, state.Status)
+			require.Nil(t, state.Decision)
+			require.Len(t, gate.requests, 1)
+		})
+	}
+}
+
+func TestSimpleEnv_ApprovalWithoutGateFails(t *testing.T) {
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, &recordingExecutor{})
+	require.NoError(t, err)
+
+	chain := approvalChain()
+	chain.Tasks[1].Transition.OnError = ""
+	_, err = env.ExecEnv(context.Background(), chain, "news")
+	require.ErrorContains(t, err, "approval tasks are not supported")
+}
@@ -0,0 +1,143 @@
+package taskengine
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// HookQueue runs the hooks of non-blocking Hook tasks in the background.
+type HookQueue interface {
+	// Enqueue schedules the hook call of job and returns the ID of the pending hook.
+	Enqueue(ctx context.Context, job *HookJob) (string, error)
+}
+
+// WithHookQueue makes Hook tasks whose HookCall is Async hand their hook to queue
+// and continue immediately. Without a queue, every hook runs inline.
+func WithHookQueue(queue HookQueue) EnvOption {
+	return func(env *SimpleEnv) {
+		env.hooks = queue
+	}
+}
+
+// HookJob is the hook call of a non-blocking Hook task.
+type HookJob struct {
+	ExecutionID string   `json:"executionId"`
+	ChainID     string   `json:"chainId"`
+	TaskID      string   `json:"taskId"`
+	Hook        HookCall `json:"hook"`
+
+	// Secrets maps the placeholders in the args of Hook to the names of the secrets they stand for.
+	// Secrets are resolved by RunHookJob right before the hook runs, so they are never queued.
+	Secrets map[string]string `json:"secrets,omitempty"`
+}
+
+// Status of a hook handed to the HookQueue, see the output of non-blocking Hook tasks.
+const HookPending = "pending"
+
+// enqueueHook hands the hook of a non-blocking Hook task to the HookQueue.
+// The output of the task is a handle to the pending hook with its hookId, hook name and status.
+func (exe SimpleEnv) enqueueHook(ctx context.Context, task *ChainTask, vars map[string]any) (any, string, error) {
+	nonce, err := secretNonce()
+	if err != nil {
+		return nil, "", err
+	}
+	job := &HookJob{
+		ChainID: runningChainID(ctx),
+		TaskID:  task.ID,
+		Hook:    *task.Hook,
+		Secrets: map[string]string{},
+	}
+	job.ExecutionID, _ = ExecutionIDFromContext(ctx)
+	// Secret references are rendered as placeholders that rendered variables cannot forge,
+	// because the nonce is only known to the job. They are resolved once here, so the job
+	// is only queued if the secrets exist and the caller may read them.
+	secret := func(name string) (string, error) {
+		if _, err := exe.resolveSecret(ctx, name); err != nil {
+			return "", err
+		}
+		placeholder := fmt.Sprintf("secret:%d:%s", len(job.Secrets), nonce)
+		job.Secrets[placeholder] = name
+		return placeholder, nil
+	}
+	job.Hook.Args = make(map[string]string, len(task.Hook.Args))
+	for name, text := range task.Hook.Args {
+		tmpl, err := parseTemplate(text, true)
+		if err != nil {
+			return nil, "", fmt.Errorf("hook arg %s: template error: %v", name, err)
+		}
+		value, err := exe.executeTemplate(ctx, task, tmpl, hookArgFuncs(secret), vars)
+		if err != nil {
+			return nil, "", fmt.Errorf("hook arg %s: template error: %v", name, err)
+		}
+		job.Hook.Args[name] = value
+	}
+
+	reportErr, reportChange, end := exe.tracker.Start(ctx, "hook_enqueue", task.ID, "hook", task.Hook.Type)
+	defer end()
+	id, err := exe.hooks.Enqueue(ctx, job)
+	if err != nil {
+		reportErr(err)
+		return nil, "", classify(ErrorTransport, fmt.Errorf("failed to queue hook %s: %w", task.Hook.Type, err))
+	}
+	reportChange(id, job.Hook.Type)
+	output := map[string]any{
+		"hookId": id,
+		"hook":   task.Hook.Type,
+		"status": HookPending,
+	}
+	return output, id, nil
+}
+
+// runningChainID returns the ID of the chain whose steps run with ctx.
+func runningChainID(ctx context.Context) string {
+	if scope, ok := chainScopeFromContext(ctx); ok {
+		return scope.chain.ID
+	}
+	return ""
+}
+
+func secretNonce() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate secret placeholder: %w", err)
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
+// RunHookJob runs the hook call of job with hooks, resolving its secrets with secrets first.
+// Resolved secrets are masked in the returned error.
+func RunHookJob(ctx context.Context, hooks HookRepo, secrets SecretResolver, job *HookJob) (any, error) {
+	call := job.Hook
+	mask := &secretMask{}
+	if len(job.Secrets) > 0 {
+		if secrets == nil {
+			return nil, fmt.Errorf("secrets are not available in this environment")
+		}
+		call.Args = make(map[string]string, len(job.Hook.Args))
+		for name, value := range job.Hook.Args {
+			for placeholder, secretName := range job.Secrets {
+				if !strings.Contains(value, placeholder) {
+					continue
+				}
+				secret, err := secrets.GetSecret(ctx, secretName)
+				if err != nil {
+					return nil, fmt.Errorf("failed to resolve secret %q: %w", secretName, err)
+				}
+				mask.add(secret)
+				value = strings.ReplaceAll(value, placeholder, secret)
+			}
+			call.Args[name] = value
+		}
+	}
+	status, output, err := hooks.Exec(ctx, &call)
+	if err != nil {
+		return nil, classify(ErrorTransport, mask.maskValue(err).(error))
+	}
+	if status != StatusSuccess {
+		return nil, classify(ErrorTransport, fmt.Errorf("hook execution failed"))
+	}
+	return output, nil
+}
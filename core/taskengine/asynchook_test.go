@@ -0,0 +1,88 @@
+package taskengine_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/contenox/contenox/core/llmrepo"
+	"github.com/contenox/contenox/core/modelprovider"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHookQueue struct {
+	jobs []*taskengine.HookJob
+}
+
+func (q *recordingHookQueue) Enqueue(_ context.Context, job *taskengine.HookJob) (string, error) {
+	q.jobs = append(q.jobs, job)
+	return fmt.Sprintf("hook-%d", len(q.jobs)), nil
+}
+
+func TestSimpleEnv_NonBlockingHook(t *testing.T) {
+	hooks := taskengine.NewMockHookRegistry()
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: &modelprovider.MockProvider{}}, hooks)
+	require.NoError(t, err)
+	queue := &recordingHookQueue{}
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, exec,
+		taskengine.WithHookQueue(queue),
+		taskengine.WithSecrets(mapSecretResolver{"crm_token": "s3cr3t"}),
+	)
+	require.NoError(t, err)
+
+	chain := secretHookChain()
+	chain.Tasks[0].Hook.Async = true
+	ctx := taskengine.WithExecutionID(context.Background(), "exec-1")
+	output, err := env.ExecEnv(ctx, chain, "T-42")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"hookId": "hook-1", "hook": "mock", "status": taskengine.HookPending}, output)
+	require.Empty(t, hooks.Calls)
+
+	require.Len(t, queue.jobs, 1)
+	job := queue.jobs[0]
+	require.Equal(t, "exec-1", job.ExecutionID)
+	require.Equal(t, "crm", job.ChainID)
+	require.Equal(t, "sync", job.TaskID)
+	require.Equal(t, "T-42", job.Hook.Args["ticket"])
+	require.NotContains(t, job.Hook.Args["headers"], "s3cr3t")
+	require.Len(t, job.Secrets, 1)
+
+	hooks.ResponseMap["mock"] = "synced"
+	result, err := taskengine.RunHookJob(context.Background(), hooks, mapSecretResolver{"crm_token": "s3cr3t"}, job)
+	require.NoError(t, err)
+	require.Equal(t, "synced", result)
+	require.Len(t, hooks.Calls, 1)
+	require.Equal(t, map[string]string{
+		"headers": `{"Authorization": "Bearer s3cr3t"}`,
+		"ticket":  "T-42",
+	}, hooks.Calls[0].Args)
+}
+
+func TestSimpleEnv_NonBlockingHookWithoutQueue(t *testing.T) {
+	hooks := taskengine.NewMockHookRegistry()
+	hooks.ResponseMap["mock"] = "synced"
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: &modelprovider.MockProvider{}}, hooks)
+	require.NoError(t, err)
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, exec,
+		taskengine.WithSecrets(mapSecretResolver{"crm_token": "s3cr3t"}),
+	)
+	require.NoError(t, err)
+
+	chain := secretHookChain()
+	chain.Tasks[0].Hook.Async = true
+	output, err := env.ExecEnv(context.Background(), chain, "T-42")
+	require.NoError(t, err)
+	require.Equal(t, "synced", output)
+	require.Len(t, hooks.Calls, 1)
+}
+
+func TestHookCall_BlockingByDefault(t *testing.T) {
+	var call taskengine.HookCall
+	require.NoError(t, json.Unmarshal([]byte(`{"name": "webhook"}`), &call))
+	require.False(t, call.Async)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"name": "webhook", "async": true}`), &call))
+	require.True(t, call.Async)
+}
@@ -0,0 +1,163 @@
+package taskengine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/contenox/contenox/core/llmresolver"
+	"github.com/contenox/contenox/core/modelprovider"
+	"github.com/contenox/contenox/core/serverops"
+)
+
+// ResponseStore persists cached prompt responses.
+// Get returns ErrCacheMiss, or any error wrapping it, for unknown keys.
+// Set stores value for ttl, stores that cannot expire single entries may keep them longer,
+// responses older than their ttl are not reused anyway.
+type ResponseStore interface {
+	Get(ctx context.Context, key []byte) ([]byte, error)
+	Set(ctx context.Context, key []byte, value []byte, ttl time.Duration) error
+}
+
+// ErrCacheMiss is returned by a ResponseStore for keys it does not hold.
+var ErrCacheMiss = errors.New("cache miss")
+
+// WithResponseCache enables the response cache for tasks and chains with a CacheConfig.
+func WithResponseCache(store ResponseStore) ExecOption {
+	return func(exe *SimpleExec) {
+		exe.cache = store
+	}
+}
+
+type cacheBypassKey struct{}
+
+// WithCacheBypass returns a context for executions that never reuse cached responses.
+// Fresh responses are still cached for later executions.
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+type chainCacheKey struct{}
+
+// chainCache is what SimpleEnv passes to the executor about the running chain.
+type chainCache struct {
+	config  *CacheConfig
+	tracker serverops.ActivityTracker
+}
+
+// withChainCache returns a context carrying the cache of chain and the tracker cache lookups are reported to.
+func withChainCache(ctx context.Context, chain *ChainDefinition, tracker serverops.ActivityTracker) context.Context {
+	return context.WithValue(ctx, chainCacheKey{}, &chainCache{config: chain.Cache, tracker: tracker})
+}
+
+// cacheEntry is the stored form of a cached response.
+type cacheEntry struct {
+	Response  string    `json:"response"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// cacheTTL returns how long responses of task are cached, 0 if they are not.
+func cacheTTL(ctx context.Context, task *ChainTask) (time.Duration, error) {
+	config := (*CacheConfig)(nil)
+	if scope, ok := ctx.Value(chainCacheKey{}).(*chainCache); ok {
+		config = scope.config
+	}
+	if task != nil && task.Cache != nil {
+		config = task.Cache
+	}
+	if config == nil || config.TTL == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(config.TTL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cache ttl: %v", err)
+	}
+	return ttl, nil
+}
+
+func cacheTracker(ctx context.Context) serverops.ActivityTracker {
+	if scope, ok := ctx.Value(chainCacheKey{}).(*chainCache); ok && scope.tracker != nil {
+		return scope.tracker
+	}
+	return serverops.NoopTracker{}
+}
+
+// responseCacheKey identifies a prompt sent to a model with generation options.
+func responseCacheKey(model string, prompt string, generation *GenerationOptions) ([]byte, error) {
+	encoded, err := json.Marshal(struct {
+		Model      string             `json:"model"`
+		Prompt     string             `json:"prompt"`
+		Generation *GenerationOptions `json:"generation,omitempty"`
+	}{model, prompt, generation})
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(encoded)
+	return []byte("taskengine/responses/" + hex.EncodeToString(sum[:])), nil
+}
+
+// selectedModel wraps policy to remember the name of the model it selected.
+func selectedModel(policy llmresolver.Policy, model *string) llmresolver.Policy {
+	return func(candidates []modelprovider.Provider) (modelprovider.Provider, string, error) {
+		provider, backend, err := policy(candidates)
+		if err == nil && provider != nil {
+			*model = provider.ModelName()
+		}
+		return provider, backend, err
+	}
+}
+
+// cachedPrompt answers prompt from the cache, or sends it with execute and caches the response for ttl.
+// The lookup is tracked as a "response_cache" operation reporting a hit, miss or bypass.
+func (exe *SimpleExec) cachedPrompt(ctx context.Context, task *ChainTask, model string, prompt string, ttl time.Duration, execute func() (string, error)) (string, error) {
+	var generation *GenerationOptions
+	subject := ""
+	if task != nil {
+		generation = task.Generation
+		subject = task.ID
+	}
+	key, err := responseCacheKey(model, prompt, generation)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute cache key: %w", err)
+	}
+	reportErr, reportChange, end := cacheTracker(ctx).Start(ctx, "response_cache", subject, "model", model)
+	defer end()
+
+	outcome := "bypass"
+	if !cacheBypassed(ctx) {
+		outcome = "miss"
+		value, err := exe.cache.Get(ctx, key)
+		if err != nil && !errors.Is(err, ErrCacheMiss) {
+			// A failing cache must not fail the task.
+			reportErr(fmt.Errorf("cache lookup failed: %w", err))
+		}
+		var entry cacheEntry
+		if err == nil && json.Unmarshal(value, &entry) == nil && time.Now().UTC().Before(entry.ExpiresAt) {
+			reportChange(subject, "hit")
+			return entry.Response, nil
+		}
+	}
+	reportChange(subject, outcome)
+
+	response, err := execute()
+	if err != nil {
+		return "", err
+	}
+	value, err := json.Marshal(cacheEntry{Response: response, ExpiresAt: time.Now().UTC().Add(ttl)})
+	if err == nil {
+		err = exe.cache.Set(ctx, key, value, ttl)
+	}
+	if err != nil {
+		reportErr(fmt.Errorf("cache update failed: %w", err))
+	}
+	return response, nil
+}
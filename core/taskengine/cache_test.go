@@ -0,0 +1,139 @@
+package taskengine_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/contenox/contenox/core/llmrepo"
+	"github.com/contenox/contenox/core/modelprovider"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type mapResponseStore struct {
+	mu      sync.Mutex
+	values  map[string][]byte
+	expires map[string]time.Time
+}
+
+func (s *mapResponseStore) Get(_ context.Context, key []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[string(key)]
+	if !ok || !time.Now().Before(s.expires[string(key)]) {
+		return nil, taskengine.ErrCacheMiss
+	}
+	return value, nil
+}
+
+func (s *mapResponseStore) Set(_ context.Context, key []byte, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[string(key)] = value
+	s.expires[string(key)] = time.Now().Add(ttl)
+	return nil
+}
+
+func newMapResponseStore() *mapResponseStore {
+	return &mapResponseStore{values: map[string][]byte{}, expires: map[string]time.Time{}}
+}
+
+func cacheOutcomes(tracker *recordingTracker) []any {
+	var outcomes []any
+	for _, op := range tracker.operations {
+		if op.operation == "response_cache" {
+			outcomes = append(outcomes, op.change)
+		}
+	}
+	return outcomes
+}
+
+func TestSimpleEnv_ResponseCache(t *testing.T) {
+	provider := &modelprovider.MockProvider{
+		Name:            "tasks-model",
+		CanPromptFlag:   true,
+		ContextLength:   2048,
+		ID:              uuid.NewString(),
+		Backends:        []string{"backend"},
+		PromptResponses: []string{"first", "second", "third"},
+	}
+	store := newMapResponseStore()
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: provider}, taskengine.NewMockHookRegistry(),
+		taskengine.WithResponseCache(store),
+	)
+	require.NoError(t, err)
+	tracker := &recordingTracker{}
+	env, err := taskengine.NewEnv(context.Background(), tracker, exec)
+	require.NoError(t, err)
+
+	chain := templateChain("{{ .input }}")
+	chain.Cache = &taskengine.CacheConfig{TTL: "1h"}
+
+	output, err := env.ExecEnv(context.Background(), chain, "evaluate")
+	require.NoError(t, err)
+	require.Equal(t, "first", output)
+
+	output, err = env.ExecEnv(context.Background(), chain, "evaluate")
+	require.NoError(t, err)
+	require.Equal(t, "first", output)
+	require.Len(t, provider.Prompts, 1)
+
+	output, err = env.ExecEnv(taskengine.WithCacheBypass(context.Background()), chain, "evaluate")
+	require.NoError(t, err)
+	require.Equal(t, "second", output)
+
+	// The bypassed execution refreshed the cached response.
+	output, err = env.ExecEnv(context.Background(), chain, "evaluate")
+	require.NoError(t, err)
+	require.Equal(t, "second", output)
+
+	// Without a cache on the chain or task, the prompt is always sent.
+	chain.Cache = nil
+	output, err = env.ExecEnv(context.Background(), chain, "evaluate")
+	require.NoError(t, err)
+	require.Equal(t, "third", output)
+
+	chain.Tasks[0].Cache = &taskengine.CacheConfig{TTL: "1h"}
+	output, err = env.ExecEnv(context.Background(), chain, "evaluate")
+	require.NoError(t, err)
+	require.Equal(t, "second", output)
+
+	require.Len(t, provider.Prompts, 3)
+	require.Equal(t, []any{"miss", "hit", "bypass", "hit", "hit"}, cacheOutcomes(tracker))
+}
+
+func TestSimpleEnv_ResponseCacheExpiry(t *testing.T) {
+	provider := &modelprovider.MockProvider{
+		Name:            "tasks-model",
+		CanPromptFlag:   true,
+		ContextLength:   2048,
+		ID:              uuid.NewString(),
+		Backends:        []string{"backend"},
+		PromptResponses: []string{"first", "second"},
+	}
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: provider}, taskengine.NewMockHookRegistry(),
+		taskengine.WithResponseCache(newMapResponseStore()),
+	)
+	require.NoError(t, err)
+	tracker := &recordingTracker{}
+	env, err := taskengine.NewEnv(context.Background(), tracker, exec)
+	require.NoError(t, err)
+
+	chain := templateChain("{{ .input }}")
+	chain.Cache = &taskengine.CacheConfig{TTL: "10ms"}
+
+	output, err := env.ExecEnv(context.Background(), chain, "evaluate")
+	require.NoError(t, err)
+	require.Equal(t, "first", output)
+
+	time.Sleep(20 * time.Millisecond)
+
+	output, err = env.ExecEnv(context.Background(), chain, "evaluate")
+	require.NoError(t, err)
+	require.Equal(t, "second", output)
+	require.Len(t, provider.Prompts, 2)
+	require.Equal(t, []any{"miss", "miss"}, cacheOutcomes(tracker))
+}
@@ -0,0 +1,92 @@
+package taskengine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/contenox/contenox/core/llmresolver"
+	"github.com/contenox/contenox/core/serverops"
+)
+
+// ChatExecutor is implemented by TaskExecutors that support Chat tasks.
+type ChatExecutor interface {
+	// ChatExec sends messages to a chat model selected for task and returns its reply.
+	ChatExec(ctx context.Context, resolver llmresolver.Policy, task *ChainTask, messages []serverops.Message) (serverops.Message, error)
+}
+
+var chatRoles = map[string]struct{}{
+	"system":    {},
+	"user":      {},
+	"assistant": {},
+}
+
+// chat renders the messages of a Chat task and sends them to the executor.
+//
+// If conversation is not nil and the task takes part in the chain conversation,
+// the conversation is sent before the prompt and the prompt and reply are appended to it.
+// The output is the content of the reply.
+func (exe SimpleEnv) chat(ctx context.Context, resolver llmresolver.Policy, task *ChainTask, renderedPrompt string, vars map[string]any, conversation *[]serverops.Message) (any, string, error) {
+	chatExec, ok := exe.exec.(ChatExecutor)
+	if !ok {
+		return nil, "", fmt.Errorf("chat tasks are not supported by this executor")
+	}
+	var messages []serverops.Message
+	useConversation := false
+	if task.Chat != nil {
+		for i, msg := range task.Chat.Messages {
+			content, err := exe.renderTemplate(ctx, task, msg.Content, vars)
+			if err != nil {
+				return nil, "", fmt.Errorf("chat message %d: template error: %v", i, err)
+			}
+			messages = append(messages, serverops.Message{Role: msg.Role, Content: content})
+		}
+		useConversation = task.Chat.Conversation && conversation != nil
+	}
+	if useConversation {
+		messages = append(messages, *conversation...)
+	}
+	prompt := serverops.Message{Role: "user", Content: renderedPrompt}
+	if renderedPrompt != "" {
+		messages = append(messages, prompt)
+	}
+	if len(messages) == 0 {
+		return nil, "", fmt.Errorf("chat task has no messages")
+	}
+	if err := chargeMessages(ctx, task, messages); err != nil {
+		return nil, "", err
+	}
+
+	reply, err := chatExec.ChatExec(ctx, resolver, task, messages)
+	if err != nil {
+		return nil, "", err
+	}
+	if useConversation {
+		if renderedPrompt != "" {
+			*conversation = append(*conversation, prompt)
+		}
+		*conversation = append(*conversation, serverops.Message{Role: "assistant", Content: reply.Content})
+	}
+	return reply.Content, reply.Content, nil
+}
+
+// chargeMessages counts the contents of messages toward the token usage of task, see chargeTokens.
+func chargeMessages(ctx context.Context, task *ChainTask, messages []serverops.Message) error {
+	contents := make([]string, len(messages))
+	for i, msg := range messages {
+		contents[i] = msg.Content
+	}
+	return chargeTokens(ctx, task, contents...)
+}
+
+// conversationVars converts the chain conversation into template variables,
+// using the same shape it has after being restored from a checkpoint.
+func conversationVars(conversation []serverops.Message) []any {
+	messages := make([]any, len(conversation))
+	for i, msg := range conversation {
+		messages[i] = map[string]any{
+			"role":    msg.Role,
+			"content": msg.Content,
+		}
+	}
+	return messages
+}
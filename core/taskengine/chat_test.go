@@ -0,0 +1,131 @@
+package taskengine_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/contenox/contenox/core/llmrepo"
+	"github.com/contenox/contenox/core/llmresolver"
+	"github.com/contenox/contenox/core/modelprovider"
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// chatExecutor answers chats with the number of messages it received.
+type chatExecutor struct {
+	recordingExecutor
+	chats [][]serverops.Message
+}
+
+func (c *chatExecutor) ChatExec(_ context.Context, _ llmresolver.Policy, task *taskengine.ChainTask, messages []serverops.Message) (serverops.Message, error) {
+	c.executed = append(c.executed, task.ID)
+	c.chats = append(c.chats, messages)
+	return serverops.Message{Role: "assistant", Content: task.ID + " reply"}, nil
+}
+
+func chatTask(id, prompt, next string) taskengine.ChainTask {
+	return taskengine.ChainTask{
+		ID:             id,
+		Type:           taskengine.Chat,
+		PromptTemplate: prompt,
+		Chat: &taskengine.ChatConfig{
+			Messages: []taskengine.ChatMessage{
+				{Role: "system", Content: "You answer in {{ .lang }}."},
+				{Role: "user", Content: "hi"},
+				{Role: "assistant", Content: "hallo"},
+			},
+			Conversation: true,
+		},
+		Transition: taskengine.Transition{
+			Next: []taskengine.ConditionalTransition{{Value: "_default", ID: next}},
+		},
+	}
+}
+
+func TestSimpleEnv_ChatConversation(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID: "support",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "lang",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "german",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "greet"}},
+				},
+			},
+			chatTask("greet", "{{ .input }}", "follow_up"),
+			chatTask("follow_up", "and then?", "summarise"),
+			{
+				ID:             "summarise",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "{{ range .conversation }}{{ .role }}: {{ .content }}\n{{ end }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+	exec := &chatExecutor{}
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, exec)
+	require.NoError(t, err)
+
+	output, err := env.ExecEnv(context.Background(), chain, "hello")
+	require.NoError(t, err)
+	require.Equal(t, "user: hello\nassistant: greet reply\nuser: and then?\nassistant: follow_up reply\n", output)
+	require.Equal(t, []string{"lang", "greet", "follow_up", "summarise"}, exec.executed)
+
+	require.Len(t, exec.chats, 2)
+	require.Equal(t, []serverops.Message{
+		{Role: "system", Content: "You answer in german."},
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", Content: "hallo"},
+		{Role: "user", Content: "hello"},
+	}, exec.chats[0])
+	require.Equal(t, []serverops.Message{
+		{Role: "system", Content: "You answer in german."},
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", Content: "hallo"},
+		{Role: "user", Content: "hello"},
+		{Role: "assistant", Content: "greet reply"},
+		{Role: "user", Content: "and then?"},
+	}, exec.chats[1])
+}
+
+func TestSimpleEnv_ChatRequiresChatExecutor(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID:    "support",
+		Tasks: []taskengine.ChainTask{chatTask("greet", "{{ .input }}", "end")},
+	}
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, &recordingExecutor{})
+	require.NoError(t, err)
+
+	_, err = env.ExecEnv(context.Background(), chain, "hello")
+	require.ErrorContains(t, err, "chat tasks are not supported by this executor")
+}
+
+func TestSimpleExec_ChatExec(t *testing.T) {
+	mockProvider := &modelprovider.MockProvider{
+		Name:          "chat-model",
+		CanChatFlag:   true,
+		ContextLength: 2048,
+		ID:            uuid.NewString(),
+		Backends:      []string{"backend1"},
+		ChatResponses: []string{"  hallo  "},
+	}
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: mockProvider}, taskengine.NewMockHookRegistry())
+	require.NoError(t, err)
+	chatExec, ok := exec.(taskengine.ChatExecutor)
+	require.True(t, ok)
+
+	messages := []serverops.Message{
+		{Role: "system", Content: "You answer in german."},
+		{Role: "user", Content: "hello"},
+	}
+	reply, err := chatExec.ChatExec(context.Background(), llmresolver.Randomly, &taskengine.ChainTask{ID: "greet", Type: taskengine.Chat}, messages)
+	require.NoError(t, err)
+	require.Equal(t, serverops.Message{Role: "assistant", Content: "hallo"}, reply)
+	require.Equal(t, [][]serverops.Message{messages}, mockProvider.Chats)
+}
@@ -0,0 +1,134 @@
+package taskengine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/contenox/contenox/core/serverops"
+)
+
+// DryRunResponses are the canned model responses of a dry run by task ID.
+//
+// Every time a task is executed it consumes the next response of its sequence, the last response
+// is repeated once the others are used up. Hook tasks are answered with their responses instead of
+// calling the hook, parsed as JSON if possible, Approval tasks take them as the ApprovalAction and
+// tool calls of Agent tasks are answered with the responses of the tool name.
+// In JSON, a single response may be given as a string instead of a list.
+type DryRunResponses map[string][]string
+
+func (r *DryRunResponses) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	responses := make(DryRunResponses, len(raw))
+	for key, value := range raw {
+		var single string
+		if err := json.Unmarshal(value, &single); err == nil {
+			responses[key] = []string{single}
+			continue
+		}
+		var sequence []string
+		if err := json.Unmarshal(value, &sequence); err != nil {
+			return fmt.Errorf("responses of %q must be a string or a list of strings", key)
+		}
+		responses[key] = sequence
+	}
+	*r = responses
+	return nil
+}
+
+type dryRunKey struct{}
+
+// WithDryRun returns a context for executions that answer every task from responses
+// instead of calling models or hooks. Dry runs are neither checkpointed nor reported to
+// the tracker or the Printer of the environment, trackers added with WithTracker and
+// observers added with WithPrintObserver still observe them.
+// Secrets referenced by hook args are not resolved.
+func WithDryRun(ctx context.Context, responses DryRunResponses) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, &dryRunScript{responses: responses, used: map[string]int{}})
+}
+
+// dryRunScript hands out the responses of a dry run.
+type dryRunScript struct {
+	mu        sync.Mutex
+	responses DryRunResponses
+	used      map[string]int
+}
+
+func (s *dryRunScript) next(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sequence := s.responses[key]
+	if len(sequence) == 0 {
+		return "", fmt.Errorf("no dry-run response for %q", key)
+	}
+	i := min(s.used[key], len(sequence)-1)
+	s.used[key]++
+	return sequence[i], nil
+}
+
+// value returns the next response of key, decoded if it is JSON.
+func (s *dryRunScript) value(key string) (any, error) {
+	response, err := s.next(key)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	if err := json.Unmarshal([]byte(response), &decoded); err == nil {
+		return decoded, nil
+	}
+	return response, nil
+}
+
+// dryRun returns the environment to run a dry run of the script in.
+// The executor is a copy of the SimpleExec of the environment answering from the script.
+func (exe SimpleEnv) dryRun(script *dryRunScript) (SimpleEnv, error) {
+	simple, ok := exe.exec.(*SimpleExec)
+	if !ok {
+		return exe, fmt.Errorf("dry runs are not supported by this executor")
+	}
+	dry := *simple
+	dry.script = script
+	dry.cache = nil
+	exe.exec = &dry
+	exe.script = script
+	exe.tracker = serverops.NoopTracker{}
+	exe.checkpointer = nil
+	exe.approvals = nil
+	exe.printer = nil
+	exe.hooks = nil
+	exe.secrets = dryRunSecrets{}
+	return exe, nil
+}
+
+// dryRunSecrets stands in for every secret, so dry runs never read stored secrets.
+type dryRunSecrets struct{}
+
+func (dryRunSecrets) GetSecret(_ context.Context, _ string) (string, error) {
+	return maskedSecret, nil
+}
+
+// ExecutionStep is a task completed by an execution.
+type ExecutionStep struct {
+	TaskID string `json:"taskId"`
+	Output any    `json:"output"`
+
+	// Vars are the template variables after the task completed.
+	Vars map[string]any `json:"vars"`
+}
+
+type stepObserverKey struct{}
+
+// WithStepObserver returns a context whose executions call observe after every completed task.
+func WithStepObserver(ctx context.Context, observe func(step ExecutionStep)) context.Context {
+	return context.WithValue(ctx, stepObserverKey{}, observe)
+}
+
+func observeStep(ctx context.Context, step ExecutionStep) {
+	if observe, ok := ctx.Value(stepObserverKey{}).(func(step ExecutionStep)); ok && observe != nil {
+		observe(step)
+	}
+}
@@ -0,0 +1,107 @@
+package taskengine_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/contenox/contenox/core/llmrepo"
+	"github.com/contenox/contenox/core/modelprovider"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func dryRunChain() *taskengine.ChainDefinition {
+	next := func(id string) taskengine.Transition {
+		return taskengine.Transition{Next: []taskengine.ConditionalTransition{{Value: "_default", ID: id}}}
+	}
+	return &taskengine.ChainDefinition{
+		ID: "refunds",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:               "classify",
+				Type:             taskengine.PromptToCondition,
+				ConditionMapping: map[string]bool{"refund": true, "other": false},
+				PromptTemplate:   "Is this a refund request? {{ .input }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{
+						{Operator: "equals", Value: "true", ID: "lookup"},
+						{Value: "_default", ID: "end"},
+					},
+				},
+			},
+			{
+				ID:         "lookup",
+				Type:       taskengine.Hook,
+				Hook:       &taskengine.HookCall{Type: "crm", Args: map[string]string{"token": `{{ secret "crm_token" }}`}},
+				Transition: next("review"),
+			},
+			{
+				ID:   "review",
+				Type: taskengine.Approval,
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{
+						{Operator: "equals", Value: "approve", ID: "end"},
+						{Value: "_default", ID: "classify"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestSimpleEnv_DryRun(t *testing.T) {
+	provider := &modelprovider.MockProvider{
+		Name:          "tasks-model",
+		CanPromptFlag: true,
+		ContextLength: 2048,
+		ID:            uuid.NewString(),
+		Backends:      []string{"backend"},
+	}
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: provider}, taskengine.NewMockHookRegistry())
+	require.NoError(t, err)
+	tracker := &recordingTracker{}
+	env, err := taskengine.NewEnv(context.Background(), tracker, exec)
+	require.NoError(t, err)
+
+	var responses taskengine.DryRunResponses
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"classify": ["refund", "other"],
+		"lookup": "{\"order\": 42}",
+		"review": "reject"
+	}`), &responses))
+
+	var steps []taskengine.ExecutionStep
+	ctx := taskengine.WithDryRun(context.Background(), responses)
+	ctx = taskengine.WithStepObserver(ctx, func(step taskengine.ExecutionStep) {
+		steps = append(steps, step)
+	})
+	output, err := env.ExecEnv(ctx, dryRunChain(), "I want my money back")
+	require.NoError(t, err)
+	require.Equal(t, false, output)
+
+	var path []string
+	for _, step := range steps {
+		path = append(path, step.TaskID)
+	}
+	require.Equal(t, []string{"classify", "lookup", "review", "classify"}, path)
+	require.Equal(t, map[string]any{"order": float64(42)}, steps[1].Vars["lookup"])
+	require.Equal(t, true, steps[0].Vars["classify"])
+	require.Equal(t, false, steps[3].Vars["classify"])
+
+	require.Empty(t, provider.Prompts)
+	require.Empty(t, tracker.operations, "dry runs are not reported to the environment tracker")
+}
+
+func TestSimpleEnv_DryRunMissingResponse(t *testing.T) {
+	provider := &modelprovider.MockProvider{Name: "tasks-model", CanPromptFlag: true, ID: uuid.NewString(), Backends: []string{"backend"}}
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: provider}, taskengine.NewMockHookRegistry())
+	require.NoError(t, err)
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, exec)
+	require.NoError(t, err)
+
+	ctx := taskengine.WithDryRun(context.Background(), taskengine.DryRunResponses{"classify": {"refund"}})
+	_, err = env.ExecEnv(ctx, dryRunChain(), "refund please")
+	require.ErrorContains(t, err, `no dry-run response for "lookup"`)
+}
@@ -0,0 +1,159 @@
+package taskengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/contenox/contenox/core/serverops"
+)
+
+// ExecutionStatus describes the lifecycle state of a chain execution.
+type ExecutionStatus string
+
+const (
+	// ExecutionRunning means the execution is in progress or was interrupted.
+	ExecutionRunning ExecutionStatus = "running"
+
+	// ExecutionCompleted means the chain reached its end.
+	ExecutionCompleted ExecutionStatus = "completed"
+
+	// ExecutionFailed means the chain stopped with an error.
+	ExecutionFailed ExecutionStatus = "failed"
+
+	// ExecutionWaiting means the execution is paused until an approval is decided.
+	ExecutionWaiting ExecutionStatus = "waiting"
+)
+
+// ExecutionState is the durable progress of a single chain execution.
+// It is checkpointed after every step, so an interrupted execution can be
+// resumed from the last completed task without re-running it.
+type ExecutionState struct {
+	// ID uniquely identifies the execution.
+	ID string `json:"id"`
+
+	// ChainID is the ID of the executed chain.
+	ChainID string `json:"chainId"`
+
+	// Status is the current lifecycle state.
+	Status ExecutionStatus `json:"status"`
+
+	// CurrentTask is the task that runs next, empty once the execution ended.
+	CurrentTask string `json:"currentTask"`
+
+	// Vars holds the template variables, including the input and all task outputs so far.
+	Vars map[string]any `json:"vars"`
+
+	// Attempts counts how often each task was attempted, including retries.
+	Attempts map[string]int `json:"attempts"`
+
+	// Steps is the number of tasks completed so far.
+	Steps int `json:"steps"`
+
+	// Visits counts how often the chain entered each task.
+	Visits map[string]int `json:"visits"`
+
+	// Path lists the tasks entered so far, in order, including the current one.
+	// Tasks entered by sub-chains are listed as "chainID/taskID" after the calling task.
+	Path []string `json:"path"`
+
+	// TokenUsage counts the tokens sent to models by each task, including retried prompts,
+	// chat messages and summaries, see ChainDefinition.MaxTokenSize. Tasks of sub-chains are
+	// counted as "chainID/taskID".
+	TokenUsage map[string]int `json:"tokenUsage,omitempty"`
+
+	// TotalTokens is the sum of TokenUsage.
+	TotalTokens int `json:"totalTokens,omitempty"`
+
+	// Log lists the messages printed by the tasks so far, in order.
+	Log []PrintEntry `json:"log,omitempty"`
+
+	// Conversation holds the messages exchanged by Chat tasks with ChatConfig.Conversation set.
+	Conversation []serverops.Message `json:"conversation,omitempty"`
+
+	// Decision answers the approval the execution is waiting for.
+	// It is set before resuming and consumed by the Approval task.
+	Decision *ApprovalDecision `json:"decision,omitempty"`
+
+	// Output is the final output once the execution completed.
+	Output any `json:"output,omitempty"`
+
+	// Error is the failure reason once the execution failed.
+	Error string `json:"error,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// stepBudget is what is left of the callers' step budget if the state belongs to a sub-chain.
+	stepBudget *int
+}
+
+// Checkpointer persists execution progress.
+type Checkpointer interface {
+	// Begin records a new execution of chain.
+	Begin(ctx context.Context, chain *ChainDefinition, state *ExecutionState) error
+
+	// Checkpoint stores the latest state of an execution started with Begin.
+	Checkpoint(ctx context.Context, state *ExecutionState) error
+}
+
+// EnvOption configures a SimpleEnv.
+type EnvOption func(*SimpleEnv)
+
+// WithCheckpointer makes the environment persist its progress after every step.
+func WithCheckpointer(checkpointer Checkpointer) EnvOption {
+	return func(env *SimpleEnv) {
+		env.checkpointer = checkpointer
+	}
+}
+
+type executionIDKey struct{}
+
+// WithExecutionID returns a context carrying the ID to use for the next execution.
+func WithExecutionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, executionIDKey{}, id)
+}
+
+// ExecutionIDFromContext returns the execution ID stored in ctx, if any.
+func ExecutionIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(executionIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+type trackerKey struct{}
+
+// WithTracker returns a context carrying an additional ActivityTracker.
+// SimpleEnv reports every operation of executions started with this context to it,
+// in addition to the tracker the environment was created with.
+func WithTracker(ctx context.Context, tracker serverops.ActivityTracker) context.Context {
+	return context.WithValue(ctx, trackerKey{}, tracker)
+}
+
+func trackerFromContext(ctx context.Context) (serverops.ActivityTracker, bool) {
+	tracker, ok := ctx.Value(trackerKey{}).(serverops.ActivityTracker)
+	return tracker, ok && tracker != nil
+}
+
+// multiTracker fans every tracked operation out to several trackers.
+type multiTracker []serverops.ActivityTracker
+
+func (m multiTracker) Start(ctx context.Context, operation string, subject string, kvArgs ...any) (func(error), func(string, any), func()) {
+	reportErrs := make([]func(error), len(m))
+	reportChanges := make([]func(string, any), len(m))
+	ends := make([]func(), len(m))
+	for i, tracker := range m {
+		reportErrs[i], reportChanges[i], ends[i] = tracker.Start(ctx, operation, subject, kvArgs...)
+	}
+	return func(err error) {
+			for _, fn := range reportErrs {
+				fn(err)
+			}
+		}, func(id string, data any) {
+			for _, fn := range reportChanges {
+				fn(id, data)
+			}
+		}, func() {
+			for _, fn := range ends {
+				fn()
+			}
+		}
+}
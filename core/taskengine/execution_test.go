@@ -0,0 +1,98 @@
+package taskengine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/contenox/contenox/core/llmresolver"
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryCheckpointer struct {
+	chain       *taskengine.ChainDefinition
+	checkpoints []taskengine.ExecutionState
+}
+
+func (m *memoryCheckpointer) Begin(_ context.Context, chain *taskengine.ChainDefinition, state *taskengine.ExecutionState) error {
+	m.chain = chain
+	return nil
+}
+
+func (m *memoryCheckpointer) Checkpoint(_ context.Context, state *taskengine.ExecutionState) error {
+	m.checkpoints = append(m.checkpoints, *state)
+	return nil
+}
+
+// recordingExecutor records the IDs of executed tasks and fails the ones listed in fail.
+type recordingExecutor struct {
+	fail     map[string]bool
+	executed []string
+}
+
+func (r *recordingExecutor) TaskExec(_ context.Context, _ llmresolver.Policy, task *taskengine.ChainTask, prompt string) (any, string, error) {
+	r.executed = append(r.executed, task.ID)
+	if r.fail[task.ID] {
+		return nil, "", errors.New("interrupted")
+	}
+	return prompt, prompt, nil
+}
+
+func TestSimpleEnv_CheckpointAndResume(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID: "durable",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "notify",
+				Type:           taskengine.Hook,
+				Hook:           &taskengine.HookCall{Type: "mock"},
+				PromptTemplate: "notified {{ .input }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "summarise"}},
+				},
+			},
+			{
+				ID:             "summarise",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "summary after {{ .notify }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+
+	checkpointer := &memoryCheckpointer{}
+	exec := &recordingExecutor{fail: map[string]bool{"summarise": true}}
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec, taskengine.WithCheckpointer(checkpointer))
+	require.NoError(t, err)
+
+	ctx := taskengine.WithExecutionID(context.Background(), "exec-1")
+	_, err = env.ExecEnv(ctx, chain, "order-42")
+	require.Error(t, err)
+	require.Equal(t, chain, checkpointer.chain)
+
+	last := checkpointer.checkpoints[len(checkpointer.checkpoints)-1]
+	require.Equal(t, "exec-1", last.ID)
+	require.Equal(t, taskengine.ExecutionFailed, last.Status)
+	require.Equal(t, "summarise", last.CurrentTask)
+	require.Equal(t, 1, last.Steps)
+	require.Equal(t, map[string]int{"notify": 1, "summarise": 1}, last.Attempts)
+	require.Equal(t, "notified order-42", last.Vars["notify"])
+
+	// Resuming must not fire the hook again.
+	exec.fail = nil
+	exec.executed = nil
+	result, err := env.ResumeEnv(context.Background(), chain, &last)
+	require.NoError(t, err)
+	require.Equal(t, "summary after notified order-42", result)
+	require.Equal(t, []string{"summarise"}, exec.executed)
+
+	final := checkpointer.checkpoints[len(checkpointer.checkpoints)-1]
+	require.Equal(t, taskengine.ExecutionCompleted, final.Status)
+	require.Empty(t, final.CurrentTask)
+	require.Empty(t, final.Error)
+	require.Equal(t, 2, final.Attempts["summarise"])
+}
@@ -0,0 +1,1254 @@
+package taskengine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Transition expressions (ConditionalTransition.When) are a small, side-effect free language
+// in the spirit of CEL, evaluated over the execution variables:
+//
+//	extract.priority == "high" && score > 0.7
+//	size(tags) > 0 && "urgent" in tags
+//	has(extract.customer) ? extract.customer.tier != "free" : input.contains("enterprise")
+//
+// It supports number, string, bool, null and list literals, member and index access,
+// the operators ! - * / % + < <= > >= == != in && || and ?:, and the functions
+// size, has, contains, startsWith, endsWith, matches, lower, upper, int, double and string.
+// The string functions may also be called as methods (input.contains("x")).
+//
+// Expressions can only read variables, they cannot loop or call into the host,
+// and their length and nesting depth are bounded, so evaluation is linear in their size.
+
+const (
+	maxExpressionLength = 4096
+	maxExpressionDepth  = 64
+
+	// maxCachedExpressions bounds expressionCache, chains are user-defined
+	// so the number of distinct expressions is not.
+	maxCachedExpressions = 1024
+)
+
+type exprType int
+
+const (
+	typeDyn exprType = iota
+	typeBool
+	typeNumber
+	typeString
+	typeList
+	typeMap
+	typeNull
+)
+
+func (t exprType) String() string {
+	switch t {
+	case typeBool:
+		return "bool"
+	case typeNumber:
+		return "number"
+	case typeString:
+		return "string"
+	case typeList:
+		return "list"
+	case typeMap:
+		return "map"
+	case typeNull:
+		return "null"
+	}
+	return "dyn"
+}
+
+// fits reports whether a value of type t can be used where want is expected.
+func (t exprType) fits(want exprType) bool {
+	return t == want || t == typeDyn || want == typeDyn
+}
+
+// expression is a parsed transition expression.
+type expression struct {
+	source string
+	root   exprNode
+	// refs lists the top-level variables the expression reads.
+	refs []string
+}
+
+type exprNode interface {
+	check(types map[string]exprType) (exprType, error)
+	eval(vars map[string]any) (any, error)
+}
+
+// expressionCache holds parsed expressions by source. Parsing does not depend on the chain,
+// only type checking does, so compiled expressions are shared between executions.
+var expressionCache = newLRUCache[string, *expression](maxCachedExpressions)
+
+// compileExpression parses source and type-checks it against the variable types,
+// variables without a known type are dynamic. The result must be a boolean.
+func compileExpression(source string, types map[string]exprType) (*expression, error) {
+	expr, ok := expressionCache.Get(source)
+	if !ok {
+		parsed, err := parseExpression(source)
+		if err != nil {
+			return nil, err
+		}
+		expressionCache.Add(source, parsed)
+		expr = parsed
+	}
+	result, err := expr.root.check(types)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %v", source, err)
+	}
+	if !result.fits(typeBool) {
+		return nil, fmt.Errorf("expression %q must evaluate to bool, not %s", source, result)
+	}
+	return expr, nil
+}
+
+// evalBool evaluates the expression against the execution variables.
+func (e *expression) evalBool(vars map[string]any) (bool, error) {
+	value, err := e.root.eval(vars)
+	if err != nil {
+		return false, fmt.Errorf("expression %q: %v", e.source, err)
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q evaluated to %s instead of bool", e.source, typeOf(value))
+	}
+	return b, nil
+}
+
+// expressionTypes returns the static types of the variables available to transition expressions of chain.
+func expressionTypes(chain *ChainDefinition) map[string]exprType {
+	types := map[string]exprType{
+		"input":           typeString,
+		"previous_output": typeDyn,
+		"loop":            typeMap,
+		"conversation":    typeList,
+	}
+	for _, task := range chain.Tasks {
+		types[task.ID] = outputType(task.Type)
+		if task.Type == Parallel && task.Parallel != nil {
+			for _, branch := range task.Parallel.Branches {
+				types[branch.ID] = outputType(branch.Type)
+			}
+		}
+	}
+	return types
+}
+
+func outputType(taskType TaskType) exprType {
+	switch taskType {
+	case PromptToString, PromptToRange, Chat, Agent:
+		return typeString
+	case PromptToNumber, PromptToScore:
+		return typeNumber
+	case PromptToCondition:
+		return typeBool
+	case Parallel:
+		return typeMap
+	}
+	return typeDyn
+}
+
+// compileTransitions compiles every transition expression of the chain, so broken expressions
+// fail the execution before its first task instead of at the transition.
+func compileTransitions(chain *ChainDefinition) error {
+	var types map[string]exprType
+	for _, task := range chain.Tasks {
+		for _, ct := range task.Transition.Next {
+			if ct.When == "" {
+				continue
+			}
+			if types == nil {
+				types = expressionTypes(chain)
+			}
+			if _, err := compileExpression(ct.When, types); err != nil {
+				return fmt.Errorf("task %s: transition to %s: %v", task.ID, ct.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+func lexExpression(source string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(source) && source[i+1] >= '0' && source[i+1] <= '9':
+			start := i
+			for i < len(source) && (source[i] >= '0' && source[i] <= '9' || source[i] == '.' ||
+				source[i] == 'e' || source[i] == 'E' ||
+				(source[i] == '-' || source[i] == '+') && (source[i-1] == 'e' || source[i-1] == 'E')) {
+				i++
+			}
+			n, err := strconv.ParseFloat(source[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", source[start:i], start)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: source[start:i], num: n, pos: start})
+		case c == '"' || c == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(source) {
+					return nil, fmt.Errorf("unterminated string at %d", start)
+				}
+				if source[i] == c {
+					i++
+					break
+				}
+				if source[i] == '\\' && i+1 < len(source) {
+					switch source[i+1] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					case 'r':
+						sb.WriteByte('\r')
+					case '\\', '"', '\'':
+						sb.WriteByte(source[i+1])
+					default:
+						return nil, fmt.Errorf("invalid escape \\%c at %d", source[i+1], i)
+					}
+					i += 2
+					continue
+				}
+				sb.WriteByte(source[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: start})
+		case isIdentByte(c):
+			start := i
+			for i < len(source) && (isIdentByte(source[i]) || source[i] >= '0' && source[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[start:i], pos: start})
+		default:
+			op := ""
+			if i+1 < len(source) {
+				switch two := source[i : i+2]; two {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					op = two
+				}
+			}
+			if op == "" {
+				if !strings.ContainsRune("!<>+-*/%()[],.?:", rune(c)) {
+					return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+				}
+				op = string(c)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(source)}), nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// Parser
+
+type exprParser struct {
+	tokens []token
+	pos    int
+	depth  int
+	refs   map[string]struct{}
+}
+
+func parseExpression(source string) (*expression, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("expression is empty")
+	}
+	if len(source) > maxExpressionLength {
+		return nil, fmt.Errorf("expression is longer than %d characters", maxExpressionLength)
+	}
+	tokens, err := lexExpression(source)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %v", source, err)
+	}
+	p := &exprParser{tokens: tokens, refs: map[string]struct{}{}}
+	root, err := p.parseConditional()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %q at %d", p.peek().text, p.peek().pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %v", source, err)
+	}
+	expr := &expression{source: source, root: root}
+	for ref := range p.refs {
+		expr.refs = append(expr.refs, ref)
+	}
+	return expr, nil
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) isOp(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokenOp && !(t.kind == tokenIdent && t.text == "in") {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.isOp(op) {
+		t := p.peek()
+		if t.kind == tokenEOF {
+			return fmt.Errorf("expected %q at end of expression", op)
+		}
+		return fmt.Errorf("expected %q at %d, found %q", op, t.pos, t.text)
+	}
+	p.next()
+	return nil
+}
+
+func (p *exprParser) enter() error {
+	p.depth++
+	if p.depth > maxExpressionDepth {
+		return fmt.Errorf("expression is nested deeper than %d levels", maxExpressionDepth)
+	}
+	return nil
+}
+
+func (p *exprParser) parseConditional() (exprNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+	cond, err := p.parseBinary(0)
+	if err != nil || !p.isOp("?") {
+		return cond, err
+	}
+	p.next()
+	then, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	return &conditionalNode{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// binaryLevels lists the binary operators from lowest to highest precedence.
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) parseBinary(level int) (exprNode, error) {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(binaryLevels[level]...) {
+		op := p.next().text
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.isOp("!", "-") {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+		op := p.next().text
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *exprParser) parsePostfix() (exprNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.isOp("."):
+			p.next()
+			name := p.next()
+			if name.kind != tokenIdent {
+				return nil, fmt.Errorf("expected field name at %d", name.pos)
+			}
+			if p.isOp("(") {
+				args, err := p.parseArgs()
+				if err != nil {
+					return nil, err
+				}
+				node, err = newCallNode(name.text, append([]exprNode{node}, args...))
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			node = &memberNode{target: node, field: name.text}
+		case p.isOp("["):
+			p.next()
+			key, err := p.parseConditional()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			node = &indexNode{target: node, key: key}
+		default:
+			return node, nil
+		}
+	}
+}
+
+func (p *exprParser) parseArgs() ([]exprNode, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []exprNode
+	for !p.isOp(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseConditional()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next()
+	return args, nil
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		return &literalNode{value: t.num, typ: typeNumber}, nil
+	case tokenString:
+		return &literalNode{value: t.text, typ: typeString}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			return &literalNode{value: t.text == "true", typ: typeBool}, nil
+		case "null":
+			return &literalNode{value: nil, typ: typeNull}, nil
+		case "in":
+			return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+		}
+		if p.isOp("(") {
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			if t.text == "has" {
+				if len(args) != 1 {
+					return nil, fmt.Errorf("has expects a single field selection")
+				}
+				member, ok := args[0].(*memberNode)
+				if !ok {
+					return nil, fmt.Errorf("has expects a field selection like has(task.field)")
+				}
+				return &hasNode{member: member}, nil
+			}
+			return newCallNode(t.text, args)
+		}
+		p.refs[t.text] = struct{}{}
+		return &identNode{name: t.text}, nil
+	case tokenOp:
+		switch t.text {
+		case "(":
+			node, err := p.parseConditional()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		case "[":
+			list := &listNode{}
+			for !p.isOp("]") {
+				if len(list.items) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+				item, err := p.parseConditional()
+				if err != nil {
+					return nil, err
+				}
+				list.items = append(list.items, item)
+			}
+			p.next()
+			return list, nil
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+// Nodes
+
+type literalNode struct {
+	value any
+	typ   exprType
+}
+
+func (n *literalNode) check(map[string]exprType) (exprType, error) { return n.typ, nil }
+func (n *literalNode) eval(map[string]any) (any, error)            { return n.value, nil }
+
+type identNode struct {
+	name string
+}
+
+func (n *identNode) check(types map[string]exprType) (exprType, error) {
+	return types[n.name], nil
+}
+
+func (n *identNode) eval(vars map[string]any) (any, error) {
+	value, ok := vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("undefined variable %q", n.name)
+	}
+	return normalizeValue(value), nil
+}
+
+type listNode struct {
+	items []exprNode
+}
+
+func (n *listNode) check(types map[string]exprType) (exprType, error) {
+	for _, item := range n.items {
+		if _, err := item.check(types); err != nil {
+			return typeDyn, err
+		}
+	}
+	return typeList, nil
+}
+
+func (n *listNode) eval(vars map[string]any) (any, error) {
+	list := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		value, err := item.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+type memberNode struct {
+	target exprNode
+	field  string
+}
+
+func (n *memberNode) check(types map[string]exprType) (exprType, error) {
+	target, err := n.target.check(types)
+	if err != nil {
+		return typeDyn, err
+	}
+	if !target.fits(typeMap) {
+		return typeDyn, fmt.Errorf("cannot select field %q of %s", n.field, target)
+	}
+	return typeDyn, nil
+}
+
+func (n *memberNode) eval(vars map[string]any) (any, error) {
+	target, err := n.target.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := target.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot select field %q of %s", n.field, typeOf(target))
+	}
+	value, ok := m[n.field]
+	if !ok {
+		return nil, fmt.Errorf("no such key %q", n.field)
+	}
+	return normalizeValue(value), nil
+}
+
+type hasNode struct {
+	member *memberNode
+}
+
+func (n *hasNode) check(types map[string]exprType) (exprType, error) {
+	if _, err := n.member.check(types); err != nil {
+		return typeDyn, err
+	}
+	return typeBool, nil
+}
+
+func (n *hasNode) eval(vars map[string]any) (any, error) {
+	target, err := n.member.target.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := target.(map[string]any)
+	if !ok {
+		return false, nil
+	}
+	_, ok = m[n.member.field]
+	return ok, nil
+}
+
+type indexNode struct {
+	target exprNode
+	key    exprNode
+}
+
+func (n *indexNode) check(types map[string]exprType) (exprType, error) {
+	target, err := n.target.check(types)
+	if err != nil {
+		return typeDyn, err
+	}
+	key, err := n.key.check(types)
+	if err != nil {
+		return typeDyn, err
+	}
+	switch target {
+	case typeList:
+		if !key.fits(typeNumber) {
+			return typeDyn, fmt.Errorf("list index must be a number, not %s", key)
+		}
+	case typeMap:
+		if !key.fits(typeString) {
+			return typeDyn, fmt.Errorf("map key must be a string, not %s", key)
+		}
+	case typeDyn:
+	default:
+		return typeDyn, fmt.Errorf("cannot index %s", target)
+	}
+	return typeDyn, nil
+}
+
+func (n *indexNode) eval(vars map[string]any) (any, error) {
+	target, err := n.target.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	key, err := n.key.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch t := target.(type) {
+	case []any:
+		i, ok := key.(float64)
+		if !ok || i != math.Trunc(i) {
+			return nil, fmt.Errorf("list index must be an integer, not %s", typeOf(key))
+		}
+		if i < 0 || int(i) >= len(t) {
+			return nil, fmt.Errorf("index %d out of range", int(i))
+		}
+		return normalizeValue(t[int(i)]), nil
+	case map[string]any:
+		k, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key must be a string, not %s", typeOf(key))
+		}
+		value, ok := t[k]
+		if !ok {
+			return nil, fmt.Errorf("no such key %q", k)
+		}
+		return normalizeValue(value), nil
+	}
+	return nil, fmt.Errorf("cannot index %s", typeOf(target))
+}
+
+type unaryNode struct {
+	op      string
+	operand exprNode
+}
+
+func (n *unaryNode) check(types map[string]exprType) (exprType, error) {
+	operand, err := n.operand.check(types)
+	if err != nil {
+		return typeDyn, err
+	}
+	want := typeNumber
+	if n.op == "!" {
+		want = typeBool
+	}
+	if !operand.fits(want) {
+		return typeDyn, fmt.Errorf("operator %s expects %s, not %s", n.op, want, operand)
+	}
+	return want, nil
+}
+
+func (n *unaryNode) eval(vars map[string]any) (any, error) {
+	operand, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		b, ok := operand.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator ! expects bool, not %s", typeOf(operand))
+		}
+		return !b, nil
+	}
+	f, ok := operand.(float64)
+	if !ok {
+		return nil, fmt.Errorf("operator - expects number, not %s", typeOf(operand))
+	}
+	return -f, nil
+}
+
+type conditionalNode struct {
+	cond, then, otherwise exprNode
+}
+
+func (n *conditionalNode) check(types map[string]exprType) (exprType, error) {
+	cond, err := n.cond.check(types)
+	if err != nil {
+		return typeDyn, err
+	}
+	if !cond.fits(typeBool) {
+		return typeDyn, fmt.Errorf("condition of ?: must be bool, not %s", cond)
+	}
+	then, err := n.then.check(types)
+	if err != nil {
+		return typeDyn, err
+	}
+	otherwise, err := n.otherwise.check(types)
+	if err != nil {
+		return typeDyn, err
+	}
+	if then == otherwise {
+		return then, nil
+	}
+	return typeDyn, nil
+}
+
+func (n *conditionalNode) eval(vars map[string]any) (any, error) {
+	cond, err := n.cond.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := cond.(bool)
+	if !ok {
+		return nil, fmt.Errorf("condition of ?: must be bool, not %s", typeOf(cond))
+	}
+	if b {
+		return n.then.eval(vars)
+	}
+	return n.otherwise.eval(vars)
+}
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *binaryNode) check(types map[string]exprType) (exprType, error) {
+	left, err := n.left.check(types)
+	if err != nil {
+		return typeDyn, err
+	}
+	right, err := n.right.check(types)
+	if err != nil {
+		return typeDyn, err
+	}
+	switch n.op {
+	case "&&", "||":
+		if !left.fits(typeBool) || !right.fits(typeBool) {
+			return typeDyn, fmt.Errorf("operator %s expects bool operands, not %s and %s", n.op, left, right)
+		}
+		return typeBool, nil
+	case "==", "!=":
+		if !left.fits(right) && left != typeNull && right != typeNull {
+			return typeDyn, fmt.Errorf("cannot compare %s and %s", left, right)
+		}
+		return typeBool, nil
+	case "<", "<=", ">", ">=":
+		numbers := left.fits(typeNumber) && right.fits(typeNumber)
+		strs := left.fits(typeString) && right.fits(typeString)
+		if !numbers && !strs {
+			return typeDyn, fmt.Errorf("operator %s cannot order %s and %s", n.op, left, right)
+		}
+		return typeBool, nil
+	case "in":
+		if !right.fits(typeList) && !right.fits(typeMap) {
+			return typeDyn, fmt.Errorf("operator in expects a list or map, not %s", right)
+		}
+		return typeBool, nil
+	case "+":
+		if !left.fits(right) {
+			return typeDyn, fmt.Errorf("operator + cannot combine %s and %s", left, right)
+		}
+		result := left
+		if result == typeDyn {
+			result = right
+		}
+		switch result {
+		case typeNumber, typeString, typeList, typeDyn:
+			return result, nil
+		}
+		return typeDyn, fmt.Errorf("operator + cannot combine %s and %s", left, right)
+	default:
+		if !left.fits(typeNumber) || !right.fits(typeNumber) {
+			return typeDyn, fmt.Errorf("operator %s expects numbers, not %s and %s", n.op, left, right)
+		}
+		return typeNumber, nil
+	}
+}
+
+func (n *binaryNode) eval(vars map[string]any) (any, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s expects bool, not %s", n.op, typeOf(left))
+		}
+		if l == (n.op == "||") {
+			return l, nil
+		}
+		right, err := n.right.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s expects bool, not %s", n.op, typeOf(right))
+		}
+		return r, nil
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return valuesEqual(left, right), nil
+	case "!=":
+		return !valuesEqual(left, right), nil
+	case "in":
+		switch r := right.(type) {
+		case []any:
+			for _, item := range r {
+				if valuesEqual(left, normalizeValue(item)) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]any:
+			key, ok := left.(string)
+			if !ok {
+				return false, nil
+			}
+			_, ok = r[key]
+			return ok, nil
+		}
+		return nil, fmt.Errorf("operator in expects a list or map, not %s", typeOf(right))
+	case "<", "<=", ">", ">=":
+		cmp, err := orderValues(left, right)
+		if err != nil {
+			return nil, fmt.Errorf("operator %s: %v", n.op, err)
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		}
+		return cmp >= 0, nil
+	case "+":
+		switch l := left.(type) {
+		case string:
+			if r, ok := right.(string); ok {
+				return l + r, nil
+			}
+		case []any:
+			if r, ok := right.([]any); ok {
+				return append(append([]any{}, l...), r...), nil
+			}
+		}
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s cannot combine %s and %s", n.op, typeOf(left), typeOf(right))
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+// Functions
+
+type exprFunction struct {
+	params []exprType
+	result exprType
+	call   func(args []any) (any, error)
+}
+
+var exprFunctions = map[string]exprFunction{
+	"size": {[]exprType{typeDyn}, typeNumber, func(args []any) (any, error) {
+		switch v := args[0].(type) {
+		case string:
+			return float64(len([]rune(v))), nil
+		case []any:
+			return float64(len(v)), nil
+		case map[string]any:
+			return float64(len(v)), nil
+		}
+		return nil, fmt.Errorf("size of %s is undefined", typeOf(args[0]))
+	}},
+	"contains":   {[]exprType{typeString, typeString}, typeBool, stringPredicate(strings.Contains)},
+	"startsWith": {[]exprType{typeString, typeString}, typeBool, stringPredicate(strings.HasPrefix)},
+	"endsWith":   {[]exprType{typeString, typeString}, typeBool, stringPredicate(strings.HasSuffix)},
+	"matches": {[]exprType{typeString, typeString}, typeBool, func(args []any) (any, error) {
+		s, ok1 := args[0].(string)
+		pattern, ok2 := args[1].(string)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("matches expects strings, not %s and %s", typeOf(args[0]), typeOf(args[1]))
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("matches: %v", err)
+		}
+		return re.MatchString(s), nil
+	}},
+	"lower": {[]exprType{typeString}, typeString, stringFunction(strings.ToLower)},
+	"upper": {[]exprType{typeString}, typeString, stringFunction(strings.ToUpper)},
+	"int": {[]exprType{typeDyn}, typeNumber, func(args []any) (any, error) {
+		n, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return math.Trunc(n), nil
+	}},
+	"double": {[]exprType{typeDyn}, typeNumber, func(args []any) (any, error) {
+		return toNumber(args[0])
+	}},
+	"string": {[]exprType{typeDyn}, typeString, func(args []any) (any, error) {
+		return formatValue(args[0]), nil
+	}},
+}
+
+func stringPredicate(fn func(s, arg string) bool) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		s, ok1 := args[0].(string)
+		arg, ok2 := args[1].(string)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("expected strings, not %s and %s", typeOf(args[0]), typeOf(args[1]))
+		}
+		return fn(s, arg), nil
+	}
+}
+
+func stringFunction(fn func(s string) string) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, not %s", typeOf(args[0]))
+		}
+		return fn(s), nil
+	}
+}
+
+func toNumber(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		n, err := parseNumber(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert %q to a number", v)
+		}
+		return n, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("cannot convert %s to a number", typeOf(value))
+}
+
+type callNode struct {
+	name string
+	fn   exprFunction
+	args []exprNode
+	// pattern is the precompiled regular expression of matches calls with a literal pattern.
+	pattern *regexp.Regexp
+}
+
+func newCallNode(name string, args []exprNode) (exprNode, error) {
+	fn, ok := exprFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+	if len(args) != len(fn.params) {
+		return nil, fmt.Errorf("function %s expects %d arguments, got %d", name, len(fn.params), len(args))
+	}
+	node := &callNode{name: name, fn: fn, args: args}
+	if lit, ok := args[len(args)-1].(*literalNode); ok && name == "matches" && lit.typ == typeString {
+		re, err := regexp.Compile(lit.value.(string))
+		if err != nil {
+			return nil, fmt.Errorf("matches: %v", err)
+		}
+		node.pattern = re
+	}
+	return node, nil
+}
+
+func (n *callNode) check(types map[string]exprType) (exprType, error) {
+	for i, arg := range n.args {
+		typ, err := arg.check(types)
+		if err != nil {
+			return typeDyn, err
+		}
+		if !typ.fits(n.fn.params[i]) {
+			return typeDyn, fmt.Errorf("argument %d of %s must be %s, not %s", i+1, n.name, n.fn.params[i], typ)
+		}
+	}
+	return n.fn.result, nil
+}
+
+func (n *callNode) eval(vars map[string]any) (any, error) {
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	if n.pattern != nil {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("matches expects a string, not %s", typeOf(args[0]))
+		}
+		return n.pattern.MatchString(s), nil
+	}
+	result, err := n.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", n.name, err)
+	}
+	return result, nil
+}
+
+// Values
+
+// normalizeValue converts Go values of task outputs into the expression value model:
+// float64, string, bool, nil, []any and map[string]any.
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case nil, float64, string, bool, []any, map[string]any:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return f
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Slice, reflect.Array:
+		list := make([]any, rv.Len())
+		for i := range list {
+			list[i] = rv.Index(i).Interface()
+		}
+		return list
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			m := make(map[string]any, rv.Len())
+			for _, key := range rv.MapKeys() {
+				m[key.String()] = rv.MapIndex(key).Interface()
+			}
+			return m
+		}
+	}
+
+	// Structs and other values are viewed through their JSON encoding.
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+func typeOf(value any) exprType {
+	switch value.(type) {
+	case nil:
+		return typeNull
+	case bool:
+		return typeBool
+	case float64:
+		return typeNumber
+	case string:
+		return typeString
+	case []any:
+		return typeList
+	case map[string]any:
+		return typeMap
+	}
+	return typeDyn
+}
+
+// valuesEqual compares normalized values, values of different types are never equal.
+func valuesEqual(left, right any) bool {
+	switch l := left.(type) {
+	case []any:
+		r, ok := right.([]any)
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for i := range l {
+			if !valuesEqual(normalizeValue(l[i]), normalizeValue(r[i])) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		r, ok := right.(map[string]any)
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for key, lv := range l {
+			rv, ok := r[key]
+			if !ok || !valuesEqual(normalizeValue(lv), normalizeValue(rv)) {
+				return false
+			}
+		}
+		return true
+	}
+	if typeOf(left) != typeOf(right) {
+		return false
+	}
+	return left == right
+}
+
+func orderValues(left, right any) (int, error) {
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			switch {
+			case l < r:
+				return -1, nil
+			case l > r:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			return strings.Compare(l, r), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot order %s and %s", typeOf(left), typeOf(right))
+}
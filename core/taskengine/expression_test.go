@@ -0,0 +1,165 @@
+package taskengine_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/contenox/contenox/core/llmresolver"
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedExecutor returns a fixed output per task ID.
+type scriptedExecutor struct {
+	outputs  map[string]any
+	executed []string
+}
+
+func (s *scriptedExecutor) TaskExec(_ context.Context, _ llmresolver.Policy, task *taskengine.ChainTask, _ string) (any, string, error) {
+	s.executed = append(s.executed, task.ID)
+	output := s.outputs[task.ID]
+	return output, fmt.Sprint(output), nil
+}
+
+func triageChain(when string) *taskengine.ChainDefinition {
+	next := func(id string) taskengine.Transition {
+		return taskengine.Transition{Next: []taskengine.ConditionalTransition{{Value: "_default", ID: id}}}
+	}
+	return &taskengine.ChainDefinition{
+		ID: "triage",
+		Tasks: []taskengine.ChainTask{
+			{ID: "extract", Type: taskengine.PromptToJSON, PromptTemplate: "{{ .input }}", Transition: next("score")},
+			{
+				ID:             "score",
+				Type:           taskengine.PromptToScore,
+				PromptTemplate: "{{ .input }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{
+						{When: when, ID: "escalate"},
+						{Value: "_default", ID: "archive"},
+					},
+				},
+			},
+			{ID: "escalate", Type: taskengine.PromptToString, Transition: next("end")},
+			{ID: "archive", Type: taskengine.PromptToString, Transition: next("end")},
+		},
+	}
+}
+
+func TestSimpleEnv_ExpressionTransitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		when     string
+		extract  map[string]any
+		score    float64
+		expected string
+	}{
+		{"example", `extract.priority == "high" && score > 0.7`, map[string]any{"priority": "high"}, 0.9, "escalate"},
+		{"low score", `extract.priority == "high" && score > 0.7`, map[string]any{"priority": "high"}, 0.5, "archive"},
+		{"list membership", `"billing" in extract.tags && size(extract.tags) <= 2`, map[string]any{"tags": []any{"billing", "urgent"}}, 0, "escalate"},
+		{"optional field", `has(extract.customer) ? extract.customer.tier != "free" : input.contains("enterprise")`, map[string]any{}, 0, "escalate"},
+		{"nested field", `extract.customer["tier"] == "free" || -score < -1`, map[string]any{"customer": map[string]any{"tier": "pro"}}, 0.5, "archive"},
+		{"conversion", `int(extract.count) % 2 == 0 && lower(extract.label).matches("^re:")`, map[string]any{"count": "4", "label": "RE: invoice"}, 0, "escalate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &scriptedExecutor{outputs: map[string]any{"extract": tt.extract, "score": tt.score}}
+			env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec)
+			require.NoError(t, err)
+
+			_, err = env.ExecEnv(context.Background(), triageChain(tt.when), "enterprise ticket")
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, exec.executed[len(exec.executed)-1])
+		})
+	}
+}
+
+func TestSimpleEnv_ExpressionEvaluation(t *testing.T) {
+	tests := []struct {
+		name     string
+		when     string
+		extract  map[string]any
+		expected string
+		err      string
+	}{
+		{name: "multiplication binds tighter", when: `1 + 2 * 3 == 7`, expected: "escalate"},
+		{name: "parentheses", when: `(1 + 2) * 3 == 9`, expected: "escalate"},
+		{name: "left associative", when: `10 - 4 - 3 == 3 && 8 / 4 / 2 == 1`, expected: "escalate"},
+		{name: "modulo and multiplication", when: `7 % 4 * 2 == 6`, expected: "escalate"},
+		{name: "and binds tighter than or", when: `true || false && false`, expected: "escalate"},
+		{name: "not binds tighter than and", when: `!false && false`, expected: "archive"},
+		{name: "unary minus", when: `-2 * -3 == 6`, expected: "escalate"},
+		{name: "comparison before equality", when: `1 < 2 == true`, expected: "escalate"},
+		{name: "conditional is lowest", when: `score > 0.5 ? false : true`, expected: "archive"},
+		{name: "integer", when: `42 == 42.0`, expected: "escalate"},
+		{name: "leading dot", when: `.5 == 0.5`, expected: "escalate"},
+		{name: "exponent", when: `1e3 == 1000 && 2.5E-1 == 0.25`, expected: "escalate"},
+		{name: "invalid number", when: `1.2.3 == 1`, err: `invalid number "1.2.3"`},
+		{name: "dynamic type mismatch", when: `extract.count > 1`, extract: map[string]any{"count": "many"}, err: "cannot order string and number"},
+		{name: "conversion error", when: `int(extract.count) > 1`, extract: map[string]any{"count": "many"}, err: `cannot convert "many" to a number`},
+		{name: "size of number", when: `size(extract.count) > 1`, extract: map[string]any{"count": 3.0}, err: "size of number is undefined"},
+		{name: "missing key", when: `extract.customer.tier == "pro"`, extract: map[string]any{}, err: `no such key "customer"`},
+		{name: "missing key guarded by has", when: `has(extract.customer) && extract.customer.tier == "pro"`, extract: map[string]any{}, expected: "archive"},
+		{name: "missing index", when: `extract.tags[2] == "x"`, extract: map[string]any{"tags": []any{"a"}}, err: "index 2 out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &scriptedExecutor{outputs: map[string]any{"extract": tt.extract, "score": 0.9}}
+			env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec)
+			require.NoError(t, err)
+
+			_, err = env.ExecEnv(context.Background(), triageChain(tt.when), "ticket")
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, exec.executed[len(exec.executed)-1])
+		})
+	}
+}
+
+func TestSimpleEnv_ExpressionErrors(t *testing.T) {
+	exec := &scriptedExecutor{outputs: map[string]any{"extract": map[string]any{}, "score": 0.9}}
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec)
+	require.NoError(t, err)
+
+	_, err = env.ExecEnv(context.Background(), triageChain(`score == "high"`), "x")
+	require.ErrorContains(t, err, "cannot compare number and string")
+	require.Empty(t, exec.executed, "invalid expressions must fail before the first task")
+
+	_, err = env.ExecEnv(context.Background(), triageChain(`extract.priority == "high"`), "x")
+	require.ErrorContains(t, err, `no such key "priority"`)
+}
+
+func TestValidate_TransitionExpressions(t *testing.T) {
+	tests := []struct {
+		when    string
+		message string
+	}{
+		{`extract.priority == "high" && score > 0.7`, ""},
+		{`score.value > 1`, "cannot select field \"value\" of number"},
+		{`input > 3`, "operator > cannot order string and number"},
+		{`score + 1`, "must evaluate to bool, not number"},
+		{`extract.priority == `, "unexpected end of expression"},
+		{`shout(input)`, `unknown function "shout"`},
+		{`customer.tier == "pro"`, `references unknown variable "customer"`},
+		{`escalate == "done"`, `references task "escalate" which never runs before it`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.when, func(t *testing.T) {
+			result, err := taskengine.Validate(context.Background(), triageChain(tt.when), nil)
+			require.NoError(t, err)
+			if tt.message == "" {
+				require.True(t, result.Valid, "%v", result.Issues)
+				return
+			}
+			require.False(t, result.Valid)
+			require.Len(t, result.Issues, 1)
+			require.Equal(t, "score", result.Issues[0].TaskID)
+			require.Contains(t, result.Issues[0].Message, tt.message)
+		})
+	}
+}
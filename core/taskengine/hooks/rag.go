@@ -26,7 +26,10 @@ func NewRagHook(embedder llmrepo.ModelRepo, vectorsStore vectors.Store, dbInstan
 	}
 }
 
-var _ taskengine.HookRepo = (*RagHook)(nil)
+var (
+	_ taskengine.HookRepo      = (*RagHook)(nil)
+	_ taskengine.ToolDescriber = (*RagHook)(nil)
+)
 
 func (h *RagHook) Exec(ctx context.Context, hook *taskengine.HookCall) (int, any, error) {
 	data, err := indexrepo.ResolveBlobFromQuery(ctx, h.embedder, h.vectorsStore, h.dbInstance.WithoutTransaction(), hook.Input, h.topK)
@@ -40,3 +43,12 @@ func (h *RagHook) Exec(ctx context.Context, hook *taskengine.HookCall) (int, any
 func (h *RagHook) Supports(ctx context.Context) ([]string, error) {
 	return []string{"rag"}, nil
 }
+
+func (h *RagHook) Tools(ctx context.Context) ([]taskengine.ToolSchema, error) {
+	return []taskengine.ToolSchema{{
+		Name:        "rag",
+		Description: "Searches the indexed documents and returns the passages most relevant to the input.",
+		Input:       "The search query.",
+		Args:        map[string]any{"type": "object", "additionalProperties": false},
+	}}, nil
+}
@@ -91,10 +91,10 @@ func (h *WebhookCaller) Exec(ctx context.Context, hook *taskengine.HookCall) (in
 		if json.Valid([]byte(hook.Input)) {
 			body = bytes.NewBufferString(hook.Input)
 		} else {
-			// Otherwise wrap in JSON
+			// Otherwise wrap in JSON. The args configure the request and may hold
+			// resolved secrets such as the headers, so they are not part of the payload.
 			payload := map[string]interface{}{
 				"message": hook.Input,
-				"data":    hook.Args,
 			}
 			jsonData, err := json.Marshal(payload)
 			if err != nil {
@@ -152,4 +152,26 @@ func (h *WebhookCaller) Supports(ctx context.Context) ([]string, error) {
 	return []string{"webhook"}, nil
 }
 
-var _ taskengine.HookRepo = (*WebhookCaller)(nil)
+func (h *WebhookCaller) Tools(ctx context.Context) ([]taskengine.ToolSchema, error) {
+	return []taskengine.ToolSchema{{
+		Name:        "webhook",
+		Description: "Sends an HTTP request and returns the response body.",
+		Input:       "The request body. Text that is not JSON is wrapped into a JSON object with the args.",
+		Args: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"url":     map[string]any{"type": "string", "description": "The URL to call."},
+				"method":  map[string]any{"type": "string", "description": "The HTTP method, defaults to POST."},
+				"query":   map[string]any{"type": "string", "description": "URL encoded query parameters."},
+				"headers": map[string]any{"type": "string", "description": "A JSON object of additional headers."},
+			},
+			"required":             []any{"url"},
+			"additionalProperties": false,
+		},
+	}}, nil
+}
+
+var (
+	_ taskengine.HookRepo      = (*WebhookCaller)(nil)
+	_ taskengine.ToolDescriber = (*WebhookCaller)(nil)
+)
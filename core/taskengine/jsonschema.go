@@ -0,0 +1,310 @@
+package taskengine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// validateSchema checks value against a JSON Schema and returns every violation found.
+//
+// Only the subset of JSON Schema needed to describe LLM output is supported:
+// type, properties, required, additionalProperties (boolean or schema), items, enum,
+// const, minimum, maximum, minLength, maxLength, pattern, minItems and maxItems.
+// Values are expected in the form produced by encoding/json (map[string]any, []any, float64, ...).
+func validateSchema(schema map[string]any, value any) []string {
+	var errs []string
+	checkSchema(schema, value, "$", &errs)
+	return errs
+}
+
+// checkSchemaDefinition reports keywords of a schema that can never be satisfied
+// or are not understood by validateSchema.
+func checkSchemaDefinition(schema map[string]any) []string {
+	var errs []string
+	checkDefinition(schema, "$", &errs)
+	return errs
+}
+
+var schemaTypes = map[string]struct{}{
+	"object": {}, "array": {}, "string": {}, "number": {}, "integer": {}, "boolean": {}, "null": {},
+}
+
+func checkDefinition(schema map[string]any, path string, errs *[]string) {
+	for _, t := range schemaTypeNames(schema) {
+		if _, ok := schemaTypes[t]; !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: unknown type %q", path, t))
+		}
+	}
+	if raw, ok := schema["type"]; ok {
+		switch raw.(type) {
+		case string, []any:
+		default:
+			*errs = append(*errs, fmt.Sprintf("%s: type must be a string or a list of strings", path))
+		}
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if _, err := regexp.Compile(pattern); err != nil {
+			*errs = append(*errs, fmt.Sprintf("%s: invalid pattern: %v", path, err))
+		}
+	}
+	if props, ok := schema["properties"]; ok {
+		propMap, ok := props.(map[string]any)
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: properties must be an object", path))
+		}
+		for name, sub := range propMap {
+			subSchema, ok := sub.(map[string]any)
+			if !ok {
+				*errs = append(*errs, fmt.Sprintf("%s.%s: schema must be an object", path, name))
+				continue
+			}
+			checkDefinition(subSchema, path+"."+name, errs)
+		}
+	}
+	if items, ok := schema["items"]; ok {
+		itemSchema, ok := items.(map[string]any)
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s[]: schema must be an object", path))
+		} else {
+			checkDefinition(itemSchema, path+"[]", errs)
+		}
+	}
+	if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+		checkDefinition(additional, path+".*", errs)
+	}
+	if required, ok := schema["required"]; ok {
+		if _, ok := required.([]any); !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: required must be a list of property names", path))
+		}
+	}
+}
+
+func checkSchema(schema map[string]any, value any, path string, errs *[]string) {
+	if types := schemaTypeNames(schema); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if hasSchemaType(t, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonTypeName(value)))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, candidate := range enum {
+			if jsonEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*errs = append(*errs, fmt.Sprintf("%s: value %s is not one of %s", path, compactJSON(value), compactJSON(enum)))
+		}
+	}
+	if constant, ok := schema["const"]; ok && !jsonEqual(constant, value) {
+		*errs = append(*errs, fmt.Sprintf("%s: value must be %s", path, compactJSON(constant)))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		checkObject(schema, v, path, errs)
+	case []any:
+		if limit, ok := schemaNumber(schema, "minItems"); ok && float64(len(v)) < limit {
+			*errs = append(*errs, fmt.Sprintf("%s: expected at least %v items, got %d", path, limit, len(v)))
+		}
+		if limit, ok := schemaNumber(schema, "maxItems"); ok && float64(len(v)) > limit {
+			*errs = append(*errs, fmt.Sprintf("%s: expected at most %v items, got %d", path, limit, len(v)))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				checkSchema(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if limit, ok := schemaNumber(schema, "minLength"); ok && length < limit {
+			*errs = append(*errs, fmt.Sprintf("%s: expected at least %v characters", path, limit))
+		}
+		if limit, ok := schemaNumber(schema, "maxLength"); ok && length > limit {
+			*errs = append(*errs, fmt.Sprintf("%s: expected at most %v characters", path, limit))
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err == nil && !re.MatchString(v) {
+				*errs = append(*errs, fmt.Sprintf("%s: value does not match pattern %q", path, pattern))
+			}
+		}
+	case float64:
+		if limit, ok := schemaNumber(schema, "minimum"); ok && v < limit {
+			*errs = append(*errs, fmt.Sprintf("%s: value %v is less than minimum %v", path, v, limit))
+		}
+		if limit, ok := schemaNumber(schema, "maximum"); ok && v > limit {
+			*errs = append(*errs, fmt.Sprintf("%s: value %v is greater than maximum %v", path, v, limit))
+		}
+	}
+}
+
+func checkObject(schema map[string]any, obj map[string]any, path string, errs *[]string) {
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			key, _ := name.(string)
+			if _, ok := obj[key]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s: missing required property %q", path, key))
+			}
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]any)
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if sub, ok := props[key].(map[string]any); ok {
+			checkSchema(sub, obj[key], path+"."+key, errs)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*errs = append(*errs, fmt.Sprintf("%s: unexpected property %q", path, key))
+			}
+		case map[string]any:
+			checkSchema(additional, obj[key], path+"."+key, errs)
+		}
+	}
+}
+
+func schemaTypeNames(schema map[string]any) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		names := make([]string, 0, len(t))
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+func hasSchemaType(t string, value any) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func schemaNumber(schema map[string]any, key string) (float64, bool) {
+	switch n := schema[key].(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func jsonEqual(a, b any) bool {
+	return compactJSON(a) == compactJSON(b)
+}
+
+func compactJSON(value any) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(b)
+}
+
+// lookupPath resolves a dot separated path (e.g. "customer.tags.0") inside a parsed JSON value.
+func lookupPath(value any, path string) (any, error) {
+	current := value
+	for _, part := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case map[string]any:
+			next, ok := v[part]
+			if !ok {
+				return nil, fmt.Errorf("path %q: key %q not found", path, part)
+			}
+			current = next
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("path %q: invalid index %q", path, part)
+			}
+			current = v[i]
+		default:
+			return nil, fmt.Errorf("path %q: cannot descend into %s at %q", path, jsonTypeName(current), part)
+		}
+	}
+	return current, nil
+}
+
+// formatValue renders a parsed JSON value the way transitions compare it.
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return ""
+	}
+	return compactJSON(value)
+}
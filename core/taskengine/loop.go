@@ -0,0 +1,211 @@
+package taskengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSteps is the step budget of chains that do not set ChainDefinition.MaxSteps.
+const DefaultMaxSteps = 1000
+
+// LoopLimit names the limit that stopped an execution.
+type LoopLimit string
+
+const (
+	// LimitMaxSteps is hit when the chain enters more tasks than ChainDefinition.MaxSteps.
+	LimitMaxSteps LoopLimit = "max_steps"
+
+	// LimitMaxVisits is hit when the chain enters a task more often than ChainTask.MaxVisits.
+	LimitMaxVisits LoopLimit = "max_visits"
+
+	// LimitTimeout is hit when the execution runs longer than ChainDefinition.Timeout.
+	LimitTimeout LoopLimit = "timeout"
+)
+
+// LoopLimitError reports that an execution was stopped by one of its loop limits.
+type LoopLimitError struct {
+	// Limit is the limit that was hit.
+	Limit LoopLimit
+
+	// Max is the configured value of the limit, e.g. the step count or "30s".
+	Max string
+
+	// TaskID is the task the chain was about to run.
+	TaskID string
+
+	// Cycle lists the tasks of the loop the chain was in, starting and ending with TaskID.
+	// It is empty if the task was not revisited.
+	Cycle []string
+}
+
+func (e *LoopLimitError) Error() string {
+	msg := fmt.Sprintf("loop limit %s (%s) exceeded at task %s", e.Limit, e.Max, e.TaskID)
+	if len(e.Cycle) > 0 {
+		msg += ": cycle " + strings.Join(e.Cycle, " -> ")
+	}
+	return msg
+}
+
+// errChainTimeout is the cancellation cause of executions that exceeded ChainDefinition.Timeout.
+var errChainTimeout = errors.New("chain timeout exceeded")
+
+// withChainTimeout bounds ctx by the chain's wall-clock limit, if any.
+func withChainTimeout(ctx context.Context, chain *ChainDefinition) (context.Context, context.CancelFunc, error) {
+	if chain.Timeout == "" {
+		return ctx, func() {}, nil
+	}
+	timeout, err := time.ParseDuration(chain.Timeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid chain timeout: %v", err)
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, errChainTimeout)
+	return ctx, cancel, nil
+}
+
+// timeoutError returns a LoopLimitError if ctx was cancelled by the chain's wall-clock limit.
+func timeoutError(ctx context.Context, chain *ChainDefinition, state *ExecutionState, taskID string) error {
+	if !errors.Is(context.Cause(ctx), errChainTimeout) {
+		return nil
+	}
+	return &LoopLimitError{
+		Limit:  LimitTimeout,
+		Max:    chain.Timeout,
+		TaskID: taskID,
+		Cycle:  lastCycle(state.Path, taskID),
+	}
+}
+
+// maxSteps returns the step budget of state, sub-chains are also bound by what is left
+// of the budget of their callers.
+func maxSteps(chain *ChainDefinition, state *ExecutionState) int {
+	limit := chain.MaxSteps
+	if limit <= 0 {
+		limit = DefaultMaxSteps
+	}
+	if state.stepBudget != nil {
+		limit = min(limit, *state.stepBudget)
+	}
+	return limit
+}
+
+// enterTask records that the chain moves to task and enforces the step and visit limits.
+func enterTask(chain *ChainDefinition, state *ExecutionState, task *ChainTask) error {
+	limit := maxSteps(chain, state)
+	if len(state.Path) >= limit {
+		return &LoopLimitError{
+			Limit:  LimitMaxSteps,
+			Max:    fmt.Sprint(limit),
+			TaskID: task.ID,
+			Cycle:  lastCycle(state.Path, task.ID),
+		}
+	}
+	if task.MaxVisits > 0 && state.Visits[task.ID] >= task.MaxVisits {
+		return &LoopLimitError{
+			Limit:  LimitMaxVisits,
+			Max:    fmt.Sprint(task.MaxVisits),
+			TaskID: task.ID,
+			Cycle:  lastCycle(state.Path, task.ID),
+		}
+	}
+	state.Visits[task.ID]++
+	state.Path = append(state.Path, task.ID)
+	return nil
+}
+
+// lastCycle returns the tasks between the last visit of taskID and now, closed by taskID.
+func lastCycle(path []string, taskID string) []string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] == taskID {
+			cycle := append([]string{}, path[i:]...)
+			return append(cycle, taskID)
+		}
+	}
+	return nil
+}
+
+type chainScopeKey struct{}
+
+// chainScope is the chain whose steps run with a context, together with its state.
+// Sub-chains called from its tasks share its step budget and report back to it.
+type chainScope struct {
+	mu    sync.Mutex
+	chain *ChainDefinition
+	state *ExecutionState
+}
+
+func withChainScope(ctx context.Context, chain *ChainDefinition, state *ExecutionState) context.Context {
+	return context.WithValue(ctx, chainScopeKey{}, &chainScope{chain: chain, state: state})
+}
+
+func chainScopeFromContext(ctx context.Context) (*chainScope, bool) {
+	scope, ok := ctx.Value(chainScopeKey{}).(*chainScope)
+	return scope, ok
+}
+
+// enter prepares the state of a sub-chain: its steps count against what is left of the
+// caller's budget, and a chain calling itself continues the visit counts of its tasks.
+func (s *chainScope) enter(chain *ChainDefinition, state *ExecutionState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	budget := maxSteps(s.chain, s.state) - len(s.state.Path)
+	state.stepBudget = &budget
+	if chain.ID == s.chain.ID {
+		for id, count := range s.state.Visits {
+			state.Visits[id] = count
+		}
+	}
+}
+
+// leave merges the steps, visits, token usage and print log of a finished sub-chain into the caller.
+// It fails with ErrTokenLimit if the tokens of the sub-chain exceed what was left of the caller's budget.
+func (s *chainScope) leave(chain *ChainDefinition, state *ExecutionState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range state.Path {
+		s.state.Path = append(s.state.Path, chain.ID+"/"+id)
+	}
+	s.state.Steps += state.Steps
+	if chain.ID == s.chain.ID {
+		for id, count := range state.Visits {
+			s.state.Visits[id] = max(s.state.Visits[id], count)
+		}
+	}
+	s.state.Log = append(s.state.Log, state.Log...)
+
+	if state.TotalTokens == 0 {
+		return nil
+	}
+	if s.state.TokenUsage == nil {
+		s.state.TokenUsage = map[string]int{}
+	}
+	for id, count := range state.TokenUsage {
+		s.state.TokenUsage[chain.ID+"/"+id] += count
+	}
+	left := int(s.chain.MaxTokenSize) - s.state.TotalTokens
+	s.state.TotalTokens += state.TotalTokens
+	if s.chain.MaxTokenSize > 0 && state.TotalTokens > left {
+		return fmt.Errorf("%w: the sub-chain used %d tokens, %d of the budget of chain %s were left", ErrTokenLimit, state.TotalTokens, left, s.chain.ID)
+	}
+	return nil
+}
+
+// loopVars builds the "loop" template variable for the current task:
+//
+//	{{ .loop.step }}        number of tasks entered so far, including the current one
+//	{{ .loop.visit }}       how often the current task was entered, including now
+//	{{ .loop.visits.ID }}   how often task ID was entered
+func loopVars(state *ExecutionState, taskID string) map[string]any {
+	visits := make(map[string]any, len(state.Visits))
+	for id, count := range state.Visits {
+		visits[id] = count
+	}
+	return map[string]any{
+		"step":   len(state.Path),
+		"visit":  state.Visits[taskID],
+		"visits": visits,
+	}
+}
@@ -0,0 +1,117 @@
+package taskengine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/contenox/contenox/core/llmresolver"
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/stretchr/testify/require"
+)
+
+func loopChain(maxVisits int) *taskengine.ChainDefinition {
+	return &taskengine.ChainDefinition{
+		ID: "loop",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "draft",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "draft {{ .loop.visit }}",
+				MaxVisits:      maxVisits,
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "review"}},
+				},
+			},
+			{
+				ID:             "review",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "review",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "draft"}},
+				},
+			},
+		},
+	}
+}
+
+func TestSimpleEnv_MaxVisitsNamesCycle(t *testing.T) {
+	exec := &recordingExecutor{}
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec)
+	require.NoError(t, err)
+
+	_, err = env.ExecEnv(context.Background(), loopChain(2), "")
+	var loopErr *taskengine.LoopLimitError
+	require.ErrorAs(t, err, &loopErr)
+	require.Equal(t, taskengine.LimitMaxVisits, loopErr.Limit)
+	require.Equal(t, "draft", loopErr.TaskID)
+	require.Equal(t, []string{"draft", "review", "draft"}, loopErr.Cycle)
+	require.Equal(t, []string{"draft", "review", "draft", "review"}, exec.executed)
+}
+
+func TestSimpleEnv_MaxSteps(t *testing.T) {
+	exec := &recordingExecutor{}
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec)
+	require.NoError(t, err)
+
+	chain := loopChain(0)
+	chain.MaxSteps = 5
+	_, err = env.ExecEnv(context.Background(), chain, "")
+	var loopErr *taskengine.LoopLimitError
+	require.ErrorAs(t, err, &loopErr)
+	require.Equal(t, taskengine.LimitMaxSteps, loopErr.Limit)
+	require.Equal(t, "review", loopErr.TaskID)
+	require.Equal(t, []string{"review", "draft", "review"}, loopErr.Cycle)
+	require.Len(t, exec.executed, 5)
+}
+
+func TestSimpleEnv_BoundedLoopUsesLoopVars(t *testing.T) {
+	exec := &recordingExecutor{}
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec)
+	require.NoError(t, err)
+
+	chain := &taskengine.ChainDefinition{
+		ID: "poll",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "poll",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "attempt {{ .loop.visit }} of step {{ .loop.step }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{
+						{Operator: "equals", Value: "attempt 3 of step 3", ID: "end"},
+						{Value: "_default", ID: "poll"},
+					},
+				},
+			},
+		},
+	}
+	output, err := env.ExecEnv(context.Background(), chain, "")
+	require.NoError(t, err)
+	require.Equal(t, "attempt 3 of step 3", output)
+	require.Equal(t, []string{"poll", "poll", "poll"}, exec.executed)
+}
+
+// blockingExecutor blocks every task until its context is done.
+type blockingExecutor struct{}
+
+func (blockingExecutor) TaskExec(ctx context.Context, _ llmresolver.Policy, _ *taskengine.ChainTask, _ string) (any, string, error) {
+	<-ctx.Done()
+	return nil, "", ctx.Err()
+}
+
+func TestSimpleEnv_ChainTimeoutStopsRetries(t *testing.T) {
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, blockingExecutor{})
+	require.NoError(t, err)
+
+	chain := loopChain(0)
+	chain.Timeout = "20ms"
+	chain.Tasks[0].RetryOnError = 100
+	_, err = env.ExecEnv(context.Background(), chain, "")
+	var loopErr *taskengine.LoopLimitError
+	require.ErrorAs(t, err, &loopErr)
+	require.Equal(t, taskengine.LimitTimeout, loopErr.Limit)
+	require.Equal(t, "draft", loopErr.TaskID)
+	require.False(t, errors.Is(err, context.DeadlineExceeded))
+}
@@ -2,6 +2,7 @@ package taskengine
 
 import (
 	"context"
+	"sync"
 
 	"github.com/contenox/contenox/core/llmresolver"
 )
@@ -17,10 +18,13 @@ type MockTaskExecutor struct {
 	// Add a function to dynamically return errors
 	ErrorSequence []error // simulate multiple error responses
 	callIndex     int
+	mu            sync.Mutex
 }
 
 // TaskExec is the mock implementation of the TaskExec method.
 func (m *MockTaskExecutor) TaskExec(ctx context.Context, resolver llmresolver.Policy, currentTask *ChainTask, renderedPrompt string) (any, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.CalledWithTask = currentTask
 	m.CalledWithPrompt = renderedPrompt
 
@@ -0,0 +1,226 @@
+package taskengine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/contenox/contenox/core/llmresolver"
+)
+
+type branchResult struct {
+	id     string
+	output any
+	err    error
+}
+
+// runTask executes a single task, fanning out to its branches if it is a Parallel task
+// and running the referenced chain if it is a SubChain task.
+// Chat tasks run here do not take part in the chain conversation.
+// The args of Hook tasks are rendered right before the hook runs, so resolved secrets are never stored.
+// Hooks of non-blocking Hook tasks are queued if the environment has a HookQueue.
+func (exe SimpleEnv) runTask(ctx context.Context, resolver llmresolver.Policy, task *ChainTask, renderedPrompt string, vars map[string]any) (any, string, error) {
+	switch task.Type {
+	case Parallel:
+		return exe.parallel(ctx, resolver, task, vars)
+	case SubChain:
+		return exe.subChain(ctx, task, renderedPrompt, vars)
+	case Chat:
+		return exe.chat(ctx, resolver, task, renderedPrompt, vars, nil)
+	case Agent:
+		return exe.agent(ctx, resolver, task, renderedPrompt)
+	case Hook:
+		if task.Hook != nil && task.Hook.Async && exe.hooks != nil {
+			return exe.enqueueHook(ctx, task, vars)
+		}
+		if task.Hook != nil && len(task.Hook.Args) > 0 {
+			args, err := exe.renderHookArgs(ctx, task, task.Hook.Args, vars)
+			if err != nil {
+				return nil, "", err
+			}
+			hook := *task.Hook
+			hook.Args = args
+			rendered := *task
+			rendered.Hook = &hook
+			task = &rendered
+		}
+	}
+	if promptsModel(task.Type) {
+		if err := chargeTokens(ctx, task, renderedPrompt); err != nil {
+			return nil, "", err
+		}
+	}
+	return exe.exec.TaskExec(ctx, resolver, task, renderedPrompt)
+}
+
+// parallel runs all branches of a Parallel task concurrently and joins them
+// according to the task's JoinPolicy.
+//
+// The output is a map from branch ID to branch output, containing only the
+// branches that succeeded before the join completed. The raw response is its JSON encoding.
+func (exe SimpleEnv) parallel(ctx context.Context, resolver llmresolver.Policy, task *ChainTask, vars map[string]any) (any, string, error) {
+	cfg := task.Parallel
+	if cfg == nil || len(cfg.Branches) == 0 {
+		return nil, "", fmt.Errorf("parallel task missing branches")
+	}
+	total := len(cfg.Branches)
+	needed, err := requiredBranches(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	limit := cfg.MaxConcurrency
+	if limit <= 0 || limit > total {
+		limit = total
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan branchResult, total)
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := range cfg.Branches {
+		branch := &cfg.Branches[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results <- branchResult{id: branch.ID, err: ctx.Err()}
+				return
+			}
+			output, err := exe.branch(ctx, resolver, branch, vars)
+			results <- branchResult{id: branch.ID, output: output, err: err}
+		}()
+	}
+
+	outputs := make(map[string]any, total)
+	var errs []error
+	for range total {
+		res := <-results
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("branch %s: %w", res.id, res.err))
+			if total-len(errs) < needed {
+				break
+			}
+			continue
+		}
+		outputs[res.id] = res.output
+		if len(outputs) >= needed {
+			break
+		}
+	}
+	// Stop the remaining branches and wait for them, so none of them outlives the task.
+	cancel()
+	wg.Wait()
+
+	if len(outputs) < needed {
+		return nil, "", fmt.Errorf("join %s not satisfied, %d of %d branches succeeded: %w",
+			joinPolicy(cfg), len(outputs), needed, errors.Join(errs...))
+	}
+
+	raw, err := json.Marshal(outputs)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode branch outputs: %w", err)
+	}
+	return outputs, string(raw), nil
+}
+
+// branch renders and executes a single branch task, honoring its timeout and retry settings.
+// Branches are retried like tasks, following their own retry policy or the one of the chain.
+func (exe SimpleEnv) branch(ctx context.Context, resolver llmresolver.Policy, branch *ChainTask, vars map[string]any) (any, error) {
+	renderedPrompt, err := exe.renderTemplate(ctx, branch, branch.PromptTemplate, vars)
+	if err != nil {
+		return nil, fmt.Errorf("template error: %v", err)
+	}
+
+	policy := branch.Retry
+	if scope, ok := chainScopeFromContext(ctx); ok {
+		policy = retryPolicy(scope.chain, branch)
+	}
+	maxRetries := policy.maxRetries(branch)
+	attemptTask, attemptPrompt := branch, renderedPrompt
+
+	var output any
+	var taskErr error
+	for retry := 0; retry <= maxRetries; retry++ {
+		if retry > 0 {
+			class := ClassifyError(taskErr)
+			if !policy.retries(class) {
+				break
+			}
+			if err := exe.backoff(ctx, policy, branch, retry, class); err != nil {
+				return nil, err
+			}
+			attemptTask, attemptPrompt = policy.attempt(branch, renderedPrompt, retry, taskErr)
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		taskCtx := ctx
+		cancel := func() {}
+		if branch.Timeout != "" {
+			timeout, err := time.ParseDuration(branch.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout: %v", err)
+			}
+			taskCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+
+		reportErrAttempt, reportChangeAttempt, endAttempt := exe.tracker.Start(
+			taskCtx,
+			"branch_attempt",
+			branch.ID,
+			"retry", retry,
+			"task_type", branch.Type,
+			"rendered_prompt", attemptPrompt,
+		)
+		selected := &resolution{}
+		var rawResponse string
+		output, rawResponse, taskErr = exe.runTask(taskCtx, selected.wrap(resolver), attemptTask, attemptPrompt, vars)
+		if taskErr != nil {
+			reportErrAttempt(taskErr)
+		} else {
+			reportChangeAttempt(branch.ID, selected.report(output, rawResponse))
+		}
+		endAttempt()
+		cancel()
+		if taskErr == nil {
+			return output, nil
+		}
+		if errors.Is(taskErr, ErrTokenLimit) {
+			break
+		}
+	}
+	return nil, taskErr
+}
+
+// requiredBranches returns how many branches must succeed to satisfy the join policy.
+func requiredBranches(cfg *ParallelConfig) (int, error) {
+	total := len(cfg.Branches)
+	switch joinPolicy(cfg) {
+	case JoinAll:
+		return total, nil
+	case JoinAny:
+		return 1, nil
+	case JoinQuorum:
+		if cfg.Quorum < 1 || cfg.Quorum > total {
+			return 0, fmt.Errorf("quorum must be between 1 and %d, got %d", total, cfg.Quorum)
+		}
+		return cfg.Quorum, nil
+	default:
+		return 0, fmt.Errorf("unsupported join policy: %s", cfg.Join)
+	}
+}
+
+func joinPolicy(cfg *ParallelConfig) JoinPolicy {
+	if cfg.Join == "" {
+		return JoinAll
+	}
+	return cfg.Join
+}
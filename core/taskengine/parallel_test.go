@@ -0,0 +1,169 @@
+package taskengine_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/contenox/contenox/core/llmresolver"
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/stretchr/testify/require"
+)
+
+// branchExecutor echoes the rendered prompt, failing or sleeping for selected task IDs.
+type branchExecutor struct {
+	fail    map[string]bool
+	delay   map[string]time.Duration
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (b *branchExecutor) TaskExec(ctx context.Context, _ llmresolver.Policy, task *taskengine.ChainTask, prompt string) (any, string, error) {
+	current := b.running.Add(1)
+	defer b.running.Add(-1)
+	for {
+		peak := b.peak.Load()
+		if current <= peak || b.peak.CompareAndSwap(peak, current) {
+			break
+		}
+	}
+	select {
+	case <-time.After(b.delay[task.ID]):
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	}
+	if b.fail[task.ID] {
+		return nil, "", errors.New("branch failed")
+	}
+	return prompt, prompt, nil
+}
+
+func parallelChain(cfg *taskengine.ParallelConfig) *taskengine.ChainDefinition {
+	return &taskengine.ChainDefinition{
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:       "fanout",
+				Type:     taskengine.Parallel,
+				Parallel: cfg,
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "merge"}},
+				},
+			},
+			{
+				ID:             "merge",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "{{ .summary }}|{{ .entities }}|{{ .fanout.summary }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+}
+
+func TestSimpleEnv_Parallel_JoinAll(t *testing.T) {
+	exec := &branchExecutor{delay: map[string]time.Duration{"summary": 20 * time.Millisecond, "entities": 20 * time.Millisecond}}
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec)
+	require.NoError(t, err)
+
+	chain := parallelChain(&taskengine.ParallelConfig{
+		MaxConcurrency: 1,
+		Branches: []taskengine.ChainTask{
+			{ID: "summary", Type: taskengine.PromptToString, PromptTemplate: "summarise {{ .input }}"},
+			{ID: "entities", Type: taskengine.PromptToString, PromptTemplate: "extract {{ .input }}"},
+		},
+	})
+
+	result, err := env.ExecEnv(context.Background(), chain, "text")
+	require.NoError(t, err)
+	require.Equal(t, "summarise text|extract text|summarise text", result)
+	require.Equal(t, int32(1), exec.peak.Load())
+}
+
+func TestSimpleEnv_Parallel_JoinAllFails(t *testing.T) {
+	exec := &branchExecutor{fail: map[string]bool{"entities": true}}
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec)
+	require.NoError(t, err)
+
+	chain := parallelChain(&taskengine.ParallelConfig{
+		Branches: []taskengine.ChainTask{
+			{ID: "summary", Type: taskengine.PromptToString, PromptTemplate: "a"},
+			{ID: "entities", Type: taskengine.PromptToString, PromptTemplate: "b"},
+		},
+	})
+
+	_, err = env.ExecEnv(context.Background(), chain, "text")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "branch entities: branch failed")
+}
+
+func TestSimpleEnv_Parallel_JoinAnyCancelsOthers(t *testing.T) {
+	exec := &branchExecutor{
+		fail:  map[string]bool{"broken": true},
+		delay: map[string]time.Duration{"slow": time.Minute},
+	}
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec)
+	require.NoError(t, err)
+
+	chain := &taskengine.ChainDefinition{
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:   "fanout",
+				Type: taskengine.Parallel,
+				Parallel: &taskengine.ParallelConfig{
+					Join: taskengine.JoinAny,
+					Branches: []taskengine.ChainTask{
+						{ID: "broken", Type: taskengine.PromptToString, PromptTemplate: "a"},
+						{ID: "slow", Type: taskengine.PromptToString, PromptTemplate: "b"},
+						{ID: "fast", Type: taskengine.PromptToString, PromptTemplate: "c"},
+					},
+				},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+
+	start := time.Now()
+	result, err := env.ExecEnv(context.Background(), chain, "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"fast": "c"}, result)
+	require.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestSimpleEnv_Parallel_Quorum(t *testing.T) {
+	exec := &branchExecutor{fail: map[string]bool{"b": true, "c": true}}
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec)
+	require.NoError(t, err)
+
+	branches := []taskengine.ChainTask{
+		{ID: "a", Type: taskengine.PromptToString, PromptTemplate: "a"},
+		{ID: "b", Type: taskengine.PromptToString, PromptTemplate: "b"},
+		{ID: "c", Type: taskengine.PromptToString, PromptTemplate: "c"},
+	}
+	chain := &taskengine.ChainDefinition{
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:       "vote",
+				Type:     taskengine.Parallel,
+				Parallel: &taskengine.ParallelConfig{Join: taskengine.JoinQuorum, Quorum: 2, Branches: branches},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+
+	_, err = env.ExecEnv(context.Background(), chain, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "join quorum not satisfied")
+
+	exec.fail = map[string]bool{"c": true}
+	result, err := env.ExecEnv(context.Background(), chain, "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"a": "a", "b": "b"}, result)
+}
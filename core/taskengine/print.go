@@ -0,0 +1,68 @@
+package taskengine
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PrintEntry is a message rendered from the Print template of a task.
+type PrintEntry struct {
+	TaskID  string    `json:"taskId"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// PrintEvent is a PrintEntry of a particular execution.
+type PrintEvent struct {
+	ExecutionID string `json:"executionId"`
+	ChainID     string `json:"chainId"`
+	PrintEntry
+}
+
+// Printer receives the messages printed by executions, e.g. to publish them to clients.
+type Printer interface {
+	Print(ctx context.Context, event *PrintEvent) error
+}
+
+// WithPrinter makes the environment hand every printed message to printer.
+// A failing printer is reported to the tracker but does not fail the task.
+func WithPrinter(printer Printer) EnvOption {
+	return func(env *SimpleEnv) {
+		env.printer = printer
+	}
+}
+
+type printObserverKey struct{}
+
+// WithPrintObserver returns a context whose executions call observe for every printed message,
+// including the messages of sub-chains.
+func WithPrintObserver(ctx context.Context, observe func(entry PrintEntry)) context.Context {
+	return context.WithValue(ctx, printObserverKey{}, observe)
+}
+
+// print renders the Print template of task, appends the message to the log of the execution
+// and hands it to the observer of ctx and the Printer of the environment.
+func (exe SimpleEnv) print(ctx context.Context, state *ExecutionState, task *ChainTask, vars map[string]any) error {
+	message, err := exe.renderTemplate(ctx, task, task.Print, vars)
+	if err != nil {
+		return fmt.Errorf("task %s: print template error: %v", task.ID, err)
+	}
+	message = exe.mask.maskString(message)
+	reportErr, reportPrint, endPrint := exe.tracker.Start(ctx, "print", task.ID)
+	defer endPrint()
+	reportPrint(task.ID, message)
+
+	entry := PrintEntry{TaskID: task.ID, Message: message, Time: time.Now().UTC()}
+	state.Log = append(state.Log, entry)
+	if observe, ok := ctx.Value(printObserverKey{}).(func(entry PrintEntry)); ok && observe != nil {
+		observe(entry)
+	}
+	if exe.printer != nil {
+		event := &PrintEvent{ExecutionID: state.ID, ChainID: state.ChainID, PrintEntry: entry}
+		if err := exe.printer.Print(ctx, event); err != nil {
+			reportErr(fmt.Errorf("failed to print: %w", err))
+		}
+	}
+	return nil
+}
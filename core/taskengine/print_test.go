@@ -0,0 +1,90 @@
+package taskengine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPrinter struct {
+	events []*taskengine.PrintEvent
+	err    error
+}
+
+func (p *recordingPrinter) Print(_ context.Context, event *taskengine.PrintEvent) error {
+	p.events = append(p.events, event)
+	return p.err
+}
+
+func printChain() *taskengine.ChainDefinition {
+	return &taskengine.ChainDefinition{
+		ID: "greeting",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "greet",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "hello {{ .input }}",
+				Print:          "greeted {{ .input }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "farewell"}},
+				},
+			},
+			{
+				ID:             "farewell",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "bye {{ .input }}",
+				Print:          "said: {{ .farewell }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+}
+
+func TestSimpleEnv_PrintLog(t *testing.T) {
+	printer := &recordingPrinter{}
+	checkpointer := &memoryCheckpointer{}
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, &recordingExecutor{},
+		taskengine.WithPrinter(printer),
+		taskengine.WithCheckpointer(checkpointer),
+	)
+	require.NoError(t, err)
+
+	var observed []taskengine.PrintEntry
+	ctx := taskengine.WithExecutionID(context.Background(), "exec-1")
+	ctx = taskengine.WithPrintObserver(ctx, func(entry taskengine.PrintEntry) {
+		observed = append(observed, entry)
+	})
+	_, err = env.ExecEnv(ctx, printChain(), "world")
+	require.NoError(t, err)
+
+	var messages []string
+	for _, entry := range observed {
+		messages = append(messages, entry.TaskID+": "+entry.Message)
+		require.False(t, entry.Time.IsZero())
+	}
+	require.Equal(t, []string{"greet: greeted world", "farewell: said: bye world"}, messages)
+
+	last := checkpointer.checkpoints[len(checkpointer.checkpoints)-1]
+	require.Equal(t, observed, last.Log)
+
+	require.Len(t, printer.events, 2)
+	require.Equal(t, "exec-1", printer.events[0].ExecutionID)
+	require.Equal(t, "greeting", printer.events[0].ChainID)
+	require.Equal(t, observed[1], printer.events[1].PrintEntry)
+}
+
+func TestSimpleEnv_PrinterFailure(t *testing.T) {
+	printer := &recordingPrinter{err: errors.New("bus down")}
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, &recordingExecutor{}, taskengine.WithPrinter(printer))
+	require.NoError(t, err)
+
+	output, err := env.ExecEnv(context.Background(), printChain(), "world")
+	require.NoError(t, err, "a failing printer does not fail the chain")
+	require.Equal(t, "bye world", output)
+	require.Len(t, printer.events, 2)
+}
@@ -0,0 +1,200 @@
+package taskengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorClass groups task errors for retry policies.
+type ErrorClass string
+
+const (
+	// ErrorTransport is a failure to reach a model or hook, or an error reported by it.
+	ErrorTransport ErrorClass = "transport"
+
+	// ErrorParse is a model response that could not be parsed into the output of the task.
+	ErrorParse ErrorClass = "parse"
+
+	// ErrorTimeout is an attempt that exceeded the timeout of its task.
+	ErrorTimeout ErrorClass = "timeout"
+)
+
+var errorClasses = map[ErrorClass]struct{}{
+	ErrorTransport: {},
+	ErrorParse:     {},
+	ErrorTimeout:   {},
+}
+
+// defaultBackoffMultiplier is used by retry policies that do not set Multiplier.
+const defaultBackoffMultiplier = 2
+
+// classifiedError marks an error with its class without changing its message.
+type classifiedError struct {
+	class ErrorClass
+	err   error
+}
+
+func (e *classifiedError) Error() string { return e.err.Error() }
+
+func (e *classifiedError) Unwrap() error { return e.err }
+
+func classify(class ErrorClass, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{class: class, err: err}
+}
+
+// ClassifyError returns the class of a task error, or "" if it belongs to none.
+// Exceeded deadlines are timeouts, even if they surfaced as transport errors.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorTimeout
+	}
+	var classified *classifiedError
+	if errors.As(err, &classified) {
+		return classified.class
+	}
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return ErrorParse
+	}
+	return ""
+}
+
+// retryPolicy returns the retry policy of task, falling back to the one of chain.
+func retryPolicy(chain *ChainDefinition, task *ChainTask) *RetryPolicy {
+	if task.Retry != nil {
+		return task.Retry
+	}
+	return chain.Retry
+}
+
+// maxRetries returns how many times task may be retried.
+func (p *RetryPolicy) maxRetries(task *ChainTask) int {
+	if task.RetryOnError > 0 || p == nil {
+		return max(task.RetryOnError, 0)
+	}
+	return max(p.MaxRetries, 0)
+}
+
+// retries reports whether failures of class are retried.
+func (p *RetryPolicy) retries(class ErrorClass) bool {
+	if p == nil || len(p.RetryOn) == 0 {
+		return true
+	}
+	return slices.Contains(p.RetryOn, class)
+}
+
+// delay returns how long to wait before the given retry, counting from 1.
+func (p *RetryPolicy) delay(retry int) (time.Duration, error) {
+	if p == nil || p.Backoff == "" {
+		return 0, nil
+	}
+	backoff, err := time.ParseDuration(p.Backoff)
+	if err != nil {
+		return 0, fmt.Errorf("invalid retry backoff: %v", err)
+	}
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = defaultBackoffMultiplier
+	}
+	delay := float64(backoff) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxBackoff != "" {
+		maxBackoff, err := time.ParseDuration(p.MaxBackoff)
+		if err != nil {
+			return 0, fmt.Errorf("invalid retry max_backoff: %v", err)
+		}
+		delay = min(delay, float64(maxBackoff))
+	}
+	if p.Jitter > 0 {
+		delay -= delay * min(p.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(delay), nil
+}
+
+// attempt returns the task and prompt of the given retry, counting from 1,
+// after an attempt failed with taskErr.
+func (p *RetryPolicy) attempt(task *ChainTask, prompt string, retry int, taskErr error) (*ChainTask, string) {
+	if p == nil {
+		return task, prompt
+	}
+	if len(p.FallbackModels) > 0 {
+		fallback := *task
+		fallback.PreferredModels = []string{p.FallbackModels[min(retry, len(p.FallbackModels))-1]}
+		task = &fallback
+	}
+	if p.CorrectivePrompt && ClassifyError(taskErr) == ErrorParse {
+		prompt = correctivePrompt(task, prompt, taskErr)
+	}
+	return task, prompt
+}
+
+// correctivePrompt asks the model to answer prompt again after its previous answer failed to parse.
+func correctivePrompt(task *ChainTask, prompt string, taskErr error) string {
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\nYour previous answer was invalid: ")
+	b.WriteString(taskErr.Error())
+	b.WriteString("\n")
+	if format := answerFormat(task); format != "" {
+		b.WriteString("Respond with " + format + " only.")
+	} else {
+		b.WriteString("Respond in the requested format only.")
+	}
+	return b.String()
+}
+
+// answerFormat describes the response expected by the type of task.
+func answerFormat(task *ChainTask) string {
+	switch task.Type {
+	case PromptToNumber:
+		return "a whole number"
+	case PromptToScore:
+		return "a number"
+	case PromptToRange:
+		return "a number or a range like 6-8"
+	case PromptToJSON:
+		return "valid JSON"
+	case PromptToCondition:
+		keys := make([]string, 0, len(task.ConditionMapping))
+		for key := range task.ConditionMapping {
+			keys = append(keys, strconv.Quote(key))
+		}
+		sort.Strings(keys)
+		return "one of " + strings.Join(keys, ", ")
+	}
+	return ""
+}
+
+// backoff waits before the given retry of task, counting from 1.
+// It returns early with the error of ctx if ctx ends first.
+func (exe SimpleEnv) backoff(ctx context.Context, policy *RetryPolicy, task *ChainTask, retry int, class ErrorClass) error {
+	delay, err := policy.delay(retry)
+	if err != nil || delay <= 0 {
+		return err
+	}
+	_, _, end := exe.tracker.Start(ctx, "retry_backoff", task.ID, "retry", retry, "delay", delay.String(), "error_class", class)
+	defer end()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,185 @@
+package taskengine_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/contenox/contenox/core/llmrepo"
+	"github.com/contenox/contenox/core/modelprovider"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func retryEnv(t *testing.T, responses map[string][]string) (taskengine.EnvExecutor, map[string]*modelprovider.MockProvider, *recordingTracker) {
+	providers := map[string]*modelprovider.MockProvider{}
+	var all []modelprovider.Provider
+	for _, name := range []string{"tasks-model", "large-model"} {
+		providers[name] = &modelprovider.MockProvider{
+			Name:            name,
+			CanPromptFlag:   true,
+			ContextLength:   2048,
+			ID:              uuid.NewString(),
+			Backends:        []string{"backend-" + name},
+			PromptResponses: responses[name],
+		}
+		all = append(all, providers[name])
+	}
+	allModels := func(context.Context) modelprovider.RuntimeState {
+		return func(context.Context, string) ([]modelprovider.Provider, error) {
+			return all, nil
+		}
+	}
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: providers["tasks-model"]}, taskengine.NewMockHookRegistry(),
+		taskengine.WithModelRuntime(allModels),
+	)
+	require.NoError(t, err)
+	tracker := &recordingTracker{}
+	env, err := taskengine.NewEnv(context.Background(), tracker, exec)
+	require.NoError(t, err)
+	return env, providers, tracker
+}
+
+func countChain(retry *taskengine.RetryPolicy) *taskengine.ChainDefinition {
+	return &taskengine.ChainDefinition{
+		ID:    "count",
+		Retry: retry,
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "count",
+				Type:           taskengine.PromptToNumber,
+				PromptTemplate: "How many items are in {{ .input }}?",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+}
+
+func TestSimpleEnv_RetryPolicy(t *testing.T) {
+	env, providers, tracker := retryEnv(t, map[string][]string{
+		"tasks-model": {"many"},
+		"large-model": {"seven", "7"},
+	})
+	chain := countChain(&taskengine.RetryPolicy{
+		MaxRetries:       3,
+		Backoff:          "1ms",
+		Jitter:           0.5,
+		RetryOn:          []taskengine.ErrorClass{taskengine.ErrorParse},
+		FallbackModels:   []string{"large-model"},
+		CorrectivePrompt: true,
+	})
+
+	output, err := env.ExecEnv(context.Background(), chain, "the basket")
+	require.NoError(t, err)
+	require.Equal(t, 7, output)
+
+	require.Equal(t, []string{"How many items are in the basket?"}, providers["tasks-model"].Prompts)
+	require.Len(t, providers["large-model"].Prompts, 2)
+	require.Equal(t, "How many items are in the basket?\n\nYour previous answer was invalid: strconv.Atoi: parsing \"many\": invalid syntax\nRespond with a whole number only.",
+		providers["large-model"].Prompts[0])
+	require.Contains(t, providers["large-model"].Prompts[1], `parsing "seven"`)
+
+	var backoffs []any
+	for _, op := range tracker.operations {
+		if op.operation == "retry_backoff" {
+			backoffs = append(backoffs, op.args[1])
+		}
+	}
+	require.Equal(t, []any{1, 2}, backoffs)
+}
+
+func TestSimpleEnv_RetryPolicyErrorClasses(t *testing.T) {
+	env, providers, _ := retryEnv(t, map[string][]string{"tasks-model": {"many", "7"}})
+	chain := countChain(&taskengine.RetryPolicy{
+		MaxRetries: 3,
+		RetryOn:    []taskengine.ErrorClass{taskengine.ErrorTransport, taskengine.ErrorTimeout},
+	})
+
+	_, err := env.ExecEnv(context.Background(), chain, "the basket")
+	require.ErrorContains(t, err, "task count failed after 0 retries")
+	require.Len(t, providers["tasks-model"].Prompts, 1, "parse errors are not retried")
+
+	// The policy of the task replaces the one of the chain, RetryOnError sets the number of retries.
+	chain.Tasks[0].RetryOnError = 1
+	chain.Tasks[0].Retry = &taskengine.RetryPolicy{}
+	output, err := env.ExecEnv(context.Background(), chain, "the basket")
+	require.NoError(t, err)
+	require.Equal(t, 7, output)
+}
+
+func TestSimpleEnv_RetryPolicyOfBranches(t *testing.T) {
+	env, providers, tracker := retryEnv(t, map[string][]string{
+		"tasks-model": {"many"},
+		"large-model": {"7"},
+	})
+	count := countChain(nil).Tasks[0]
+	count.Transition = taskengine.Transition{}
+	chain := &taskengine.ChainDefinition{
+		ID: "fanout",
+		Retry: &taskengine.RetryPolicy{
+			MaxRetries:       1,
+			Backoff:          "1ms",
+			FallbackModels:   []string{"large-model"},
+			CorrectivePrompt: true,
+		},
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:       "fanout",
+				Type:     taskengine.Parallel,
+				Parallel: &taskengine.ParallelConfig{Branches: []taskengine.ChainTask{count}},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+
+	output, err := env.ExecEnv(context.Background(), chain, "the basket")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"count": 7}, output)
+	require.Len(t, providers["tasks-model"].Prompts, 1)
+	require.Len(t, providers["large-model"].Prompts, 1)
+	require.Contains(t, providers["large-model"].Prompts[0], "Respond with a whole number only.")
+	backoffs := 0
+	for _, op := range tracker.operations {
+		if op.operation == "retry_backoff" {
+			backoffs++
+		}
+	}
+	require.Equal(t, 1, backoffs)
+
+	// The policy of the branch replaces the one of the chain, the Parallel task itself is not retried.
+	count.Retry = &taskengine.RetryPolicy{MaxRetries: 1, RetryOn: []taskengine.ErrorClass{taskengine.ErrorTransport}}
+	chain.Tasks[0].Parallel.Branches[0] = count
+	chain.Tasks[0].Retry = &taskengine.RetryPolicy{}
+	_, err = env.ExecEnv(context.Background(), chain, "the basket")
+	require.ErrorContains(t, err, "branch count")
+	require.Len(t, providers["tasks-model"].Prompts, 2, "parse errors are not retried")
+}
+
+func TestSimpleEnv_RetryBackoffStopsAtChainTimeout(t *testing.T) {
+	env, _, _ := retryEnv(t, map[string][]string{"tasks-model": {"many"}})
+	chain := countChain(&taskengine.RetryPolicy{MaxRetries: 1, Backoff: "1h"})
+	chain.Timeout = "50ms"
+
+	start := time.Now()
+	_, err := env.ExecEnv(context.Background(), chain, "the basket")
+	var limitErr *taskengine.LoopLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, taskengine.LimitTimeout, limitErr.Limit)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestClassifyError(t *testing.T) {
+	_, numErr := strconv.Atoi("many")
+	require.Equal(t, taskengine.ErrorParse, taskengine.ClassifyError(fmt.Errorf("wrapped: %w", numErr)))
+	require.Equal(t, taskengine.ErrorTimeout, taskengine.ClassifyError(fmt.Errorf("prompt execution failed: %w", context.DeadlineExceeded)))
+	require.Equal(t, taskengine.ErrorClass(""), taskengine.ClassifyError(errors.New("unknown")))
+	require.Equal(t, taskengine.ErrorClass(""), taskengine.ClassifyError(nil))
+}
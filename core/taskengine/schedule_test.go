@@ -0,0 +1,61 @@
+package taskengine_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		timeZone string
+		after    string
+		next     string
+	}{
+		{"every minute", "* * * * *", "", "2025-03-10T10:15:30Z", "2025-03-10T10:16:00Z"},
+		{"daily", "@daily", "", "2025-03-10T10:15:00Z", "2025-03-11T00:00:00Z"},
+		{"step", "*/15 9-17 * * *", "", "2025-03-10T17:50:00Z", "2025-03-11T09:00:00Z"},
+		{"weekday names", "30 8 * * mon-fri", "", "2025-03-08T12:00:00Z", "2025-03-10T08:30:00Z"},
+		{"sunday as 7", "0 12 * * 7", "", "2025-03-10T00:00:00Z", "2025-03-16T12:00:00Z"},
+		{"day of month or week", "0 0 1 * fri", "", "2025-03-10T00:00:00Z", "2025-03-14T00:00:00Z"},
+		{"month names", "0 6 1 jan,jul *", "", "2025-03-10T00:00:00Z", "2025-07-01T06:00:00Z"},
+		{"time zone", "0 9 * * *", "Europe/Berlin", "2025-03-10T00:00:00Z", "2025-03-10T08:00:00Z"},
+		{"skipped by daylight saving", "30 2 * * *", "Europe/Berlin", "2025-03-29T12:00:00Z", "2025-03-31T00:30:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := taskengine.ParseSchedule(tt.expr, tt.timeZone)
+			require.NoError(t, err)
+			after, err := time.Parse(time.RFC3339, tt.after)
+			require.NoError(t, err)
+			expected, err := time.Parse(time.RFC3339, tt.next)
+			require.NoError(t, err)
+			require.True(t, expected.Equal(schedule.Next(after)), "got %s", schedule.Next(after).UTC())
+		})
+	}
+}
+
+func TestParseSchedule_Between(t *testing.T) {
+	schedule, err := taskengine.ParseSchedule("0 */6 * * *", "")
+	require.NoError(t, err)
+	from := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	times := schedule.Between(from, from.Add(24*time.Hour), 10)
+	require.Len(t, times, 4)
+	require.True(t, times[0].Equal(from.Add(6*time.Hour)))
+	require.True(t, times[3].Equal(from.Add(24*time.Hour)))
+	require.Len(t, schedule.Between(from, from.Add(24*time.Hour), 2), 2)
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		_, err := taskengine.ParseSchedule(expr, "")
+		require.Error(t, err, expr)
+	}
+	_, err := taskengine.ParseSchedule("* * * * *", "Mars/Olympus")
+	require.ErrorContains(t, err, "invalid time zone")
+}
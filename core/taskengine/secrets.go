@@ -0,0 +1,246 @@
+package taskengine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/contenox/contenox/core/serverops"
+)
+
+// SecretResolver returns the plaintext values of stored secrets.
+// Implementations check that the caller in ctx may read the secret.
+type SecretResolver interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// WithSecrets lets hook args reference stored secrets with {{ secret "name" }}.
+// Without a resolver such references fail the task.
+func WithSecrets(secrets SecretResolver) EnvOption {
+	return func(env *SimpleEnv) {
+		env.secrets = secrets
+	}
+}
+
+type secretResolverKey struct{}
+
+// WithSecretResolver returns a context for executions that resolve secrets with secrets instead
+// of the resolver of the environment, e.g. executions started by the server rather than by a user.
+// Dry runs never resolve secrets.
+func WithSecretResolver(ctx context.Context, secrets SecretResolver) context.Context {
+	return context.WithValue(ctx, secretResolverKey{}, secrets)
+}
+
+// maskedSecret replaces secret values in everything reported to the tracker.
+const maskedSecret = "********"
+
+// hookArgFuncs are the template functions available in hook args.
+// secret is only called while the args are rendered, never while they are parsed.
+func hookArgFuncs(secret func(name string) (string, error)) template.FuncMap {
+	return template.FuncMap{"secret": secret}
+}
+
+// renderHookArgs renders the args of a hook call with the execution variables, resolving secret references.
+// Resolved secrets are registered with the secret mask of the execution.
+func (exe SimpleEnv) renderHookArgs(ctx context.Context, task *ChainTask, args map[string]string, vars map[string]any) (map[string]string, error) {
+	secret := func(name string) (string, error) {
+		return exe.resolveSecret(ctx, name)
+	}
+	rendered := make(map[string]string, len(args))
+	for name, text := range args {
+		tmpl, err := parseTemplate(text, true)
+		if err != nil {
+			return nil, fmt.Errorf("hook arg %s: template error: %v", name, err)
+		}
+		value, err := exe.executeTemplate(ctx, task, tmpl, hookArgFuncs(secret), vars)
+		if err != nil {
+			return nil, fmt.Errorf("hook arg %s: template error: %v", name, err)
+		}
+		rendered[name] = value
+	}
+	return rendered, nil
+}
+
+// resolveSecret returns the value of the secret name and registers it with the secret mask.
+func (exe SimpleEnv) resolveSecret(ctx context.Context, name string) (string, error) {
+	if exe.secrets == nil {
+		return "", fmt.Errorf("secrets are not available in this environment")
+	}
+	value, err := exe.secrets.GetSecret(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %q: %w", name, err)
+	}
+	exe.mask.add(value)
+	return value, nil
+}
+
+// secretMask collects the secrets resolved during an execution.
+type secretMask struct {
+	mu     sync.Mutex
+	values []string
+}
+
+func (m *secretMask) add(value string) {
+	if m == nil || value == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.values {
+		if existing == value {
+			return
+		}
+	}
+	m.values = append(m.values, value)
+	// Longer secrets first, so a secret containing another one is masked as a whole.
+	sort.Slice(m.values, func(i, j int) bool {
+		return len(m.values[i]) > len(m.values[j])
+	})
+}
+
+func (m *secretMask) maskString(s string) string {
+	if m == nil {
+		return s
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, value := range m.values {
+		s = strings.ReplaceAll(s, value, maskedSecret)
+	}
+	return s
+}
+
+func (m *secretMask) contains(s string) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, value := range m.values {
+		if strings.Contains(s, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskValue returns value with every known secret replaced.
+// Values of other types than strings, string maps and lists are masked through their JSON encoding.
+func (m *secretMask) maskValue(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return m.maskString(v)
+	case error:
+		return m.maskError(v)
+	case map[string]string:
+		masked := make(map[string]string, len(v))
+		for key, item := range v {
+			masked[key] = m.maskString(item)
+		}
+		return masked
+	case map[string]any:
+		masked := make(map[string]any, len(v))
+		for key, item := range v {
+			masked[key] = m.maskValue(item)
+		}
+		return masked
+	case []any:
+		masked := make([]any, len(v))
+		for i, item := range v {
+			masked[i] = m.maskValue(item)
+		}
+		return masked
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil || !m.contains(string(encoded)) {
+		return value
+	}
+	var decoded any
+	if err := json.Unmarshal([]byte(m.maskString(string(encoded))), &decoded); err != nil {
+		return maskedSecret
+	}
+	return decoded
+}
+
+// maskError returns err with every known secret replaced in its message.
+// The masked error still wraps err, so errors.Is and errors.As see through it.
+func (m *secretMask) maskError(err error) error {
+	if err == nil || !m.contains(err.Error()) {
+		return err
+	}
+	return &maskedError{err: err, message: m.maskString(err.Error())}
+}
+
+type maskedError struct {
+	err     error
+	message string
+}
+
+func (e *maskedError) Error() string { return e.message }
+
+func (e *maskedError) Unwrap() error { return e.err }
+
+// maskVars returns a copy of vars with every known secret replaced.
+func (m *secretMask) maskVars(vars map[string]any) map[string]any {
+	return m.maskValue(vars).(map[string]any)
+}
+
+// maskState returns the state to persist for state: a copy with every known secret replaced
+// in its variables, output, error, log and conversation, or state itself if no secret was resolved.
+func (m *secretMask) maskState(state *ExecutionState) *ExecutionState {
+	if m == nil {
+		return state
+	}
+	m.mu.Lock()
+	empty := len(m.values) == 0
+	m.mu.Unlock()
+	if empty {
+		return state
+	}
+	masked := *state
+	masked.Vars = m.maskVars(state.Vars)
+	masked.Output = m.maskValue(state.Output)
+	masked.Error = m.maskString(state.Error)
+	masked.Log = make([]PrintEntry, len(state.Log))
+	for i, entry := range state.Log {
+		entry.Message = m.maskString(entry.Message)
+		masked.Log[i] = entry
+	}
+	if state.Conversation != nil {
+		masked.Conversation = make([]serverops.Message, len(state.Conversation))
+		for i, msg := range state.Conversation {
+			msg.Content = m.maskString(msg.Content)
+			masked.Conversation[i] = msg
+		}
+	}
+	return &masked
+}
+
+// maskingTracker masks the secrets of an execution in every reported operation.
+type maskingTracker struct {
+	tracker serverops.ActivityTracker
+	mask    *secretMask
+}
+
+func (t maskingTracker) Start(ctx context.Context, operation string, subject string, kvArgs ...any) (func(error), func(string, any), func()) {
+	masked := make([]any, len(kvArgs))
+	for i, arg := range kvArgs {
+		masked[i] = t.mask.maskValue(arg)
+	}
+	reportErr, reportChange, end := t.tracker.Start(ctx, operation, subject, masked...)
+	return func(err error) {
+			if err != nil && t.mask.contains(err.Error()) {
+				err = errors.New(t.mask.maskString(err.Error()))
+			}
+			reportErr(err)
+		}, func(id string, data any) {
+			reportChange(id, t.mask.maskValue(data))
+		}, end
+}
@@ -0,0 +1,158 @@
+package taskengine_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/contenox/contenox/core/llmrepo"
+	"github.com/contenox/contenox/core/modelprovider"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/stretchr/testify/require"
+)
+
+type mapSecretResolver map[string]string
+
+func (m mapSecretResolver) GetSecret(_ context.Context, name string) (string, error) {
+	value, ok := m[name]
+	if !ok {
+		return "", libdb.ErrNotFound
+	}
+	return value, nil
+}
+
+func secretHookChain() *taskengine.ChainDefinition {
+	return &taskengine.ChainDefinition{
+		ID: "crm",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:   "sync",
+				Type: taskengine.Hook,
+				Hook: &taskengine.HookCall{
+					Type: "mock",
+					Args: map[string]string{
+						"headers": `{"Authorization": "Bearer {{ secret "crm_token" }}"}`,
+						"ticket":  "{{ .input }}",
+					},
+				},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+}
+
+func TestSimpleEnv_HookArgsResolveSecrets(t *testing.T) {
+	hooks := taskengine.NewMockHookRegistry()
+	hooks.ResponseMap["mock"] = map[string]any{"echo": "token s3cr3t accepted"}
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: &modelprovider.MockProvider{}}, hooks)
+	require.NoError(t, err)
+	tracker := &recordingTracker{}
+	env, err := taskengine.NewEnv(context.Background(), tracker, exec,
+		taskengine.WithSecrets(mapSecretResolver{"crm_token": "s3cr3t"}),
+	)
+	require.NoError(t, err)
+
+	output, err := env.ExecEnv(context.Background(), secretHookChain(), "T-42")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"echo": "token ******** accepted"}, output)
+	require.Len(t, hooks.Calls, 1)
+	require.Equal(t, map[string]string{
+		"headers": `{"Authorization": "Bearer s3cr3t"}`,
+		"ticket":  "T-42",
+	}, hooks.Calls[0].Args)
+
+	var traced []any
+	for _, op := range tracker.operations {
+		traced = append(traced, op.args, op.change)
+	}
+	require.NotContains(t, fmt.Sprint(traced...), "s3cr3t")
+	require.Contains(t, fmt.Sprint(traced...), "token ******** accepted")
+}
+
+// echoingHookRepo fails every hook with an error quoting its args, like a remote
+// service rejecting a request.
+type echoingHookRepo struct {
+	taskengine.MockHookRepo
+}
+
+func (e *echoingHookRepo) Exec(_ context.Context, args *taskengine.HookCall) (int, any, error) {
+	return taskengine.StatusError, nil, fmt.Errorf("crm rejected headers %s", args.Args["headers"])
+}
+
+func TestSimpleEnv_SecretsAreMaskedInErrorsAndCheckpoints(t *testing.T) {
+	checkpointer := &memoryCheckpointer{}
+	var steps []taskengine.ExecutionStep
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: &modelprovider.MockProvider{}}, &echoingHookRepo{})
+	require.NoError(t, err)
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, exec,
+		taskengine.WithSecrets(mapSecretResolver{"crm_token": "s3cr3t"}),
+		taskengine.WithCheckpointer(checkpointer),
+	)
+	require.NoError(t, err)
+
+	_, err = env.ExecEnv(context.Background(), secretHookChain(), "T-42")
+	require.ErrorContains(t, err, `crm rejected headers {"Authorization": "Bearer ********"}`)
+	require.NotContains(t, err.Error(), "s3cr3t")
+	failed := checkpointer.checkpoints[len(checkpointer.checkpoints)-1]
+	require.Equal(t, taskengine.ExecutionFailed, failed.Status)
+	require.NotContains(t, failed.Error, "s3cr3t")
+	require.Contains(t, failed.Error, "Bearer ********")
+
+	hooks := taskengine.NewMockHookRegistry()
+	hooks.ResponseMap["mock"] = "token s3cr3t accepted"
+	exec, err = taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: &modelprovider.MockProvider{}}, hooks)
+	require.NoError(t, err)
+	env, err = taskengine.NewEnv(context.Background(), &recordingTracker{}, exec,
+		taskengine.WithSecrets(mapSecretResolver{"crm_token": "s3cr3t"}),
+		taskengine.WithCheckpointer(checkpointer),
+	)
+	require.NoError(t, err)
+	ctx := taskengine.WithStepObserver(context.Background(), func(step taskengine.ExecutionStep) {
+		steps = append(steps, step)
+	})
+	_, err = env.ExecEnv(ctx, secretHookChain(), "T-42")
+	require.NoError(t, err)
+	completed := checkpointer.checkpoints[len(checkpointer.checkpoints)-1]
+	require.Equal(t, "token ******** accepted", completed.Output)
+	require.Equal(t, "token ******** accepted", completed.Vars["sync"])
+	require.Len(t, steps, 1)
+	require.Equal(t, "token ******** accepted", steps[0].Vars["sync"])
+}
+
+func TestSimpleEnv_HookArgsRequireSecretResolver(t *testing.T) {
+	hooks := taskengine.NewMockHookRegistry()
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: &modelprovider.MockProvider{}}, hooks)
+	require.NoError(t, err)
+
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, exec)
+	require.NoError(t, err)
+	_, err = env.ExecEnv(context.Background(), secretHookChain(), "T-42")
+	require.ErrorContains(t, err, "secrets are not available in this environment")
+
+	env, err = taskengine.NewEnv(context.Background(), &recordingTracker{}, exec,
+		taskengine.WithSecrets(mapSecretResolver{}),
+	)
+	require.NoError(t, err)
+	_, err = env.ExecEnv(context.Background(), secretHookChain(), "T-42")
+	require.ErrorContains(t, err, `failed to resolve secret "crm_token"`)
+	require.Empty(t, hooks.Calls)
+}
+
+func TestSimpleEnv_SecretResolverOfContext(t *testing.T) {
+	hooks := taskengine.NewMockHookRegistry()
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: &modelprovider.MockProvider{}}, hooks)
+	require.NoError(t, err)
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, exec,
+		taskengine.WithSecrets(mapSecretResolver{}),
+	)
+	require.NoError(t, err)
+
+	ctx := taskengine.WithSecretResolver(context.Background(), mapSecretResolver{"crm_token": "s3cr3t"})
+	_, err = env.ExecEnv(ctx, secretHookChain(), "T-42")
+	require.NoError(t, err)
+	require.Len(t, hooks.Calls, 1)
+	require.Equal(t, `{"Authorization": "Bearer s3cr3t"}`, hooks.Calls[0].Args["headers"])
+}
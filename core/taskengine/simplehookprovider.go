@@ -30,4 +30,29 @@ func (m *SimpleHookRepo) Supports(ctx context.Context) ([]string, error) {
 	return supported, nil
 }
 
-var _ HookRepo = (*SimpleHookRepo)(nil)
+// Tools describes every registered hook, using the schema the hook provides if it implements ToolDescriber.
+func (m *SimpleHookRepo) Tools(ctx context.Context) ([]ToolSchema, error) {
+	tools := make([]ToolSchema, 0, len(m.hooks))
+	for name, hook := range m.hooks {
+		schema := defaultToolSchema(name)
+		if describer, ok := hook.(ToolDescriber); ok {
+			described, err := describer.Tools(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe hook %s: %w", name, err)
+			}
+			for _, candidate := range described {
+				if candidate.Name == name {
+					schema = candidate
+				}
+			}
+		}
+		tools = append(tools, schema)
+	}
+	sortTools(tools)
+	return tools, nil
+}
+
+var (
+	_ HookRepo      = (*SimpleHookRepo)(nil)
+	_ ToolDescriber = (*SimpleHookRepo)(nil)
+)
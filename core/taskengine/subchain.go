@@ -0,0 +1,110 @@
+package taskengine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultMaxChainDepth limits how deeply SubChain tasks may nest,
+// which also stops chains that call themselves.
+const DefaultMaxChainDepth = 5
+
+// ChainResolver loads stored chains for SubChain tasks.
+type ChainResolver interface {
+	GetChain(ctx context.Context, id string) (*ChainDefinition, error)
+}
+
+// WithChainResolver enables SubChain tasks, which fail without a resolver.
+func WithChainResolver(resolver ChainResolver) EnvOption {
+	return func(env *SimpleEnv) {
+		env.chains = resolver
+	}
+}
+
+type chainDepthKey struct{}
+
+func chainDepth(ctx context.Context) int {
+	depth, _ := ctx.Value(chainDepthKey{}).(int)
+	return depth
+}
+
+// subChain runs the chain referenced by a SubChain task and returns its final output.
+//
+// The sub-chain runs within the calling execution: it shares its context, so cancellation
+// and the execution ID propagate and its steps are tracked as part of the caller.
+// Its steps count against the caller's MaxSteps, and its steps, visits and print log
+// are merged into the caller's state once it ends.
+// It is not checkpointed on its own, a resumed caller runs the whole sub-chain again.
+func (exe SimpleEnv) subChain(ctx context.Context, task *ChainTask, renderedPrompt string, vars map[string]any) (any, string, error) {
+	call := task.SubChain
+	if call == nil || call.ChainID == "" {
+		return nil, "", fmt.Errorf("sub-chain task missing chain id")
+	}
+	if exe.chains == nil {
+		return nil, "", fmt.Errorf("sub-chain tasks are not supported in this environment")
+	}
+	depth := chainDepth(ctx) + 1
+	if depth > DefaultMaxChainDepth {
+		return nil, "", fmt.Errorf("sub-chain depth limit %d exceeded calling chain %s", DefaultMaxChainDepth, call.ChainID)
+	}
+	chain, err := exe.chains.GetChain(ctx, call.ChainID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load chain %s: %w", call.ChainID, err)
+	}
+	if len(chain.Tasks) == 0 {
+		return nil, "", fmt.Errorf("chain %s has no tasks", chain.ID)
+	}
+
+	subVars := map[string]any{
+		"input": renderedPrompt,
+	}
+	for name, tmpl := range call.Vars {
+		value, err := exe.renderTemplate(ctx, task, tmpl, vars)
+		if err != nil {
+			return nil, "", fmt.Errorf("sub-chain var %s: template error: %v", name, err)
+		}
+		subVars[name] = value
+	}
+	executionID, _ := ExecutionIDFromContext(ctx)
+	state := &ExecutionState{
+		ID:          executionID,
+		ChainID:     chain.ID,
+		Status:      ExecutionRunning,
+		CurrentTask: chain.Tasks[0].ID,
+		Vars:        subVars,
+		Attempts:    map[string]int{},
+		Visits:      map[string]int{},
+	}
+	scope, hasScope := chainScopeFromContext(ctx)
+	if hasScope {
+		scope.enter(chain, state)
+	}
+
+	_, reportChange, end := exe.tracker.Start(ctx, "sub_chain", task.ID, "chain_id", chain.ID, "depth", depth)
+	defer end()
+
+	// Only the calling execution is persisted and may wait for approvals.
+	sub := exe
+	sub.checkpointer = nil
+	sub.approvals = nil
+	output, err := sub.steps(context.WithValue(ctx, chainDepthKey{}, depth), chain, state)
+	if hasScope {
+		if leaveErr := scope.leave(chain, state); err == nil {
+			err = leaveErr
+		}
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("chain %s: %w", chain.ID, err)
+	}
+	reportChange(task.ID, output)
+
+	if s, ok := output.(string); ok {
+		return output, s, nil
+	}
+	raw, err := json.Marshal(output)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode output of chain %s: %w", chain.ID, err)
+	}
+	return output, string(raw), nil
+}
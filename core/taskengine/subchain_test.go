@@ -0,0 +1,185 @@
+package taskengine_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/contenox/contenox/libs/libdb"
+	"github.com/stretchr/testify/require"
+)
+
+type mapChainResolver map[string]*taskengine.ChainDefinition
+
+func (m mapChainResolver) GetChain(_ context.Context, id string) (*taskengine.ChainDefinition, error) {
+	chain, ok := m[id]
+	if !ok {
+		return nil, libdb.ErrNotFound
+	}
+	return chain, nil
+}
+
+func subChainTask(id, chainID string) taskengine.ChainTask {
+	return taskengine.ChainTask{
+		ID:             id,
+		Type:           taskengine.SubChain,
+		PromptTemplate: "{{ .input }}",
+		SubChain: &taskengine.SubChainCall{
+			ChainID: chainID,
+			Vars:    map[string]string{"lang": "de-{{ .input }}"},
+		},
+		Transition: taskengine.Transition{
+			Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+		},
+	}
+}
+
+func TestSimpleEnv_SubChain(t *testing.T) {
+	classify := &taskengine.ChainDefinition{
+		ID: "classify",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "label",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "label {{ .input }} in {{ .lang }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+	exec := &recordingExecutor{}
+	tracker := &recordingTracker{}
+	env, err := taskengine.NewEnv(context.Background(), tracker, exec,
+		taskengine.WithChainResolver(mapChainResolver{"classify": classify}),
+	)
+	require.NoError(t, err)
+
+	chain := &taskengine.ChainDefinition{
+		ID:    "support",
+		Tasks: []taskengine.ChainTask{subChainTask("route", "classify")},
+	}
+	output, err := env.ExecEnv(context.Background(), chain, "ticket")
+	require.NoError(t, err)
+	require.Equal(t, "label ticket in de-ticket", output)
+	require.Equal(t, []string{"label"}, exec.executed)
+
+	var operations []string
+	for _, op := range tracker.operations {
+		operations = append(operations, op.operation+":"+op.subject)
+	}
+	require.Contains(t, operations, "sub_chain:route")
+	require.Contains(t, operations, "task_attempt:label")
+}
+
+func TestSimpleEnv_SubChainDepthLimit(t *testing.T) {
+	recursive := &taskengine.ChainDefinition{
+		ID:    "recursive",
+		Tasks: []taskengine.ChainTask{subChainTask("again", "recursive")},
+	}
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, &recordingExecutor{},
+		taskengine.WithChainResolver(mapChainResolver{"recursive": recursive}),
+	)
+	require.NoError(t, err)
+
+	_, err = env.ExecEnv(context.Background(), recursive, "x")
+	require.ErrorContains(t, err, "sub-chain depth limit 5 exceeded calling chain recursive")
+}
+
+func TestSimpleEnv_SubChainPropagatesCancellation(t *testing.T) {
+	blocking := &taskengine.ChainDefinition{
+		ID: "blocking",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:   "wait",
+				Type: taskengine.PromptToString,
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, blockingExecutor{},
+		taskengine.WithChainResolver(mapChainResolver{"blocking": blocking}),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chain := &taskengine.ChainDefinition{
+		ID:    "caller",
+		Tasks: []taskengine.ChainTask{subChainTask("call", "blocking")},
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	_, err = env.ExecEnv(ctx, chain, "x")
+	require.ErrorContains(t, err, "chain blocking")
+	require.ErrorContains(t, err, context.Canceled.Error())
+}
+
+func TestSimpleEnv_SubChainSharesCallerState(t *testing.T) {
+	classify := &taskengine.ChainDefinition{
+		ID: "classify",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "label",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "label {{ .input }}",
+				Print:          "labelled {{ .input }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+	checkpointer := &memoryCheckpointer{}
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, &recordingExecutor{},
+		taskengine.WithChainResolver(mapChainResolver{"classify": classify}),
+		taskengine.WithCheckpointer(checkpointer),
+	)
+	require.NoError(t, err)
+
+	chain := &taskengine.ChainDefinition{
+		ID:    "support",
+		Tasks: []taskengine.ChainTask{subChainTask("route", "classify")},
+	}
+	_, err = env.ExecEnv(context.Background(), chain, "ticket")
+	require.NoError(t, err)
+
+	final := checkpointer.checkpoints[len(checkpointer.checkpoints)-1]
+	require.Equal(t, []string{"route", "classify/label"}, final.Path)
+	require.Equal(t, 2, final.Steps)
+	require.Len(t, final.Log, 1)
+	require.Equal(t, "labelled ticket", final.Log[0].Message)
+}
+
+func TestSimpleEnv_SubChainCountsTowardsCallerMaxSteps(t *testing.T) {
+	next := func(id string) taskengine.Transition {
+		return taskengine.Transition{Next: []taskengine.ConditionalTransition{{Value: "_default", ID: id}}}
+	}
+	steps := &taskengine.ChainDefinition{
+		ID: "steps",
+		Tasks: []taskengine.ChainTask{
+			{ID: "a", Type: taskengine.PromptToString, PromptTemplate: "a", Transition: next("b")},
+			{ID: "b", Type: taskengine.PromptToString, PromptTemplate: "b", Transition: next("c")},
+			{ID: "c", Type: taskengine.PromptToString, PromptTemplate: "c", Transition: next("end")},
+		},
+	}
+	exec := &recordingExecutor{}
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec,
+		taskengine.WithChainResolver(mapChainResolver{"steps": steps}),
+	)
+	require.NoError(t, err)
+
+	chain := &taskengine.ChainDefinition{
+		ID:       "caller",
+		MaxSteps: 3,
+		Tasks:    []taskengine.ChainTask{subChainTask("call", "steps")},
+	}
+	_, err = env.ExecEnv(context.Background(), chain, "go")
+	require.ErrorContains(t, err, "loop limit max_steps (2) exceeded at task c")
+	require.Equal(t, []string{"a", "b"}, exec.executed)
+}
@@ -13,8 +13,10 @@
 //   - PromptToNumber: parses the prompt result as an integer
 //   - PromptToScore: parses the prompt result as a float
 //   - PromptToRange: parses a numeric range like "3-5"
+//   - PromptToJSON: parses the prompt result as JSON, validated against an optional JSON Schema
 //   - PromptToCondition: resolves a boolean by matching prompt result to a condition map
 //   - Hook: invokes an external system using the HookProvider interface
+//   - Parallel: runs several branch tasks concurrently and joins their outputs
 //
 // Typical use cases:
 //   - Dynamic content generation (e.g. marketing copy, reports)
@@ -1,17 +1,18 @@
 package taskengine
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
-	"text/template"
+	"sync"
 	"time"
 
 	"github.com/contenox/contenox/core/llmresolver"
+	"github.com/contenox/contenox/core/modelprovider"
 	"github.com/contenox/contenox/core/serverops"
+	"github.com/google/uuid"
 )
 
 const StatusSuccess = 1
@@ -23,6 +24,8 @@ const StatusError = 3
 // It handles task transitions, error recovery, retry logic, and output tracking.
 type EnvExecutor interface {
 	ExecEnv(ctx context.Context, chain *ChainDefinition, input string) (any, error)
+	// ResumeEnv continues an execution from a previously checkpointed state.
+	ResumeEnv(ctx context.Context, chain *ChainDefinition, state *ExecutionState) (any, error)
 }
 
 // ErrUnsupportedTaskType is returned when a TaskExecutor does not recognize the task type.
@@ -42,10 +45,23 @@ type HookRegistry interface {
 // SimpleEnv is the default implementation of EnvExecutor.
 //
 // It executes tasks in order, using retry and timeout policies, and tracks execution
-// progress using an ActivityTracker.
+// progress using an ActivityTracker. If a Checkpointer is configured, the execution
+// state is persisted after every step.
 type SimpleEnv struct {
-	exec    TaskExecutor
-	tracker serverops.ActivityTracker
+	exec         TaskExecutor
+	tracker      serverops.ActivityTracker
+	checkpointer Checkpointer
+	approvals    ApprovalGate
+	printer      Printer
+	hooks        HookQueue
+	chains       ChainResolver
+	secrets      SecretResolver
+	mask         *secretMask
+
+	tokenizer      Tokenizer
+	tokenizerModel string
+	templateLimits TemplateLimits
+	script         *dryRunScript
 }
 
 // NewEnv creates a new SimpleEnv with the given tracker and task executor.
@@ -53,21 +69,127 @@ func NewEnv(
 	_ context.Context,
 	tracker serverops.ActivityTracker,
 	exec TaskExecutor,
+	opts ...EnvOption,
 ) (EnvExecutor, error) {
-	return &SimpleEnv{
+	env := &SimpleEnv{
 		exec:    exec,
 		tracker: tracker,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(env)
+	}
+	return env, nil
 }
 
 // ExecEnv executes the given chain with the provided input.
 //
 // It manages the full lifecycle of task execution: rendering prompts, calling the
 // TaskExecutor, handling timeouts, retries, transitions, and collecting final output.
+// The execution ID is taken from the context (see WithExecutionID) or generated.
 func (exe SimpleEnv) ExecEnv(ctx context.Context, chain *ChainDefinition, input string) (any, error) {
-	vars := map[string]any{
-		"input": input,
+	if len(chain.Tasks) == 0 {
+		return nil, fmt.Errorf("chain %s has no tasks", chain.ID)
+	}
+	if script, ok := ctx.Value(dryRunKey{}).(*dryRunScript); ok {
+		dry, err := exe.dryRun(script)
+		if err != nil {
+			return nil, err
+		}
+		exe = dry
+	}
+	id, ok := ExecutionIDFromContext(ctx)
+	if !ok {
+		id = uuid.NewString()
+		ctx = WithExecutionID(ctx, id)
+	}
+	state := &ExecutionState{
+		ID:          id,
+		ChainID:     chain.ID,
+		Status:      ExecutionRunning,
+		CurrentTask: chain.Tasks[0].ID,
+		Vars: map[string]any{
+			"input": input,
+		},
+		Attempts: map[string]int{},
+		Visits:   map[string]int{},
+	}
+	if exe.checkpointer != nil {
+		if err := exe.checkpointer.Begin(ctx, chain, state); err != nil {
+			return nil, fmt.Errorf("failed to persist execution: %w", err)
+		}
+	}
+	return exe.run(ctx, chain, state)
+}
+
+// ResumeEnv continues an interrupted or failed execution from its last checkpoint.
+// Tasks that completed before the checkpoint are not executed again.
+func (exe SimpleEnv) ResumeEnv(ctx context.Context, chain *ChainDefinition, state *ExecutionState) (any, error) {
+	if state.Status == ExecutionCompleted {
+		return state.Output, nil
+	}
+	if state.CurrentTask == "" {
+		return nil, fmt.Errorf("execution %s has no task to resume", state.ID)
+	}
+	if state.Status == ExecutionWaiting && state.Decision == nil {
+		return nil, fmt.Errorf("execution %s is waiting for an approval decision", state.ID)
+	}
+	if state.Vars == nil {
+		state.Vars = map[string]any{}
+	}
+	if state.Attempts == nil {
+		state.Attempts = map[string]int{}
+	}
+	if state.Visits == nil {
+		state.Visits = map[string]int{}
+	}
+	state.Status = ExecutionRunning
+	state.Error = ""
+	return exe.run(WithExecutionID(ctx, state.ID), chain, state)
+}
+
+// run executes the chain starting at state.CurrentTask and records the outcome in state.
+func (exe SimpleEnv) run(ctx context.Context, chain *ChainDefinition, state *ExecutionState) (any, error) {
+	if tracker, ok := trackerFromContext(ctx); ok {
+		exe.tracker = multiTracker{exe.tracker, tracker}
+	}
+	if secrets, ok := ctx.Value(secretResolverKey{}).(SecretResolver); ok && exe.script == nil {
+		exe.secrets = secrets
+	}
+	exe.mask = &secretMask{}
+	exe.tracker = maskingTracker{tracker: exe.tracker, mask: exe.mask}
+	output, err := exe.steps(ctx, chain, state)
+	// Secrets resolved for hooks never leave the execution, not through its output nor its error.
+	output = exe.mask.maskValue(output)
+	err = exe.mask.maskError(err)
+	if errors.Is(err, ErrExecutionPaused) {
+		state.Status = ExecutionWaiting
+	} else if err != nil {
+		state.Status = ExecutionFailed
+		state.Error = err.Error()
+	} else {
+		state.Status = ExecutionCompleted
+		state.Output = output
+		state.CurrentTask = ""
+	}
+	// The final checkpoint must be written even if ctx was cancelled.
+	if cpErr := exe.checkpoint(context.WithoutCancel(ctx), state); cpErr != nil && err == nil {
+		return nil, cpErr
+	}
+	return output, err
+}
+
+func (exe SimpleEnv) checkpoint(ctx context.Context, state *ExecutionState) error {
+	if exe.checkpointer == nil {
+		return nil
+	}
+	if err := exe.checkpointer.Checkpoint(ctx, exe.mask.maskState(state)); err != nil {
+		return fmt.Errorf("failed to checkpoint execution %s: %w", state.ID, err)
 	}
+	return nil
+}
+
+func (exe SimpleEnv) steps(ctx context.Context, chain *ChainDefinition, state *ExecutionState) (any, error) {
+	vars := state.Vars
 	resolver := llmresolver.Randomly
 	var err error
 	if len(chain.RoutingStrategy) > 0 {
@@ -77,38 +199,96 @@ func (exe SimpleEnv) ExecEnv(ctx context.Context, chain *ChainDefinition, input
 		}
 	}
 
-	currentTask, err := findTaskByID(chain.Tasks, chain.Tasks[0].ID)
+	if err := compileTransitions(chain); err != nil {
+		return nil, err
+	}
+	if err := compileTemplates(chain); err != nil {
+		return nil, err
+	}
+	ctx = withChainCache(ctx, chain, exe.tracker)
+	ctx = withChainScope(ctx, chain, state)
+
+	currentTask, err := findTaskByID(chain.Tasks, state.CurrentTask)
+	if err != nil {
+		return nil, err
+	}
+	// A resumed execution already entered its current task.
+	if len(state.Path) == 0 {
+		if err := enterTask(chain, state, currentTask); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancelChain, err := withChainTimeout(ctx, chain)
 	if err != nil {
 		return nil, err
 	}
+	defer cancelChain()
 
 	var finalOutput any
 
 	for {
+		vars["loop"] = loopVars(state, currentTask.ID)
+		vars["conversation"] = conversationVars(state.Conversation)
+
 		// Render prompt template
-		renderedPrompt, err := renderTemplate(currentTask.PromptTemplate, vars)
+		render := func() (string, error) {
+			return exe.renderTemplate(ctx, currentTask, currentTask.PromptTemplate, vars)
+		}
+		renderedPrompt, err := render()
 		if err != nil {
 			return nil, fmt.Errorf("task %s: template error: %v", currentTask.ID, err)
 		}
+		meteredCtx := exe.withTokenMeter(ctx, chain, state, currentTask)
+		renderedPrompt, err = exe.budgetPrompt(meteredCtx, resolver, chain, state, currentTask, renderedPrompt, render)
+		if err != nil {
+			return nil, fmt.Errorf("task %s: %w", currentTask.ID, err)
+		}
 
 		var rawResponse string
 		var output any
 		var taskErr error
 
-		maxRetries := max(currentTask.RetryOnError, 0)
+		policy := retryPolicy(chain, currentTask)
+		maxRetries := policy.maxRetries(currentTask)
+		attemptTask, attemptPrompt := currentTask, renderedPrompt
+		retries := 0
 
 	retryLoop:
 		for retry := 0; retry <= maxRetries; retry++ {
+			if retry > 0 {
+				class := ClassifyError(taskErr)
+				if !policy.retries(class) {
+					break retryLoop
+				}
+				if err := exe.backoff(ctx, policy, currentTask, retry, class); err != nil && ctx.Err() == nil {
+					return nil, fmt.Errorf("task %s: %w", currentTask.ID, err)
+				}
+				attemptTask, attemptPrompt = policy.attempt(currentTask, renderedPrompt, retry, taskErr)
+				retries = retry
+			}
+			if ctx.Err() != nil {
+				if err := timeoutError(ctx, chain, state, currentTask.ID); err != nil {
+					return nil, err
+				}
+				return nil, ctx.Err()
+			}
+
+			// Persist the attempt before running it, so a crash mid-task is visible on resume.
+			state.Attempts[currentTask.ID]++
+			if err := exe.checkpoint(ctx, state); err != nil {
+				return nil, err
+			}
+
 			// Track task attempt start
-			taskCtx := ctx
-			var cancel context.CancelFunc
+			taskCtx := meteredCtx
+			cancel := func() {}
 			if currentTask.Timeout != "" {
 				timeout, err := time.ParseDuration(currentTask.Timeout)
 				if err != nil {
 					return nil, fmt.Errorf("task %s: invalid timeout: %v", currentTask.ID, err)
 				}
-				taskCtx, cancel = context.WithTimeout(ctx, timeout)
-				defer cancel()
+				taskCtx, cancel = context.WithTimeout(meteredCtx, timeout)
 			}
 
 			reportErrAttempt, reportChangeAttempt, endAttempt := exe.tracker.Start(
@@ -117,20 +297,42 @@ func (exe SimpleEnv) ExecEnv(ctx context.Context, chain *ChainDefinition, input
 				currentTask.ID,
 				"retry", retry,
 				"task_type", currentTask.Type,
+				"rendered_prompt", attemptPrompt,
 			)
-			defer endAttempt()
-			output, rawResponse, taskErr = exe.exec.TaskExec(taskCtx, resolver, currentTask, renderedPrompt)
-			if taskErr != nil {
+			selected := &resolution{}
+			switch currentTask.Type {
+			case Approval:
+				output, rawResponse, taskErr = exe.approval(taskCtx, state, attemptTask, attemptPrompt)
+			case Chat:
+				output, rawResponse, taskErr = exe.chat(taskCtx, selected.wrap(resolver), attemptTask, attemptPrompt, vars, &state.Conversation)
+			default:
+				output, rawResponse, taskErr = exe.runTask(taskCtx, selected.wrap(resolver), attemptTask, attemptPrompt, vars)
+			}
+			if taskErr == nil {
+				reportChangeAttempt(currentTask.ID, selected.report(output, rawResponse))
+			} else if !errors.Is(taskErr, ErrExecutionPaused) {
 				reportErrAttempt(taskErr)
-				continue retryLoop
 			}
+			endAttempt()
+			cancel()
 
-			// Report successful attempt
-			reportChangeAttempt(currentTask.ID, output)
+			if errors.Is(taskErr, ErrExecutionPaused) {
+				return nil, taskErr
+			}
+			// Retrying cannot bring the execution back within its token budget.
+			if errors.Is(taskErr, ErrTokenLimit) {
+				return nil, fmt.Errorf("task %s: %w", currentTask.ID, taskErr)
+			}
+			if taskErr != nil {
+				continue retryLoop
+			}
 			break retryLoop
 		}
 
 		if taskErr != nil {
+			if err := timeoutError(ctx, chain, state, currentTask.ID); err != nil {
+				return nil, err
+			}
 			if currentTask.Transition.OnError != "" {
 				previousTaskID := currentTask.ID
 				currentTask, err = findTaskByID(chain.Tasks, currentTask.Transition.OnError)
@@ -145,32 +347,45 @@ func (exe SimpleEnv) ExecEnv(ctx context.Context, chain *ChainDefinition, input
 					"next_task", currentTask.ID,
 					"reason", "error",
 				)
-				defer endErrTransition()
 				reportChangeErrTransition(currentTask.ID, taskErr)
+				endErrTransition()
+				if err := enterTask(chain, state, currentTask); err != nil {
+					return nil, err
+				}
+				state.CurrentTask = currentTask.ID
+				if err := exe.checkpoint(ctx, state); err != nil {
+					return nil, err
+				}
 				continue
 			}
 			return nil, fmt.Errorf("task %s failed after %d retries: %v",
-				currentTask.ID, maxRetries, taskErr)
+				currentTask.ID, retries, taskErr)
 		}
 
 		// Update execution variables
 		vars["previous_output"] = output
 		vars[currentTask.ID] = output
+		if branches, ok := output.(map[string]any); ok && currentTask.Type == Parallel {
+			for branchID, branchOutput := range branches {
+				vars[branchID] = branchOutput
+			}
+		}
+		state.Steps++
 
 		// Handle print statement
 		if currentTask.Print != "" {
-			printMsg, err := renderTemplate(currentTask.Print, vars)
-			if err != nil {
-				return nil, fmt.Errorf("task %s: print template error: %v", currentTask.ID, err)
+			if err := exe.print(ctx, state, currentTask, vars); err != nil {
+				return nil, err
 			}
-			fmt.Println(printMsg)
 		}
+		observeStep(ctx, ExecutionStep{TaskID: currentTask.ID, Output: exe.mask.maskValue(output), Vars: exe.mask.maskVars(vars)})
 
 		// Evaluate transitions
-		nextTaskID, err := evaluateTransitions(currentTask.Transition, rawResponse)
+		next, err := evaluateTransitions(currentTask.Transition, output, rawResponse, vars)
 		if err != nil {
 			return nil, fmt.Errorf("task %s: transition error: %v", currentTask.ID, err)
 		}
+		nextTaskID := next.ID
 
 		if nextTaskID == "" || nextTaskID == "end" {
 			finalOutput = output
@@ -181,8 +396,8 @@ func (exe SimpleEnv) ExecEnv(ctx context.Context, chain *ChainDefinition, input
 				"chain",
 				"final_output", finalOutput,
 			)
-			defer endFinal()
 			reportChangeFinal("chain", finalOutput)
+			endFinal()
 			break
 		}
 
@@ -192,56 +407,84 @@ func (exe SimpleEnv) ExecEnv(ctx context.Context, chain *ChainDefinition, input
 			"transition",
 			currentTask.ID,
 			"next_task", nextTaskID,
+			"operator", next.Operator,
+			"value", next.Value,
+			"path", next.Path,
 		)
-		defer endTransition()
 		reportChangeTransition(nextTaskID, nil)
+		endTransition()
 
 		// Find next task
 		currentTask, err = findTaskByID(chain.Tasks, nextTaskID)
 		if err != nil {
 			return nil, fmt.Errorf("next task %s not found: %v", nextTaskID, err)
 		}
-	}
 
-	return finalOutput, nil
-}
+		if err := enterTask(chain, state, currentTask); err != nil {
+			return nil, err
+		}
 
-func renderTemplate(tmplStr string, vars map[string]any) (string, error) {
-	tmpl, err := template.New("prompt").Parse(tmplStr)
-	if err != nil {
-		return "", err
-	}
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, vars); err != nil {
-		return "", err
+		// The completed task is persisted, a resume starts at the next one.
+		state.CurrentTask = currentTask.ID
+		if err := exe.checkpoint(ctx, state); err != nil {
+			return nil, err
+		}
 	}
-	return buf.String(), nil
+
+	return finalOutput, nil
 }
 
-func evaluateTransitions(transition Transition, rawResponse string) (string, error) {
+// evaluateTransitions returns the first branch whose condition matches the task output,
+// falling back to the "_default" branch.
+func evaluateTransitions(transition Transition, output any, rawResponse string, vars map[string]any) (ConditionalTransition, error) {
 	// First check explicit matches
 	for _, ct := range transition.Next {
 		if ct.Value == "_default" {
 			continue
 		}
 
-		match, err := compare(ct.Operator, rawResponse, ct.Value)
+		if ct.When != "" {
+			// Parsed expressions are cached, they were type-checked against the chain before it started.
+			expr, err := compileExpression(ct.When, nil)
+			if err != nil {
+				return ConditionalTransition{}, err
+			}
+			match, err := expr.evalBool(vars)
+			if err != nil {
+				return ConditionalTransition{}, err
+			}
+			if match {
+				return ct, nil
+			}
+			continue
+		}
+
+		response := rawResponse
+		if ct.Path != "" {
+			value, err := lookupPath(output, ct.Path)
+			if err != nil {
+				return ConditionalTransition{}, err
+			}
+			response = formatValue(value)
+		}
+
+		match, err := compare(ct.Operator, response, ct.Value)
 		if err != nil {
-			return "", err
+			return ConditionalTransition{}, err
 		}
 		if match {
-			return ct.ID, nil
+			return ct, nil
 		}
 	}
 
 	// Then check for default
 	for _, ct := range transition.Next {
 		if ct.Value == "_default" {
-			return ct.ID, nil
+			return ct, nil
 		}
 	}
 
-	return "", fmt.Errorf("no matching transition found")
+	return ConditionalTransition{}, fmt.Errorf("no matching transition found")
 }
 
 // parseNumber attempts to parse a string as either an integer or float.
@@ -254,6 +497,19 @@ func parseNumber(s string) (float64, error) {
 	return strconv.ParseFloat(s, 64)
 }
 
+// supportedOperators lists every operator understood by compare.
+var supportedOperators = map[string]struct{}{
+	"equals":     {},
+	"contains":   {},
+	"startsWith": {},
+	"endsWith":   {},
+	">":          {},
+	"gt":         {},
+	"<":          {},
+	"lt":         {},
+	"between":    {},
+}
+
 // compare applies a logical operator to a model response and a target value.
 //
 // Supported operators include equality, string containment, numeric comparisons,
@@ -320,3 +576,40 @@ func findTaskByID(tasks []ChainTask, id string) (*ChainTask, error) {
 	}
 	return nil, fmt.Errorf("task not found: %s", id)
 }
+
+// resolution remembers which model and backend a resolver policy selected,
+// so they can be reported to the tracker.
+type resolution struct {
+	mu      sync.Mutex
+	model   string
+	backend string
+}
+
+// wrap returns a policy that delegates to policy and records its selection.
+func (r *resolution) wrap(policy llmresolver.Policy) llmresolver.Policy {
+	return func(candidates []modelprovider.Provider) (modelprovider.Provider, string, error) {
+		provider, backend, err := policy(candidates)
+		if err == nil && provider != nil {
+			r.mu.Lock()
+			r.model = provider.ModelName()
+			r.backend = backend
+			r.mu.Unlock()
+		}
+		return provider, backend, err
+	}
+}
+
+// report describes a successful attempt for the tracker.
+func (r *resolution) report(output any, rawResponse string) map[string]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := map[string]any{
+		"output":       output,
+		"raw_response": rawResponse,
+	}
+	if r.model != "" {
+		report["model"] = r.model
+		report["backend"] = r.backend
+	}
+	return report
+}
@@ -181,3 +181,157 @@ func TestSimpleEnv_ExecEnv_PrintTemplate(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "printed-value", result)
 }
+
+func TestSimpleEnv_ExecEnv_StructuredOutput(t *testing.T) {
+	mockExec := &taskengine.MockTaskExecutor{
+		MockOutput:      map[string]any{"customer": map[string]any{"name": "Ada", "tier": "gold"}},
+		MockRawResponse: `{"customer":{"name":"Ada","tier":"gold"}}`,
+	}
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, mockExec)
+	require.NoError(t, err)
+
+	chain := &taskengine.ChainDefinition{
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:   "extract",
+				Type: taskengine.PromptToJSON,
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{
+						{Operator: "equals", Path: "customer.tier", Value: "gold", ID: "vip"},
+						{Value: "_default", ID: "end"},
+					},
+				},
+			},
+			{
+				ID:             "vip",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "Greet {{ .extract.customer.name }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+
+	_, err = env.ExecEnv(context.Background(), chain, "")
+	require.NoError(t, err)
+	require.Equal(t, "vip", mockExec.CalledWithTask.ID)
+	require.Equal(t, "Greet Ada", mockExec.CalledWithPrompt)
+}
+
+type recordedOperation struct {
+	operation string
+	subject   string
+	args      []any
+	change    any
+	ended     bool
+	// open is the number of operations that had not ended when this one started.
+	open int
+}
+
+// recordingTracker records every tracked operation with its arguments and reported change.
+type recordingTracker struct {
+	operations []*recordedOperation
+}
+
+func (r *recordingTracker) Start(_ context.Context, operation string, subject string, kvArgs ...any) (func(error), func(string, any), func()) {
+	op := &recordedOperation{operation: operation, subject: subject, args: kvArgs}
+	for _, started := range r.operations {
+		if !started.ended {
+			op.open++
+		}
+	}
+	r.operations = append(r.operations, op)
+	return func(error) {}, func(_ string, data any) { op.change = data }, func() { op.ended = true }
+}
+
+func TestSimpleEnv_ExecEnv_TracksPromptAndTransition(t *testing.T) {
+	mockExec := &taskengine.MockTaskExecutor{
+		MockOutput:      "yes",
+		MockRawResponse: "yes",
+	}
+	tracker := &recordingTracker{}
+	env, err := taskengine.NewEnv(context.Background(), tracker, mockExec)
+	require.NoError(t, err)
+
+	chain := &taskengine.ChainDefinition{
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "ask",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "Is {{ .input }} valid?",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{
+						{Operator: "equals", Value: "yes", ID: "done"},
+						{Value: "_default", ID: "end"},
+					},
+				},
+			},
+			{
+				ID:             "done",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "ok",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+
+	_, err = env.ExecEnv(context.Background(), chain, "order-1")
+	require.NoError(t, err)
+
+	require.Equal(t, "task_attempt", tracker.operations[0].operation)
+	require.Contains(t, tracker.operations[0].args, "Is order-1 valid?")
+	require.Equal(t, map[string]any{"output": "yes", "raw_response": "yes"}, tracker.operations[0].change)
+
+	require.Equal(t, "transition", tracker.operations[1].operation)
+	require.Equal(t, []any{"next_task", "done", "operator", "equals", "value", "yes", "path", ""}, tracker.operations[1].args)
+
+	// Every attempt and transition ends before the next one starts.
+	require.Len(t, tracker.operations, 4)
+	for _, op := range tracker.operations {
+		require.True(t, op.ended, op.operation)
+		require.Zero(t, op.open, op.operation)
+	}
+}
+
+func TestSimpleEnv_ExecEnv_ReportsToContextTracker(t *testing.T) {
+	mockExec := &taskengine.MockTaskExecutor{
+		MockOutput:      "hello",
+		MockRawResponse: "hello",
+	}
+	envTracker := &recordingTracker{}
+	env, err := taskengine.NewEnv(context.Background(), envTracker, mockExec)
+	require.NoError(t, err)
+
+	chain := &taskengine.ChainDefinition{
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "greet",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "Say hello",
+				Print:          "Model said: {{ .greet }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+
+	ctxTracker := &recordingTracker{}
+	ctx := taskengine.WithTracker(context.Background(), ctxTracker)
+	_, err = env.ExecEnv(ctx, chain, "hi")
+	require.NoError(t, err)
+
+	require.Equal(t, len(envTracker.operations), len(ctxTracker.operations))
+	var printed *recordedOperation
+	for _, op := range ctxTracker.operations {
+		if op.operation == "print" {
+			printed = op
+		}
+	}
+	require.NotNil(t, printed)
+	require.Equal(t, "greet", printed.subject)
+	require.Equal(t, "Model said: hello", printed.change)
+}
@@ -2,12 +2,17 @@ package taskengine
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/contenox/contenox/core/llmrepo"
 	"github.com/contenox/contenox/core/llmresolver"
+	"github.com/contenox/contenox/core/modelprovider"
+	"github.com/contenox/contenox/core/serverops"
 )
 
 // TaskExecutor defines the interface for executing a single task step.
@@ -23,6 +28,20 @@ type TaskExecutor interface {
 type SimpleExec struct {
 	promptExec   llmrepo.ModelRepo
 	hookProvider HookRepo
+	models       func(ctx context.Context) modelprovider.RuntimeState
+	cache        ResponseStore
+	script       *dryRunScript
+}
+
+// ExecOption configures a SimpleExec.
+type ExecOption func(*SimpleExec)
+
+// WithModelRuntime sets the models of all pools, which tasks with PreferredModels are resolved from.
+// Without it preferred models are only resolved from the exec repo.
+func WithModelRuntime(models func(ctx context.Context) modelprovider.RuntimeState) ExecOption {
+	return func(exe *SimpleExec) {
+		exe.models = models
+	}
 }
 
 // NewExec creates a new instance of SimpleExec.
@@ -30,6 +49,7 @@ func NewExec(
 	_ context.Context,
 	promptExec llmrepo.ModelRepo,
 	hookProvider HookRepo,
+	opts ...ExecOption,
 ) (TaskExecutor, error) {
 	if hookProvider == nil {
 		return nil, fmt.Errorf("hook provider is nil")
@@ -37,42 +57,153 @@ func NewExec(
 	if promptExec == nil {
 		return nil, fmt.Errorf("prompt executor is nil")
 	}
-	return &SimpleExec{
+	exe := &SimpleExec{
 		hookProvider: hookProvider,
 		promptExec:   promptExec,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(exe)
+	}
+	return exe, nil
 }
 
 // Prompt resolves a model client using the resolver policy and sends the prompt
 // to be executed. Returns the trimmed response string or an error.
-func (exe *SimpleExec) Prompt(ctx context.Context, resolver llmresolver.Policy, prompt string) (string, error) {
+// The preferred models and generation options of task are applied if task is not nil.
+// If the response cache is enabled for task, responses of the selected model are reused.
+func (exe *SimpleExec) Prompt(ctx context.Context, resolver llmresolver.Policy, task *ChainTask, prompt string) (string, error) {
 	if prompt == "" {
 		return "", fmt.Errorf("unprocessable empty prompt")
 	}
+	if exe.script != nil {
+		key := ""
+		if task != nil {
+			key = task.ID
+		}
+		response, err := exe.script.next(key)
+		return strings.TrimSpace(response), err
+	}
+	var ttl time.Duration
+	if exe.cache != nil {
+		var err error
+		if ttl, err = cacheTTL(ctx, task); err != nil {
+			return "", err
+		}
+	}
+	var model string
+	if ttl > 0 {
+		resolver = selectedModel(resolver, &model)
+	}
+	req := llmresolver.PromptRequest{}
+	runtime := exe.promptExec.GetRuntime(ctx)
+	if task != nil {
+		req.PreferredModels = task.PreferredModels
+		req.Options = task.Generation.promptOptions()
+		if len(task.PreferredModels) > 0 && exe.models != nil {
+			runtime = exe.models(ctx)
+		}
+	}
 	provider, err := exe.promptExec.GetProvider(ctx)
-	if err != nil {
+	if err != nil && len(req.PreferredModels) == 0 {
 		return "", fmt.Errorf("provider resolution failed: %w", err)
 	}
+	if err == nil {
+		req.ModelName = provider.ModelName()
+	}
+
+	client, err := llmresolver.PromptExecute(ctx, req, runtime, resolver)
+	if err != nil {
+		return "", classify(ErrorTransport, fmt.Errorf("client resolution failed: %w", err))
+	}
+
+	execute := func() (string, error) {
+		response, err := client.Prompt(ctx, prompt)
+		if err != nil {
+			return "", classify(ErrorTransport, fmt.Errorf("prompt execution failed: %w", err))
+		}
+		return strings.TrimSpace(response), nil
+	}
+	if ttl > 0 {
+		if model == "" {
+			model = req.ModelName
+		}
+		return exe.cachedPrompt(ctx, task, model, prompt, ttl, execute)
+	}
+	return execute()
+}
 
-	client, err := llmresolver.PromptExecute(ctx, llmresolver.PromptRequest{
-		ModelName: provider.ModelName(),
-	}, exe.promptExec.GetRuntime(ctx), resolver)
+// ContextLength returns the context length of the first preferred model of task that is available,
+// or of the exec repo's model.
+func (exe *SimpleExec) ContextLength(ctx context.Context, task *ChainTask) (int, error) {
+	if exe.script != nil {
+		return 0, nil
+	}
+	if len(task.PreferredModels) > 0 && exe.models != nil {
+		providers, err := exe.models(ctx)(ctx, "")
+		if err != nil {
+			return 0, err
+		}
+		for _, name := range task.PreferredModels {
+			for _, provider := range providers {
+				if provider.ModelName() == name {
+					return provider.GetContextLength(), nil
+				}
+			}
+		}
+	}
+	provider, err := exe.promptExec.GetProvider(ctx)
 	if err != nil {
-		return "", fmt.Errorf("client resolution failed: %w", err)
+		return 0, err
 	}
+	return provider.GetContextLength(), nil
+}
 
-	response, err := client.Prompt(ctx, prompt)
+// ChatExec resolves a chat client for the preferred models of task, falling back to the exec repo's model,
+// and sends messages to it. Returns the reply with trimmed content or an error.
+func (exe *SimpleExec) ChatExec(ctx context.Context, resolver llmresolver.Policy, task *ChainTask, messages []serverops.Message) (serverops.Message, error) {
+	if len(messages) == 0 {
+		return serverops.Message{}, fmt.Errorf("unprocessable empty chat")
+	}
+	if exe.script != nil {
+		content, err := exe.script.next(task.ID)
+		return serverops.Message{Role: "assistant", Content: strings.TrimSpace(content)}, err
+	}
+	modelNames := task.PreferredModels
+	runtime := exe.promptExec.GetRuntime(ctx)
+	if len(modelNames) > 0 && exe.models != nil {
+		runtime = exe.models(ctx)
+	}
+	provider, err := exe.promptExec.GetProvider(ctx)
+	if err != nil && len(modelNames) == 0 {
+		return serverops.Message{}, fmt.Errorf("provider resolution failed: %w", err)
+	}
+	if err == nil {
+		modelNames = append(modelNames[:len(modelNames):len(modelNames)], provider.ModelName())
+	}
+
+	var client serverops.LLMChatClient
+	for _, modelName := range modelNames {
+		client, err = llmresolver.Chat(ctx, llmresolver.Request{ModelNames: []string{modelName}}, runtime, resolver)
+		if err == nil || !errors.Is(err, llmresolver.ErrNoSatisfactoryModel) {
+			break
+		}
+	}
 	if err != nil {
-		return "", fmt.Errorf("prompt execution failed: %w", err)
+		return serverops.Message{}, classify(ErrorTransport, fmt.Errorf("client resolution failed: %w", err))
 	}
 
-	return strings.TrimSpace(response), nil
+	reply, err := client.Chat(ctx, messages)
+	if err != nil {
+		return serverops.Message{}, classify(ErrorTransport, fmt.Errorf("chat execution failed: %w", err))
+	}
+	reply.Content = strings.TrimSpace(reply.Content)
+	return reply, nil
 }
 
 // rang executes the prompt and attempts to parse the response as a range string (e.g. "6-8").
 // If the response is a single number, it returns a degenerate range like "6-6".
-func (exe *SimpleExec) rang(ctx context.Context, resolver llmresolver.Policy, prompt string) (string, error) {
-	response, err := exe.Prompt(ctx, resolver, prompt)
+func (exe *SimpleExec) rang(ctx context.Context, resolver llmresolver.Policy, task *ChainTask, prompt string) (string, error) {
+	response, err := exe.Prompt(ctx, resolver, task, prompt)
 	if err != nil {
 		return "", err
 	}
@@ -83,7 +214,7 @@ func (exe *SimpleExec) rang(ctx context.Context, resolver llmresolver.Policy, pr
 	if strings.Contains(clean, "-") {
 		parts := strings.Split(clean, "-")
 		if len(parts) != 2 {
-			return "", fmt.Errorf("invalid range format: %s", rangeStr)
+			return "", classify(ErrorParse, fmt.Errorf("invalid range format: %s", rangeStr))
 		}
 		_, err = strconv.Atoi(parts[0])
 		if err != nil {
@@ -98,7 +229,7 @@ func (exe *SimpleExec) rang(ctx context.Context, resolver llmresolver.Policy, pr
 
 	// Fallback: try parsing as a single number
 	if _, err := strconv.Atoi(clean); err != nil {
-		return "", fmt.Errorf("invalid number format: %s", rangeStr)
+		return "", classify(ErrorParse, fmt.Errorf("invalid number format: %s", rangeStr))
 	}
 
 	// Treat a single number as a degenerate range like "6-6"
@@ -106,8 +237,8 @@ func (exe *SimpleExec) rang(ctx context.Context, resolver llmresolver.Policy, pr
 }
 
 // number executes the prompt and parses the response as an integer.
-func (exe *SimpleExec) number(ctx context.Context, resolver llmresolver.Policy, prompt string) (int, error) {
-	response, err := exe.Prompt(ctx, resolver, prompt)
+func (exe *SimpleExec) number(ctx context.Context, resolver llmresolver.Policy, task *ChainTask, prompt string) (int, error) {
+	response, err := exe.Prompt(ctx, resolver, task, prompt)
 	if err != nil {
 		return 0, err
 	}
@@ -119,8 +250,8 @@ func (exe *SimpleExec) number(ctx context.Context, resolver llmresolver.Policy,
 }
 
 // score executes the prompt and parses the response as a floating-point score.
-func (exe *SimpleExec) score(ctx context.Context, resolver llmresolver.Policy, prompt string) (float64, error) {
-	response, err := exe.Prompt(ctx, resolver, prompt)
+func (exe *SimpleExec) score(ctx context.Context, resolver llmresolver.Policy, task *ChainTask, prompt string) (float64, error) {
+	response, err := exe.Prompt(ctx, resolver, task, prompt)
 	if err != nil {
 		return 0, err
 	}
@@ -132,6 +263,90 @@ func (exe *SimpleExec) score(ctx context.Context, resolver llmresolver.Policy, p
 	return f, nil
 }
 
+// defaultRepairAttempts is used for PromptToJSON tasks that do not set RepairAttempts.
+const defaultRepairAttempts = 2
+
+// jsonObject executes the prompt and parses the response as JSON, validating it against the
+// task's OutputSchema. If parsing or validation fails, the model is asked to repair its
+// response up to RepairAttempts times.
+func (exe *SimpleExec) jsonObject(ctx context.Context, resolver llmresolver.Policy, task *ChainTask, prompt string) (any, error) {
+	repairs := defaultRepairAttempts
+	if task.RepairAttempts != nil {
+		repairs = *task.RepairAttempts
+	}
+	current := prompt
+	var lastErr error
+	for attempt := 0; attempt <= repairs; attempt++ {
+		// The first prompt was counted by the environment, repair prompts are sent from here.
+		if attempt > 0 {
+			if err := chargeTokens(ctx, task, current); err != nil {
+				return nil, err
+			}
+		}
+		response, err := exe.Prompt(ctx, resolver, task, current)
+		if err != nil {
+			return nil, err
+		}
+		parsed, problems := parseJSONResponse(response, task.OutputSchema)
+		if len(problems) == 0 {
+			return parsed, nil
+		}
+		lastErr = fmt.Errorf("invalid JSON response: %s", strings.Join(problems, "; "))
+		current = repairPrompt(prompt, response, task.OutputSchema, problems)
+	}
+	return nil, classify(ErrorParse, fmt.Errorf("%w after %d repair attempts", lastErr, repairs))
+}
+
+// parseJSONResponse extracts the JSON document from a model response and validates it.
+// Markdown code fences and text around the document are ignored.
+func parseJSONResponse(response string, schema map[string]any) (any, []string) {
+	text := strings.TrimSpace(response)
+	if start := strings.Index(text, "```"); start >= 0 {
+		text = text[start+3:]
+		text = strings.TrimPrefix(text, "json")
+		if end := strings.Index(text, "```"); end >= 0 {
+			text = text[:end]
+		}
+		text = strings.TrimSpace(text)
+	}
+	if start := strings.IndexAny(text, "{["); start > 0 {
+		text = text[start:]
+	}
+	if end := strings.LastIndexAny(text, "}]"); end >= 0 && end < len(text)-1 {
+		text = text[:end+1]
+	}
+
+	var parsed any
+	if err := json.Unmarshal([]byte(text), &parsed); err != nil {
+		return nil, []string{fmt.Sprintf("response is not valid JSON: %v", err)}
+	}
+	if len(schema) > 0 {
+		if problems := validateSchema(schema, parsed); len(problems) > 0 {
+			return nil, problems
+		}
+	}
+	return parsed, nil
+}
+
+// repairPrompt asks the model to correct a response that failed parsing or validation.
+func repairPrompt(prompt, response string, schema map[string]any, problems []string) string {
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\nYour previous response was rejected:\n")
+	b.WriteString(response)
+	b.WriteString("\n\nProblems:\n")
+	for _, problem := range problems {
+		b.WriteString("- " + problem + "\n")
+	}
+	if len(schema) > 0 {
+		b.WriteString("\nThe response must be valid JSON matching this JSON Schema:\n")
+		b.WriteString(compactJSON(schema))
+		b.WriteString("\n")
+	}
+	b.WriteString("\nRespond with the corrected JSON only, without explanations or markdown.")
+	return b.String()
+}
+
 // TaskExec dispatches task execution based on the task type.
 // It handles prompt-based task types like string, number, score, condition, and range,
 // as well as custom hook invocations.
@@ -141,29 +356,37 @@ func (exe *SimpleExec) TaskExec(taskCtx context.Context, resolver llmresolver.Po
 	var output any
 	switch currentTask.Type {
 	case PromptToString:
-		rawResponse, taskErr = exe.Prompt(taskCtx, resolver, renderedPrompt)
+		rawResponse, taskErr = exe.Prompt(taskCtx, resolver, currentTask, renderedPrompt)
 		output = rawResponse
 	case PromptToCondition:
 		var hit bool
-		hit, taskErr = exe.condition(taskCtx, resolver, currentTask.ConditionMapping, renderedPrompt)
+		hit, taskErr = exe.condition(taskCtx, resolver, currentTask, renderedPrompt)
 		output = hit
 		rawResponse = strconv.FormatBool(hit)
 	case PromptToNumber:
 		var number int
-		number, taskErr = exe.number(taskCtx, resolver, renderedPrompt)
+		number, taskErr = exe.number(taskCtx, resolver, currentTask, renderedPrompt)
 		output = number
 		rawResponse = strconv.FormatInt(int64(number), 10)
 	case PromptToScore:
 		var score float64
-		score, taskErr = exe.score(taskCtx, resolver, renderedPrompt)
+		score, taskErr = exe.score(taskCtx, resolver, currentTask, renderedPrompt)
 		output = score
 		rawResponse = strconv.FormatFloat(score, 'f', 2, 64)
 	case PromptToRange:
-		rawResponse, taskErr = exe.rang(taskCtx, resolver, renderedPrompt)
+		rawResponse, taskErr = exe.rang(taskCtx, resolver, currentTask, renderedPrompt)
 		output = rawResponse
+	case PromptToJSON:
+		output, taskErr = exe.jsonObject(taskCtx, resolver, currentTask, renderedPrompt)
+		if taskErr == nil {
+			rawResponse = compactJSON(output)
+		}
 	case Hook:
 		if currentTask.Hook == nil {
 			taskErr = fmt.Errorf("hook task missing hook definition")
+		} else if exe.script != nil {
+			output, taskErr = exe.script.value(currentTask.ID)
+			rawResponse = fmt.Sprintf("%v", output)
 		} else {
 			output, taskErr = exe.hookengine(taskCtx, *currentTask.Hook)
 			rawResponse = fmt.Sprintf("%v", output)
@@ -175,23 +398,50 @@ func (exe *SimpleExec) TaskExec(taskCtx context.Context, resolver llmresolver.Po
 	return output, rawResponse, taskErr
 }
 
+// Tools describes the hooks of the hook provider. Hooks are described by the provider if it
+// implements ToolDescriber, otherwise with a generic schema.
+func (exe *SimpleExec) Tools(ctx context.Context) ([]ToolSchema, error) {
+	if describer, ok := exe.hookProvider.(ToolDescriber); ok {
+		return describer.Tools(ctx)
+	}
+	names, err := exe.hookProvider.Supports(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tools := make([]ToolSchema, 0, len(names))
+	for _, name := range names {
+		tools = append(tools, defaultToolSchema(name))
+	}
+	sortTools(tools)
+	return tools, nil
+}
+
+// CallTool executes a hook requested by an Agent task.
+func (exe *SimpleExec) CallTool(ctx context.Context, call *HookCall) (any, error) {
+	if exe.script != nil {
+		return exe.script.value(call.Type)
+	}
+	return exe.hookengine(ctx, *call)
+}
+
 // hookengine is a placeholder for future hook execution support using the hookProvider.
 // Currently unimplemented.
 func (exe *SimpleExec) hookengine(ctx context.Context, hook HookCall) (any, error) {
 	status, res, err := exe.hookProvider.Exec(ctx, &hook)
 	if err != nil {
-		return nil, err
+		return nil, classify(ErrorTransport, err)
 	}
 	if status != StatusSuccess {
-		return nil, fmt.Errorf("hook execution failed")
+		return nil, classify(ErrorTransport, fmt.Errorf("hook execution failed"))
 	}
 	return res, nil
 }
 
 // condition executes a prompt and evaluates its result against a provided condition mapping.
 // It returns true/false based on the resolved condition value or fallback heuristics.
-func (exe *SimpleExec) condition(ctx context.Context, resolver llmresolver.Policy, conditionMapping map[string]bool, prompt string) (bool, error) {
-	response, err := exe.Prompt(ctx, resolver, prompt)
+func (exe *SimpleExec) condition(ctx context.Context, resolver llmresolver.Policy, task *ChainTask, prompt string) (bool, error) {
+	conditionMapping := task.ConditionMapping
+	response, err := exe.Prompt(ctx, resolver, task, prompt)
 	if err != nil {
 		return false, err
 	}
@@ -202,7 +452,7 @@ func (exe *SimpleExec) condition(ctx context.Context, resolver llmresolver.Polic
 		}
 	}
 	if !found {
-		return false, fmt.Errorf("failed to parse into valid condition output was %s", response)
+		return false, classify(ErrorParse, fmt.Errorf("failed to parse into valid condition output was %s", response))
 	}
 	for key, val := range conditionMapping {
 		if strings.EqualFold(response, key) {
@@ -215,3 +465,27 @@ func (exe *SimpleExec) condition(ctx context.Context, resolver llmresolver.Polic
 
 	return strings.EqualFold(strings.TrimSpace(response), "yes"), nil
 }
+
+// promptOptions converts the generation options of a task into prompt options.
+func (g *GenerationOptions) promptOptions() []serverops.PromptOption {
+	if g == nil {
+		return nil
+	}
+	var opts []serverops.PromptOption
+	if g.Temperature != nil {
+		opts = append(opts, serverops.WithTemperature(*g.Temperature))
+	}
+	if g.TopP != nil {
+		opts = append(opts, serverops.WithTopP(*g.TopP))
+	}
+	if g.Seed != nil {
+		opts = append(opts, serverops.WithSeed(*g.Seed))
+	}
+	if len(g.Stop) > 0 {
+		opts = append(opts, serverops.WithStop(g.Stop...))
+	}
+	if g.MaxTokens > 0 {
+		opts = append(opts, serverops.WithMaxTokens(g.MaxTokens))
+	}
+	return opts
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"strings"
@@ -281,3 +282,134 @@ func TestSimpleExec_TaskExec(t *testing.T) {
 		require.Equal(t, "42", formatted)
 	})
 }
+
+func TestSimpleExec_TaskExec_PromptToJSONRepairs(t *testing.T) {
+	mockProvider := &modelprovider.MockProvider{
+		Name:          "mock-model",
+		CanPromptFlag: true,
+		ContextLength: 2048,
+		ID:            uuid.NewString(),
+		Backends:      []string{"my-backend-1"},
+		PromptResponses: []string{
+			`{"customer": {"name": 42}}`,
+			"```json\n{\"customer\": {\"name\": \"Ada\", \"tier\": \"gold\"}}\n```",
+		},
+	}
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: mockProvider}, taskengine.NewMockHookRegistry())
+	require.NoError(t, err)
+
+	task := &taskengine.ChainTask{
+		ID:   "extract",
+		Type: taskengine.PromptToJSON,
+		OutputSchema: map[string]any{
+			"type":     "object",
+			"required": []any{"customer"},
+			"properties": map[string]any{
+				"customer": map[string]any{
+					"type":     "object",
+					"required": []any{"name"},
+					"properties": map[string]any{
+						"name": map[string]any{"type": "string"},
+						"tier": map[string]any{"enum": []any{"gold", "silver"}},
+					},
+				},
+			},
+		},
+	}
+
+	output, raw, err := exec.TaskExec(context.Background(), llmresolver.Randomly, task, "extract the customer")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"customer": map[string]any{"name": "Ada", "tier": "gold"}}, output)
+	require.JSONEq(t, `{"customer":{"name":"Ada","tier":"gold"}}`, raw)
+	require.Len(t, mockProvider.Prompts, 2)
+	require.Contains(t, mockProvider.Prompts[1], `$.customer.name: expected string, got number`)
+}
+
+func TestSimpleExec_TaskExec_PromptToJSONGivesUp(t *testing.T) {
+	mockProvider := &modelprovider.MockProvider{
+		Name:            "mock-model",
+		CanPromptFlag:   true,
+		ContextLength:   2048,
+		ID:              uuid.NewString(),
+		Backends:        []string{"my-backend-1"},
+		PromptResponses: []string{"not json", "still not json"},
+	}
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: mockProvider}, taskengine.NewMockHookRegistry())
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		repairs *int
+		prompts int
+	}{
+		{repairs: nil, prompts: 3},
+		{repairs: ptr(1), prompts: 2},
+		{repairs: ptr(0), prompts: 1},
+	} {
+		mockProvider.Prompts = nil
+		mockProvider.PromptResponses = []string{"not json", "still not json", "never json"}
+		task := &taskengine.ChainTask{ID: "extract", Type: taskengine.PromptToJSON, RepairAttempts: tc.repairs}
+		_, _, err = exec.TaskExec(context.Background(), llmresolver.Randomly, task, "extract")
+		require.ErrorContains(t, err, fmt.Sprintf("after %d repair attempts", tc.prompts-1))
+		require.Len(t, mockProvider.Prompts, tc.prompts)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestSimpleExec_TaskExec_PreferredModelsAndGenerationOptions(t *testing.T) {
+	newProvider := func(name string) *modelprovider.MockProvider {
+		return &modelprovider.MockProvider{
+			Name:            name,
+			CanPromptFlag:   true,
+			ContextLength:   2048,
+			ID:              uuid.NewString(),
+			Backends:        []string{"backend-" + name},
+			PromptResponses: []string{"from " + name},
+		}
+	}
+	tasksModel := newProvider("tasks-model")
+	largeModel := newProvider("large-model")
+	allModels := func(context.Context) modelprovider.RuntimeState {
+		return func(context.Context, string) ([]modelprovider.Provider, error) {
+			return []modelprovider.Provider{tasksModel, largeModel}, nil
+		}
+	}
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: tasksModel}, taskengine.NewMockHookRegistry(),
+		taskengine.WithModelRuntime(allModels),
+	)
+	require.NoError(t, err)
+
+	temperature := 0.7
+	seed := 42
+	task := &taskengine.ChainTask{
+		ID:              "draft",
+		Type:            taskengine.PromptToString,
+		PreferredModels: []string{"missing-model", "large-model"},
+		Generation: &taskengine.GenerationOptions{
+			Temperature: &temperature,
+			Seed:        &seed,
+			Stop:        []string{"\n\n"},
+			MaxTokens:   256,
+		},
+	}
+	output, _, err := exec.TaskExec(context.Background(), llmresolver.Randomly, task, "write")
+	require.NoError(t, err)
+	require.Equal(t, "from large-model", output)
+	require.Empty(t, tasksModel.Prompts)
+	require.Len(t, largeModel.PromptConfigs, 1)
+	config := largeModel.PromptConfigs[0]
+	require.Equal(t, 0.7, *config.Temperature)
+	require.Equal(t, 42, *config.Seed)
+	require.Nil(t, config.TopP)
+	require.Equal(t, []string{"\n\n"}, config.Stop)
+	require.Equal(t, 256, config.MaxTokens)
+
+	task.PreferredModels = []string{"missing-model"}
+	task.Generation = nil
+	output, _, err = exec.TaskExec(context.Background(), llmresolver.Randomly, task, "write")
+	require.NoError(t, err)
+	require.Equal(t, "from tasks-model", output)
+	require.Equal(t, serverops.PromptConfig{}, tasksModel.PromptConfigs[0])
+}
@@ -21,10 +21,113 @@ const (
 	// PromptToString returns the raw string result from the LLM.
 	PromptToString TaskType = "string"
 
+	// PromptToJSON expects a JSON document, optionally validated against ChainTask.OutputSchema.
+	// Invalid responses are sent back to the model with a repair prompt.
+	PromptToJSON TaskType = "json"
+
 	// Hook indicates this task should execute an external action rather than calling the LLM.
 	Hook TaskType = "hook"
+
+	// Parallel runs the tasks listed in ParallelConfig.Branches concurrently
+	// and joins their outputs according to the configured JoinPolicy.
+	Parallel TaskType = "parallel"
+
+	// Approval pauses the execution until a person approves, rejects or edits
+	// the output of the previous task. See ApprovalGate.
+	Approval TaskType = "approval"
+
+	// SubChain runs another stored chain and returns its final output. See SubChainCall.
+	SubChain TaskType = "chain"
+
+	// Chat sends role-based messages to a chat model and returns the content of its reply. See ChatConfig.
+	Chat TaskType = "chat"
+
+	// Agent lets a chat model call hooks as tools until it gives a final answer. See AgentConfig.
+	Agent TaskType = "agent"
 )
 
+// JoinPolicy defines when a Parallel task is considered complete.
+type JoinPolicy string
+
+const (
+	// JoinAll waits for every branch to succeed, any failing branch fails the task.
+	JoinAll JoinPolicy = "all"
+
+	// JoinAny completes as soon as one branch succeeds and cancels the others.
+	JoinAny JoinPolicy = "any"
+
+	// JoinQuorum completes once ParallelConfig.Quorum branches succeeded and cancels the others.
+	JoinQuorum JoinPolicy = "quorum"
+)
+
+// SubChainCall describes the chain a SubChain task runs.
+type SubChainCall struct {
+	// ChainID is the ID of the stored chain to run.
+	ChainID string `yaml:"chain_id" json:"chainId"`
+
+	// Vars maps variable names of the sub-chain to templates rendered with the
+	// variables of the calling chain. The rendered prompt template of the task
+	// is passed as the sub-chain's "input".
+	Vars map[string]string `yaml:"vars,omitempty" json:"vars,omitempty"`
+}
+
+// ChatConfig describes the messages of a Chat task.
+//
+// The model receives Messages, then the chain conversation if Conversation is set,
+// then the rendered prompt template of the task as a "user" message, if it is not empty.
+type ChatConfig struct {
+	// Messages are sent on every call, e.g. a system prompt and few-shot examples.
+	// Their content is a template rendered with the execution variables.
+	Messages []ChatMessage `yaml:"messages,omitempty" json:"messages,omitempty"`
+
+	// Conversation sends the chain conversation before the prompt and adds the prompt
+	// and the reply to it. Later tasks can refer to the conversation as "conversation",
+	// a list of messages with "role" and "content" fields.
+	Conversation bool `yaml:"conversation,omitempty" json:"conversation,omitempty"`
+}
+
+// ChatMessage is a templated message of a Chat task.
+type ChatMessage struct {
+	// Role is the author of the message: "system", "user" or "assistant".
+	Role string `yaml:"role" json:"role"`
+
+	// Content is the message template.
+	Content string `yaml:"content" json:"content"`
+}
+
+// AgentConfig describes the tools of an Agent task.
+//
+// The model is asked to complete the rendered prompt template of the task. In every
+// iteration it either calls one of the tools, whose result is sent back to it, or
+// gives the final answer, which becomes the output of the task.
+type AgentConfig struct {
+	// Tools lists the hooks the model may call. Other hooks of the registry cannot be called.
+	Tools []string `yaml:"tools" json:"tools"`
+
+	// MaxIterations limits how often the model is asked, defaults to DefaultAgentMaxIterations.
+	// The task fails if the model gave no final answer within the limit.
+	MaxIterations int `yaml:"max_iterations,omitempty" json:"maxIterations,omitempty"`
+}
+
+// DefaultAgentMaxIterations is the MaxIterations of Agent tasks that do not set one.
+const DefaultAgentMaxIterations = 8
+
+// ParallelConfig describes the branches of a Parallel task.
+type ParallelConfig struct {
+	// Branches are executed concurrently. Each branch output is stored in the
+	// execution variables under the branch ID. Branch transitions are ignored.
+	Branches []ChainTask `yaml:"branches" json:"branches"`
+
+	// MaxConcurrency limits how many branches run at the same time, 0 means no limit.
+	MaxConcurrency int `yaml:"max_concurrency,omitempty" json:"maxConcurrency,omitempty"`
+
+	// Join selects the join policy, defaults to JoinAll.
+	Join JoinPolicy `yaml:"join,omitempty" json:"join,omitempty"`
+
+	// Quorum is the number of branches that must succeed when Join is JoinQuorum.
+	Quorum int `yaml:"quorum,omitempty" json:"quorum,omitempty"`
+}
+
 // TriggerType defines the type of trigger that starts a chain.
 type TriggerType string
 
@@ -40,6 +143,9 @@ const (
 
 	// TriggerEvent starts the chain in response to an external event or webhook.
 	TriggerEvent TriggerType = "event"
+
+	// TriggerSchedule starts the chain at the times of a cron expression.
+	TriggerSchedule TriggerType = "schedule"
 )
 
 // Trigger defines how and when a chain should be started.
@@ -51,9 +157,26 @@ type Trigger struct {
 	Description string `yaml:"description" json:"description"`
 
 	// Pattern is used for matching input in keyword or event triggers.
+	// For keyword triggers it is a regular expression matched case-insensitively against the input,
+	// for semantic triggers the text compared to the input (defaults to Description)
+	// and for event triggers the libbus subject to subscribe to.
+	// For schedule triggers it is the cron expression, see ParseSchedule.
 	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+
+	// TimeZone is the IANA time zone schedule triggers are evaluated in, defaults to UTC.
+	TimeZone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+
+	// Input is passed as the chain input when a schedule trigger fires.
+	Input string `yaml:"input,omitempty" json:"input,omitempty"`
+
+	// MaxDistance is the largest vector distance between input and Pattern
+	// at which a semantic trigger fires. Defaults to DefaultTriggerMaxDistance.
+	MaxDistance float32 `yaml:"max_distance,omitempty" json:"maxDistance,omitempty"`
 }
 
+// DefaultTriggerMaxDistance is the MaxDistance of semantic triggers that do not set one.
+const DefaultTriggerMaxDistance = 0.3
+
 // Transition defines what happens after a task completes,
 // including which task to go to next and how to handle errors.
 type Transition struct {
@@ -75,6 +198,15 @@ type ConditionalTransition struct {
 
 	// ID is the target task ID to transition to if the condition is met.
 	ID string `yaml:"id" json:"id"`
+
+	// Path optionally selects a field of a structured output (e.g. "customer.tier")
+	// to compare instead of the raw response.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// When is a boolean expression over the execution variables, e.g.
+	// `extract.priority == "high" && score > 0.7`. If set, Operator, Value and Path are ignored.
+	// Expressions are type-checked when the chain is validated and before it runs.
+	When string `yaml:"when,omitempty" json:"when,omitempty"`
 }
 
 // HookCall represents an external integration or side-effect triggered during a task.
@@ -87,10 +219,13 @@ type HookCall struct {
 	Input string `yaml:"input,omitempty" json:"input,omitempty"`
 
 	// Args are key-value pairs to parameterize the hook call.
+	// Values are templates rendered with the execution variables when the hook runs,
+	// {{ secret "name" }} inserts the value of a stored secret. See WithSecrets.
 	Args map[string]string `yaml:"args,omitempty" json:"args"`
 
-	// Blocking determines whether the execution should wait for the hook to complete.
-	Blocking bool `yaml:"blocking,omitempty" json:"blocking"`
+	// Async makes the execution continue without waiting for the hook to complete.
+	// Async hooks are handed to the HookQueue of the environment, see WithHookQueue.
+	Async bool `yaml:"async,omitempty" json:"async,omitempty"`
 }
 
 // ChainTask represents a single step in a workflow.
@@ -111,9 +246,30 @@ type ChainTask struct {
 	// Hook defines an external action to run (only for Hook tasks).
 	Hook *HookCall `yaml:"hook,omitempty" json:"hook,omitempty"`
 
-	// Print optionally formats the output for display/logging.
+	// SubChain defines the chain to run (only for SubChain tasks).
+	SubChain *SubChainCall `yaml:"sub_chain,omitempty" json:"subChain,omitempty"`
+
+	// Chat defines the messages to send (only for Chat tasks).
+	Chat *ChatConfig `yaml:"chat,omitempty" json:"chat,omitempty"`
+
+	// Agent defines the tools the model may call (only for Agent tasks).
+	Agent *AgentConfig `yaml:"agent,omitempty" json:"agent,omitempty"`
+
+	// Parallel defines the concurrent branches to run (only for Parallel tasks).
+	Parallel *ParallelConfig `yaml:"parallel,omitempty" json:"parallel,omitempty"`
+
+	// Print optionally renders a message after the task completed, which is added to the
+	// log of the execution (see ExecutionState.Log) and handed to the Printer of the environment.
 	Print string `yaml:"print,omitempty" json:"print,omitempty"`
 
+	// OutputSchema is the JSON Schema the response must satisfy (only for PromptToJSON tasks).
+	OutputSchema map[string]any `yaml:"output_schema,omitempty" json:"outputSchema,omitempty"`
+
+	// RepairAttempts sets how many times an invalid JSON response is sent back
+	// to the model for correction before the task fails (only for PromptToJSON tasks).
+	// Defaults to 2 if unset, 0 disables repairs.
+	RepairAttempts *int `yaml:"repair_attempts,omitempty" json:"repairAttempts,omitempty"`
+
 	// PromptTemplate is the text prompt (with optional template variables) sent to the LLM.
 	PromptTemplate string `yaml:"prompt_template" json:"prompt_template"`
 
@@ -123,11 +279,77 @@ type ChainTask struct {
 	// Timeout optionally sets a timeout for task execution (e.g., "10s", "2m").
 	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
 
-	// PreferredModels optionally lists preferred LLM models to use for this task.
+	// PreferredModels optionally lists preferred LLM models to use for this task, in order of preference.
+	// They are resolved across all pools, the exec repo's model is used if none of them is available.
 	PreferredModels []string `yaml:"preferred_models,omitempty" json:"preferredModels,omitempty"`
 
+	// Generation optionally tunes how the model generates responses for this task.
+	Generation *GenerationOptions `yaml:"generation,omitempty" json:"generation,omitempty"`
+
+	// Cache optionally reuses earlier responses to the same prompt, overriding the cache of the chain.
+	Cache *CacheConfig `yaml:"cache,omitempty" json:"cache,omitempty"`
+
 	// RetryOnError sets how many times to retry this task on failure.
+	// It takes precedence over the MaxRetries of the retry policy.
 	RetryOnError int `yaml:"retry_on_error,omitempty" json:"retryOnError,omitempty"`
+
+	// Retry optionally configures how failed attempts are retried, overriding the retry policy of the chain.
+	Retry *RetryPolicy `yaml:"retry,omitempty" json:"retry,omitempty"`
+
+	// MaxVisits optionally limits how often the chain may enter this task, 0 means no limit.
+	// Exceeding it fails the execution with a LoopLimitError.
+	MaxVisits int `yaml:"max_visits,omitempty" json:"maxVisits,omitempty"`
+}
+
+// GenerationOptions are the sampling parameters of a task's prompts. Unset fields use the model defaults,
+// except Temperature which defaults to 0 for tasks.
+type GenerationOptions struct {
+	Temperature *float64 `yaml:"temperature,omitempty" json:"temperature,omitempty"`
+	TopP        *float64 `yaml:"top_p,omitempty" json:"topP,omitempty"`
+	Seed        *int     `yaml:"seed,omitempty" json:"seed,omitempty"`
+	Stop        []string `yaml:"stop,omitempty" json:"stop,omitempty"`
+
+	// MaxTokens limits the length of each response, 0 means no limit.
+	MaxTokens int `yaml:"max_tokens,omitempty" json:"maxTokens,omitempty"`
+}
+
+// CacheConfig enables the response cache for the prompts of a task or chain.
+// It requires an executor created with WithResponseCache.
+type CacheConfig struct {
+	// TTL is how long a cached response is reused (e.g., "24h"), the server keeps responses at most 24h.
+	TTL string `yaml:"ttl" json:"ttl"`
+}
+
+// RetryPolicy configures how failed task attempts are retried.
+// Without a policy, failed attempts are retried immediately, RetryOnError times.
+type RetryPolicy struct {
+	// MaxRetries is how many times to retry a failed attempt, unless the task sets RetryOnError.
+	MaxRetries int `yaml:"max_retries,omitempty" json:"maxRetries,omitempty"`
+
+	// Backoff is the delay before the first retry (e.g., "500ms"), no delay if empty.
+	Backoff string `yaml:"backoff,omitempty" json:"backoff,omitempty"`
+
+	// MaxBackoff optionally caps the delay between retries.
+	MaxBackoff string `yaml:"max_backoff,omitempty" json:"maxBackoff,omitempty"`
+
+	// Multiplier grows the delay after every retry, defaults to 2.
+	Multiplier float64 `yaml:"multiplier,omitempty" json:"multiplier,omitempty"`
+
+	// Jitter is the fraction of every delay that is randomized, between 0 and 1.
+	// A jitter of 0.5 waits between half and all of the delay.
+	Jitter float64 `yaml:"jitter,omitempty" json:"jitter,omitempty"`
+
+	// RetryOn optionally restricts retries to errors of the listed classes.
+	// Failures of other classes fail the task immediately.
+	RetryOn []ErrorClass `yaml:"retry_on,omitempty" json:"retryOn,omitempty"`
+
+	// FallbackModels are used for retries in order, the last one for all remaining retries.
+	// They replace the PreferredModels of the task.
+	FallbackModels []string `yaml:"fallback_models,omitempty" json:"fallbackModels,omitempty"`
+
+	// CorrectivePrompt tells the model why its previous answer was invalid
+	// when retrying after a parse error.
+	CorrectivePrompt bool `yaml:"corrective_prompt,omitempty" json:"correctivePrompt,omitempty"`
 }
 
 // ChainWithTrigger is a convenience struct that combines triggers and chain definition.
@@ -158,8 +380,27 @@ type ChainDefinition struct {
 	Tasks []ChainTask `yaml:"tasks" json:"tasks"`
 
 	// MaxTokenSize is the token limit for the context window (used during execution).
+	// It budgets the tokens of all prompts of an execution, 0 means no budget.
 	MaxTokenSize int64 `yaml:"max_token_size" json:"maxTokenSize"`
 
+	// TokenPolicy applies to prompts exceeding MaxTokenSize or the context length of their model.
+	// Defaults to TokenPolicyFail.
+	TokenPolicy TokenPolicy `yaml:"token_policy,omitempty" json:"tokenPolicy,omitempty"`
+
 	// RoutingStrategy defines how transitions should be evaluated (optional).
 	RoutingStrategy string `yaml:"routing_strategy" json:"routingStrategy"`
+
+	// MaxSteps limits how many tasks the chain may enter in one execution,
+	// counting every revisit. Defaults to DefaultMaxSteps.
+	MaxSteps int `yaml:"max_steps,omitempty" json:"maxSteps,omitempty"`
+
+	// Timeout optionally limits the wall-clock time of the whole execution (e.g., "5m"),
+	// including all retries.
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// Cache optionally enables the response cache for every prompt of the chain.
+	Cache *CacheConfig `yaml:"cache,omitempty" json:"cache,omitempty"`
+
+	// Retry optionally sets the retry policy of all tasks and parallel branches without their own.
+	Retry *RetryPolicy `yaml:"retry,omitempty" json:"retry,omitempty"`
 }
@@ -0,0 +1,468 @@
+package taskengine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+	"unicode"
+)
+
+// Tokenizer counts the tokens a model sees for a text.
+type Tokenizer interface {
+	CountTokens(ctx context.Context, modelName string, prompt string) (int, error)
+}
+
+// WithTokenizer enables the token functions of templates.
+// Tokens are counted for the first preferred model of a task, or for model if it has none.
+func WithTokenizer(tokenizer Tokenizer, model string) EnvOption {
+	return func(env *SimpleEnv) {
+		env.tokenizer = tokenizer
+		env.tokenizerModel = model
+	}
+}
+
+// TemplateLimits bounds the rendering of a single template.
+type TemplateLimits struct {
+	// MaxOutputBytes is the maximum size of the rendered text.
+	MaxOutputBytes int
+
+	// Timeout is the maximum time rendering may take.
+	Timeout time.Duration
+}
+
+// DefaultTemplateLimits apply to limits that are not set.
+var DefaultTemplateLimits = TemplateLimits{
+	MaxOutputBytes: 1 << 20,
+	Timeout:        5 * time.Second,
+}
+
+// WithTemplateLimits overrides the DefaultTemplateLimits of the environment.
+func WithTemplateLimits(limits TemplateLimits) EnvOption {
+	return func(env *SimpleEnv) {
+		env.templateLimits = limits
+	}
+}
+
+// ErrTemplateLimit is returned when rendering a template exceeds its TemplateLimits,
+// or when a template is rejected because it could not be stopped by them.
+var ErrTemplateLimit = errors.New("template limit exceeded")
+
+// maxTemplateRangeIterations bounds the iterations of ranges over integer constants such as
+// {{ range 1000 }}, nested ranges multiply. Such loops need not write any output, so the
+// timeout of TemplateLimits cannot stop them and they are rejected when parsed instead.
+const maxTemplateRangeIterations = 10000
+
+// maxCachedTemplates bounds templateCache, chains are user-defined so the number
+// of distinct templates is not.
+const maxCachedTemplates = 1024
+
+// templateRuntime is what the template functions need while a template is executed.
+type templateRuntime struct {
+	ctx       context.Context
+	tokenizer Tokenizer
+	model     string
+}
+
+// templateFuncs returns the functions available in every template.
+//
+// None of them can reach the file system, the environment or the network, except for the
+// token functions asking the configured tokenizer. With a nil runtime the token functions fail,
+// which is enough for parsing.
+func templateFuncs(rt *templateRuntime) template.FuncMap {
+	return template.FuncMap{
+		"json":           templateJSON,
+		"join":           templateJoin,
+		"split":          templateSplit,
+		"upper":          strings.ToUpper,
+		"lower":          strings.ToLower,
+		"trim":           strings.TrimSpace,
+		"replace":        templateReplace,
+		"default":        templateDefault,
+		"truncate":       templateTruncate,
+		"now":            templateNow,
+		"date":           templateDate,
+		"countTokens":    rt.countTokens,
+		"truncateTokens": rt.truncateTokens,
+	}
+}
+
+// templateCache holds parsed templates by kind and source, see compileTemplates.
+var templateCache = newLRUCache[templateKey, *template.Template](maxCachedTemplates)
+
+type templateKey struct {
+	hookArg bool
+	source  string
+}
+
+// parseTemplate returns the parsed template for source, parsing it on first use.
+// Hook arg templates may additionally call the secret function.
+func parseTemplate(source string, hookArg bool) (*template.Template, error) {
+	key := templateKey{hookArg: hookArg, source: source}
+	if cached, ok := templateCache.Get(key); ok {
+		return cached, nil
+	}
+	name, funcs := "prompt", templateFuncs(nil)
+	if hookArg {
+		name = "hook_arg"
+		for fn, impl := range hookArgFuncs(nil) {
+			funcs[fn] = impl
+		}
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	for _, defined := range tmpl.Templates() {
+		if defined.Tree == nil {
+			continue
+		}
+		if err := checkTemplateRanges(defined.Tree.Root, 1); err != nil {
+			return nil, err
+		}
+	}
+	templateCache.Add(key, tmpl)
+	return tmpl, nil
+}
+
+// checkTemplateRanges rejects ranges over integer constants below node that would iterate
+// more than maxTemplateRangeIterations times, iterations is the product of the enclosing ones.
+func checkTemplateRanges(node parse.Node, iterations int64) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkTemplateRanges(child, iterations); err != nil {
+				return err
+			}
+		}
+	case *parse.IfNode:
+		return checkTemplateBranch(&n.BranchNode, iterations)
+	case *parse.WithNode:
+		return checkTemplateBranch(&n.BranchNode, iterations)
+	case *parse.RangeNode:
+		if count, ok := rangeConstant(n.Pipe); ok && count > 0 {
+			if count > maxTemplateRangeIterations/iterations {
+				return fmt.Errorf("%w: range over %d would iterate more than %d times", ErrTemplateLimit, count, maxTemplateRangeIterations)
+			}
+			iterations *= count
+		}
+		return checkTemplateBranch(&n.BranchNode, iterations)
+	}
+	return nil
+}
+
+func checkTemplateBranch(branch *parse.BranchNode, iterations int64) error {
+	if err := checkTemplateRanges(branch.List, iterations); err != nil {
+		return err
+	}
+	return checkTemplateRanges(branch.ElseList, iterations)
+}
+
+// rangeConstant returns the integer a range pipeline consists of, if it is a constant.
+func rangeConstant(pipe *parse.PipeNode) (int64, bool) {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return 0, false
+	}
+	number, ok := pipe.Cmds[0].Args[0].(*parse.NumberNode)
+	if !ok || !number.IsInt {
+		return 0, false
+	}
+	return number.Int64, true
+}
+
+// compileTemplates parses every template of the chain once before it runs,
+// so tasks executed repeatedly render from the cache and syntax errors surface early.
+func compileTemplates(chain *ChainDefinition) error {
+	for i := range chain.Tasks {
+		if err := compileTaskTemplates(&chain.Tasks[i]); err != nil {
+			return fmt.Errorf("task %s: %w", chain.Tasks[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func compileTaskTemplates(task *ChainTask) error {
+	if _, err := parseTemplate(task.PromptTemplate, false); err != nil {
+		return fmt.Errorf("template error: %v", err)
+	}
+	if _, err := parseTemplate(task.Print, false); err != nil {
+		return fmt.Errorf("print template error: %v", err)
+	}
+	if task.Chat != nil {
+		for i, msg := range task.Chat.Messages {
+			if _, err := parseTemplate(msg.Content, false); err != nil {
+				return fmt.Errorf("chat message %d: template error: %v", i, err)
+			}
+		}
+	}
+	if task.SubChain != nil {
+		for name, source := range task.SubChain.Vars {
+			if _, err := parseTemplate(source, false); err != nil {
+				return fmt.Errorf("sub-chain var %s: template error: %v", name, err)
+			}
+		}
+	}
+	if task.Hook != nil {
+		for name, source := range task.Hook.Args {
+			if _, err := parseTemplate(source, true); err != nil {
+				return fmt.Errorf("hook arg %s: template error: %v", name, err)
+			}
+		}
+	}
+	if task.Parallel != nil {
+		for i := range task.Parallel.Branches {
+			branch := &task.Parallel.Branches[i]
+			if err := compileTaskTemplates(branch); err != nil {
+				return fmt.Errorf("branch %s: %w", branch.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// renderTemplate renders a template of task with the execution variables.
+func (exe SimpleEnv) renderTemplate(ctx context.Context, task *ChainTask, source string, vars map[string]any) (string, error) {
+	tmpl, err := parseTemplate(source, false)
+	if err != nil {
+		return "", err
+	}
+	return exe.executeTemplate(ctx, task, tmpl, nil, vars)
+}
+
+// executeTemplate executes a parsed template within the TemplateLimits of the environment.
+// funcs are bound in addition to the template functions of the task.
+func (exe SimpleEnv) executeTemplate(ctx context.Context, task *ChainTask, tmpl *template.Template, funcs template.FuncMap, vars map[string]any) (string, error) {
+	limits := exe.templateLimits
+	if limits.MaxOutputBytes <= 0 {
+		limits.MaxOutputBytes = DefaultTemplateLimits.MaxOutputBytes
+	}
+	if limits.Timeout <= 0 {
+		limits.Timeout = DefaultTemplateLimits.Timeout
+	}
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	rt := &templateRuntime{ctx: ctx, tokenizer: exe.tokenizer, model: exe.tokenModel(task)}
+	// The cached template is shared, its clone gets the functions bound to this execution.
+	bound, err := tmpl.Clone()
+	if err != nil {
+		return "", err
+	}
+	bound.Funcs(templateFuncs(rt))
+	if funcs != nil {
+		bound.Funcs(funcs)
+	}
+
+	out := &limitedWriter{ctx: ctx, limit: limits.MaxOutputBytes}
+	done := make(chan error, 1)
+	go func() {
+		done <- bound.Execute(out, vars)
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// The writer stops the template at its next output.
+		err = ctx.Err()
+	}
+	if err != nil && ctx.Err() != nil && parent.Err() == nil {
+		return "", fmt.Errorf("%w: rendering took longer than %s", ErrTemplateLimit, limits.Timeout)
+	}
+	if err != nil {
+		return "", err
+	}
+	return out.buf.String(), nil
+}
+
+// limitedWriter fails writes beyond its limit or after its context is done.
+type limitedWriter struct {
+	ctx   context.Context
+	limit int
+	buf   bytes.Buffer
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if w.buf.Len()+len(p) > w.limit {
+		return 0, fmt.Errorf("%w: output is larger than %d bytes", ErrTemplateLimit, w.limit)
+	}
+	return w.buf.Write(p)
+}
+
+func templateJSON(value any) (string, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// templateJoin joins the elements of a list, formatting elements that are not strings.
+func templateJoin(sep string, list any) (string, error) {
+	if list == nil {
+		return "", nil
+	}
+	if s, ok := list.([]string); ok {
+		return strings.Join(s, sep), nil
+	}
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: expected a list, got %T", list)
+	}
+	parts := make([]string, v.Len())
+	for i := range parts {
+		parts[i] = formatValue(v.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func templateSplit(sep, s string) []string {
+	return strings.Split(s, sep)
+}
+
+func templateReplace(old, new, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+// templateDefault returns def if value is missing, empty or zero.
+func templateDefault(def any, value any) any {
+	if value == nil {
+		return def
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if v.Len() == 0 {
+			return def
+		}
+	default:
+		if v.IsZero() {
+			return def
+		}
+	}
+	return value
+}
+
+// templateTruncate shortens s to at most n characters.
+func templateTruncate(n int, s string) string {
+	if n < 0 {
+		n = 0
+	}
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+func templateNow() time.Time {
+	return time.Now().UTC()
+}
+
+// templateDate formats a time, an RFC 3339 string or Unix seconds with a Go time layout.
+func templateDate(layout string, value any) (string, error) {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return "", fmt.Errorf("date: %v", err)
+		}
+		t = parsed
+	case int:
+		t = time.Unix(int64(v), 0).UTC()
+	case int64:
+		t = time.Unix(v, 0).UTC()
+	case float64:
+		t = time.Unix(int64(v), 0).UTC()
+	default:
+		return "", fmt.Errorf("date: unsupported value %T", value)
+	}
+	return t.Format(layout), nil
+}
+
+func (rt *templateRuntime) count(s string) (int, error) {
+	if rt == nil || rt.tokenizer == nil {
+		return 0, fmt.Errorf("token functions are not available in this environment")
+	}
+	return rt.tokenizer.CountTokens(rt.ctx, rt.model, s)
+}
+
+func (rt *templateRuntime) countTokens(s string) (int, error) {
+	return rt.count(s)
+}
+
+// truncateTokens shortens s to the longest prefix of at most n tokens,
+// cutting between words unless not even the first word fits.
+func (rt *templateRuntime) truncateTokens(n int, s string) (string, error) {
+	count, err := rt.count(s)
+	if err != nil {
+		return "", err
+	}
+	if count <= n {
+		return s, nil
+	}
+	var cuts []int
+	inWord := false
+	for i, r := range s {
+		space := unicode.IsSpace(r)
+		if space && inWord {
+			cuts = append(cuts, i)
+		}
+		inWord = !space
+	}
+	cut, err := rt.longestFit(s, cuts, n)
+	if err != nil {
+		return "", err
+	}
+	if cut == 0 {
+		word := strings.TrimLeftFunc(s, unicode.IsSpace)
+		offset := len(s) - len(word)
+		if end := strings.IndexFunc(word, unicode.IsSpace); end >= 0 {
+			word = word[:end]
+		}
+		cuts = cuts[:0]
+		for i := range word {
+			if i > 0 {
+				cuts = append(cuts, offset+i)
+			}
+		}
+		if cut, err = rt.longestFit(s, cuts, n); err != nil {
+			return "", err
+		}
+	}
+	return s[:cut], nil
+}
+
+// longestFit returns the largest of the ascending cuts whose prefix of s has at most n tokens, or 0.
+func (rt *templateRuntime) longestFit(s string, cuts []int, n int) (int, error) {
+	best := 0
+	low, high := 0, len(cuts)-1
+	for low <= high {
+		mid := (low + high) / 2
+		count, err := rt.count(s[:cuts[mid]])
+		if err != nil {
+			return 0, err
+		}
+		if count <= n {
+			best = cuts[mid]
+			low = mid + 1
+		} else {
+			high = mid - 1
+		}
+	}
+	return best, nil
+}
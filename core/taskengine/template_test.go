@@ -0,0 +1,142 @@
+package taskengine_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/contenox/contenox/core/serverops"
+	"github.com/contenox/contenox/core/services/tokenizerservice"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/stretchr/testify/require"
+)
+
+func templateChain(prompt string) *taskengine.ChainDefinition {
+	return &taskengine.ChainDefinition{
+		ID: "render",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "render",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: prompt,
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+}
+
+func TestSimpleEnv_TemplateFunctions(t *testing.T) {
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, &recordingExecutor{},
+		taskengine.WithTokenizer(tokenizerservice.MockTokenizer{}, "tasks-model"),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		prompt string
+		want   string
+	}{
+		{name: "json", prompt: `{{ json .loop.visits }}`, want: `{"render":1}`},
+		{name: "join", prompt: `{{ split "," .input | join " | " }}`, want: "a | b | c"},
+		{name: "strings", prompt: `{{ .input | upper | replace "," ";" }}`, want: "A;B;C"},
+		{name: "default", prompt: `{{ .missing | default "none" }}`, want: "none"},
+		{name: "truncate", prompt: `{{ truncate 3 "abcdef" }}`, want: "abc"},
+		{name: "date", prompt: `{{ date "2006-01-02" "2025-03-04T05:06:07Z" }}`, want: "2025-03-04"},
+		{name: "count tokens", prompt: `{{ countTokens "one two three" }}`, want: "3"},
+		{name: "truncate tokens", prompt: `{{ truncateTokens 2 "one two  three four" }}`, want: "one two"},
+		{name: "truncate tokens within budget", prompt: `{{ truncateTokens 5 "one two" }}`, want: "one two"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := env.ExecEnv(context.Background(), templateChain(tt.prompt), "a,b,c")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, output)
+		})
+	}
+}
+
+func TestSimpleEnv_TemplateTokenFunctionsRequireTokenizer(t *testing.T) {
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, &recordingExecutor{})
+	require.NoError(t, err)
+
+	_, err = env.ExecEnv(context.Background(), templateChain(`{{ truncateTokens 2 .input }}`), "one two three")
+	require.ErrorContains(t, err, "token functions are not available")
+}
+
+func TestSimpleEnv_TemplateLimits(t *testing.T) {
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, &recordingExecutor{},
+		taskengine.WithTemplateLimits(taskengine.TemplateLimits{MaxOutputBytes: 16, Timeout: 50 * time.Millisecond}),
+	)
+	require.NoError(t, err)
+
+	_, err = env.ExecEnv(context.Background(), templateChain(`{{ range split "" .input }}{{ . }}{{ . }}{{ end }}`), "0123456789")
+	require.ErrorContains(t, err, taskengine.ErrTemplateLimit.Error())
+	require.ErrorContains(t, err, "larger than 16 bytes")
+
+	output, err := env.ExecEnv(context.Background(), templateChain(`{{ .input }}`), "0123456789")
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", output)
+}
+
+func TestSimpleEnv_TemplateTimeout(t *testing.T) {
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, &recordingExecutor{},
+		taskengine.WithTemplateLimits(taskengine.TemplateLimits{MaxOutputBytes: 1 << 30, Timeout: 10 * time.Millisecond}),
+	)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = env.ExecEnv(context.Background(), templateChain(`{{ range split "" .input }}{{ range split "" $.input }}.{{ end }}{{ end }}`), strings.Repeat("x", 5000))
+	require.ErrorContains(t, err, taskengine.ErrTemplateLimit.Error()+": rendering took longer than 10ms")
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestSimpleEnv_TemplateRangeLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		prompt string
+		want   string
+		err    string
+	}{
+		{name: "small range", prompt: `{{ range 3 }}x{{ end }}`, want: "xxx"},
+		{name: "large range", prompt: `{{ range 100000000000 }}{{ end }}`, err: "range over 100000000000 would iterate more than 10000 times"},
+		{name: "nested ranges", prompt: `{{ range 200 }}{{ range $i := 100 }}{{ end }}{{ end }}`, err: "range over 100 would iterate more than 10000 times"},
+		{name: "range in branch", prompt: `{{ if .input }}{{ with .input }}{{ range 20000 }}{{ end }}{{ end }}{{ end }}`, err: "range over 20000"},
+		{name: "range in defined template", prompt: `{{ define "loop" }}{{ range 20000 }}{{ end }}{{ end }}{{ template "loop" }}`, err: "range over 20000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &recordingExecutor{}
+			env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec)
+			require.NoError(t, err)
+
+			output, err := env.ExecEnv(context.Background(), templateChain(tt.prompt), "go")
+			if tt.err != "" {
+				require.ErrorContains(t, err, taskengine.ErrTemplateLimit.Error()+": "+tt.err)
+				require.Empty(t, exec.executed)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, output)
+		})
+	}
+}
+
+func TestSimpleEnv_TemplateSyntaxErrorsFailBeforeExecution(t *testing.T) {
+	exec := &recordingExecutor{}
+	env, err := taskengine.NewEnv(context.Background(), serverops.NoopTracker{}, exec)
+	require.NoError(t, err)
+
+	chain := templateChain("{{ .input }}")
+	chain.Tasks[0].Transition.Next[0].ID = "broken"
+	chain.Tasks = append(chain.Tasks, taskengine.ChainTask{
+		ID:             "broken",
+		Type:           taskengine.PromptToString,
+		PromptTemplate: "{{ unknownFunc .input }}",
+	})
+	_, err = env.ExecEnv(context.Background(), chain, "hello")
+	require.ErrorContains(t, err, "task broken: template error")
+	require.Empty(t, exec.executed)
+}
@@ -0,0 +1,214 @@
+package taskengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/contenox/contenox/core/llmresolver"
+)
+
+// TokenPolicy decides what happens to a prompt that exceeds the token budget of its chain
+// or the context length of its model.
+type TokenPolicy string
+
+const (
+	// TokenPolicyFail fails the task, it is the default.
+	TokenPolicyFail TokenPolicy = "fail"
+
+	// TokenPolicyTruncate cuts the prompt to the tokens left.
+	TokenPolicyTruncate TokenPolicy = "truncate"
+
+	// TokenPolicySummarize replaces the outputs of the earliest tasks with summaries
+	// until the re-rendered prompt fits.
+	TokenPolicySummarize TokenPolicy = "summarize"
+)
+
+var tokenPolicies = map[TokenPolicy]struct{}{
+	"":                   {},
+	TokenPolicyFail:      {},
+	TokenPolicyTruncate:  {},
+	TokenPolicySummarize: {},
+}
+
+// ErrTokenLimit is returned for prompts exceeding the token budget of their chain or the context of their model.
+var ErrTokenLimit = errors.New("token limit exceeded")
+
+// ContextLimiter is implemented by TaskExecutors that know the context length
+// of the model the prompts of a task are sent to.
+type ContextLimiter interface {
+	// ContextLength returns the context length in tokens, 0 if it is unknown.
+	ContextLength(ctx context.Context, task *ChainTask) (int, error)
+}
+
+// promptsModel reports whether the rendered prompt of tasks of type t is sent to a model.
+func promptsModel(t TaskType) bool {
+	switch t {
+	case Hook, Parallel, SubChain, Approval:
+		return false
+	}
+	return true
+}
+
+// tokenModel returns the model tokens of task are counted for.
+func (exe SimpleEnv) tokenModel(task *ChainTask) string {
+	if task != nil && len(task.PreferredModels) > 0 {
+		return task.PreferredModels[0]
+	}
+	return exe.tokenizerModel
+}
+
+// summaryPrompt asks a model to shorten the output of an earlier task.
+const summaryPrompt = "Summarize the following text as briefly as possible, keeping every fact that may be needed later. Respond with the summary only.\n\n"
+
+// tokenMeterKey carries the tokenMeter of the running task.
+type tokenMeterKey struct{}
+
+// tokenMeter counts the tokens a task sends to models, across all its attempts, chat messages
+// and summaries, toward the usage of its execution.
+type tokenMeter struct {
+	mu     sync.Mutex
+	env    SimpleEnv
+	chain  *ChainDefinition
+	state  *ExecutionState
+	taskID string
+}
+
+// withTokenMeter returns a context in which the tokens sent for task are counted, see chargeTokens.
+// Without a tokenizer nothing is counted.
+func (exe SimpleEnv) withTokenMeter(ctx context.Context, chain *ChainDefinition, state *ExecutionState, task *ChainTask) context.Context {
+	if exe.tokenizer == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tokenMeterKey{}, &tokenMeter{env: exe, chain: chain, state: state, taskID: task.ID})
+}
+
+// chargeTokens adds the tokens of texts, sent to a model together, to the usage of the running task.
+// It fails with ErrTokenLimit if they exceed what is left of the budget of the chain.
+func chargeTokens(ctx context.Context, task *ChainTask, texts ...string) error {
+	meter, ok := ctx.Value(tokenMeterKey{}).(*tokenMeter)
+	if !ok {
+		return nil
+	}
+	count := 0
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+		n, err := meter.env.tokenizer.CountTokens(ctx, meter.env.tokenModel(task), text)
+		if err != nil {
+			return fmt.Errorf("failed to count tokens: %w", err)
+		}
+		count += n
+	}
+
+	meter.mu.Lock()
+	defer meter.mu.Unlock()
+	state := meter.state
+	if budget := int(meter.chain.MaxTokenSize); budget > 0 && state.TotalTokens+count > budget {
+		return fmt.Errorf("%w: sending %d tokens, %d of the budget of chain %s are left", ErrTokenLimit, count, budget-state.TotalTokens, meter.chain.ID)
+	}
+	if state.TokenUsage == nil {
+		state.TokenUsage = map[string]int{}
+	}
+	state.TokenUsage[meter.taskID] += count
+	state.TotalTokens += count
+	return nil
+}
+
+// budgetPrompt counts the tokens of the rendered prompt of task and applies the TokenPolicy of
+// chain if the prompt does not fit. render renders the prompt again after variables were summarized.
+// The tokens are added to the usage of the execution once they are sent, see chargeTokens.
+//
+// Without a tokenizer neither the budget nor the context length is enforced.
+func (exe SimpleEnv) budgetPrompt(ctx context.Context, resolver llmresolver.Policy, chain *ChainDefinition, state *ExecutionState, task *ChainTask, prompt string, render func() (string, error)) (string, error) {
+	if exe.tokenizer == nil || prompt == "" || !promptsModel(task.Type) {
+		return prompt, nil
+	}
+	model := exe.tokenModel(task)
+	limit := 0
+	if limiter, ok := exe.exec.(ContextLimiter); ok {
+		contextLength, err := limiter.ContextLength(ctx, task)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve context length: %w", err)
+		}
+		limit = contextLength
+	}
+	if chain.MaxTokenSize > 0 {
+		remaining := int(chain.MaxTokenSize) - state.TotalTokens
+		if remaining <= 0 {
+			return "", fmt.Errorf("%w: chain %s used its budget of %d tokens", ErrTokenLimit, chain.ID, chain.MaxTokenSize)
+		}
+		if limit == 0 || remaining < limit {
+			limit = remaining
+		}
+	}
+
+	count, err := exe.tokenizer.CountTokens(ctx, model, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to count tokens: %w", err)
+	}
+	if limit == 0 || count <= limit {
+		return prompt, nil
+	}
+	switch chain.TokenPolicy {
+	case TokenPolicyTruncate:
+		rt := &templateRuntime{ctx: ctx, tokenizer: exe.tokenizer, model: model}
+		return rt.truncateTokens(limit, prompt)
+	case TokenPolicySummarize:
+		return exe.summarizeVars(ctx, resolver, state, task, count, limit, render)
+	default:
+		return "", fmt.Errorf("%w: prompt has %d tokens, %d are left", ErrTokenLimit, count, limit)
+	}
+}
+
+// summarizeVars summarizes the outputs of the tasks in the order they ran, re-rendering the prompt
+// after every summary until it has at most limit tokens. The summary prompts count toward the usage of task.
+func (exe SimpleEnv) summarizeVars(ctx context.Context, resolver llmresolver.Policy, state *ExecutionState, task *ChainTask, count, limit int, render func() (string, error)) (string, error) {
+	model := exe.tokenModel(task)
+	summarized := map[string]struct{}{task.ID: {}}
+	for _, name := range state.Path {
+		if _, ok := summarized[name]; ok {
+			continue
+		}
+		summarized[name] = struct{}{}
+		value, ok := state.Vars[name]
+		if !ok || value == nil {
+			continue
+		}
+		text := formatValue(value)
+		if text == "" {
+			continue
+		}
+
+		reportErr, reportChange, end := exe.tracker.Start(ctx, "summarize", task.ID, "variable", name)
+		summaryTask := &ChainTask{ID: name + "_summary", Type: PromptToString, PreferredModels: task.PreferredModels}
+		if err := chargeTokens(ctx, task, summaryPrompt+text); err != nil {
+			reportErr(err)
+			end()
+			return "", err
+		}
+		summary, _, err := exe.exec.TaskExec(ctx, resolver, summaryTask, summaryPrompt+text)
+		if err != nil {
+			reportErr(err)
+			end()
+			return "", fmt.Errorf("failed to summarize %s: %w", name, err)
+		}
+		reportChange(name, summary)
+		end()
+		state.Vars[name] = summary
+
+		prompt, err := render()
+		if err != nil {
+			return "", err
+		}
+		if count, err = exe.tokenizer.CountTokens(ctx, model, prompt); err != nil {
+			return "", fmt.Errorf("failed to count tokens: %w", err)
+		}
+		if count <= limit {
+			return prompt, nil
+		}
+	}
+	return "", fmt.Errorf("%w: prompt has %d tokens after summarizing earlier outputs, %d are left", ErrTokenLimit, count, limit)
+}
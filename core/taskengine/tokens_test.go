@@ -0,0 +1,293 @@
+package taskengine_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/contenox/contenox/core/llmrepo"
+	"github.com/contenox/contenox/core/modelprovider"
+	"github.com/contenox/contenox/core/services/tokenizerservice"
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func budgetChain(policy taskengine.TokenPolicy) *taskengine.ChainDefinition {
+	return &taskengine.ChainDefinition{
+		ID:           "budget",
+		MaxTokenSize: 7,
+		TokenPolicy:  policy,
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "first",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "{{ .input }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "second"}},
+				},
+			},
+			{
+				ID:             "second",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "{{ .first }} five six",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+}
+
+func renderedPrompts(tracker *recordingTracker, taskID string) []any {
+	var prompts []any
+	for _, op := range tracker.operations {
+		if op.operation == "task_attempt" && op.subject == taskID {
+			prompts = append(prompts, op.args[5])
+		}
+	}
+	return prompts
+}
+
+func TestSimpleEnv_TokenBudget(t *testing.T) {
+	run := func(t *testing.T, policy taskengine.TokenPolicy) (*taskengine.ExecutionState, *recordingTracker, error) {
+		exec := &scriptedExecutor{outputs: map[string]any{
+			"first":         "one two three four",
+			"first_summary": "short",
+			"second":        "done",
+		}}
+		tracker := &recordingTracker{}
+		checkpointer := &memoryCheckpointer{}
+		env, err := taskengine.NewEnv(context.Background(), tracker, exec,
+			taskengine.WithTokenizer(tokenizerservice.MockTokenizer{}, "tasks-model"),
+			taskengine.WithCheckpointer(checkpointer),
+		)
+		require.NoError(t, err)
+		_, err = env.ExecEnv(context.Background(), budgetChain(policy), "a b")
+		last := checkpointer.checkpoints[len(checkpointer.checkpoints)-1]
+		return &last, tracker, err
+	}
+
+	t.Run("fail", func(t *testing.T) {
+		state, _, err := run(t, "")
+		require.ErrorIs(t, err, taskengine.ErrTokenLimit)
+		require.ErrorContains(t, err, "task second: token limit exceeded: prompt has 6 tokens, 5 are left")
+		require.Equal(t, map[string]int{"first": 2}, state.TokenUsage)
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		state, tracker, err := run(t, taskengine.TokenPolicyTruncate)
+		require.NoError(t, err)
+		require.Equal(t, []any{"one two three four five"}, renderedPrompts(tracker, "second"))
+		require.Equal(t, map[string]int{"first": 2, "second": 5}, state.TokenUsage)
+		require.Equal(t, 7, state.TotalTokens)
+	})
+}
+
+func TestSimpleEnv_TokenBudgetOfTimedTasks(t *testing.T) {
+	exec := &scriptedExecutor{outputs: map[string]any{"first": "one two three four", "second": "done"}}
+	checkpointer := &memoryCheckpointer{}
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, exec,
+		taskengine.WithTokenizer(tokenizerservice.MockTokenizer{}, "tasks-model"),
+		taskengine.WithCheckpointer(checkpointer),
+	)
+	require.NoError(t, err)
+	chain := budgetChain("")
+	for i := range chain.Tasks {
+		chain.Tasks[i].Timeout = "1m"
+	}
+
+	_, err = env.ExecEnv(context.Background(), chain, "a b")
+	require.ErrorIs(t, err, taskengine.ErrTokenLimit)
+	require.ErrorContains(t, err, "task second: token limit exceeded: prompt has 6 tokens, 5 are left")
+	state := checkpointer.checkpoints[len(checkpointer.checkpoints)-1]
+	require.Equal(t, map[string]int{"first": 2}, state.TokenUsage)
+}
+
+// limitedExecutor is a scriptedExecutor serving a model with a fixed context length.
+type limitedExecutor struct {
+	scriptedExecutor
+	contextLength int
+}
+
+func (l *limitedExecutor) ContextLength(context.Context, *taskengine.ChainTask) (int, error) {
+	return l.contextLength, nil
+}
+
+func TestSimpleEnv_TokenPolicySummarize(t *testing.T) {
+	run := func(t *testing.T, secondPrompt string) (*taskengine.ExecutionState, *recordingTracker, error) {
+		exec := &limitedExecutor{contextLength: 5, scriptedExecutor: scriptedExecutor{outputs: map[string]any{
+			"first":         "one two three four",
+			"first_summary": "short",
+			"second":        "done",
+		}}}
+		checkpointer := &memoryCheckpointer{}
+		tracker := &recordingTracker{}
+		env, err := taskengine.NewEnv(context.Background(), tracker, exec,
+			taskengine.WithTokenizer(tokenizerservice.MockTokenizer{}, "tasks-model"),
+			taskengine.WithCheckpointer(checkpointer),
+		)
+		require.NoError(t, err)
+		chain := budgetChain(taskengine.TokenPolicySummarize)
+		chain.MaxTokenSize = 0
+		chain.Tasks[1].PromptTemplate = secondPrompt
+		_, err = env.ExecEnv(context.Background(), chain, "a b")
+		last := checkpointer.checkpoints[len(checkpointer.checkpoints)-1]
+		return &last, tracker, err
+	}
+
+	t.Run("fits", func(t *testing.T) {
+		state, tracker, err := run(t, "{{ .first }} five six")
+		require.NoError(t, err)
+		require.Equal(t, []any{"short five six"}, renderedPrompts(tracker, "second"))
+		require.Equal(t, "short", state.Vars["first"])
+		// The summary prompt has 21 words besides the summarized output.
+		require.Equal(t, map[string]int{"first": 2, "second": 25 + 3}, state.TokenUsage)
+		require.Equal(t, 30, state.TotalTokens)
+	})
+
+	t.Run("never fits", func(t *testing.T) {
+		state, tracker, err := run(t, "{{ .first }} five six seven eight nine")
+		require.ErrorIs(t, err, taskengine.ErrTokenLimit)
+		require.ErrorContains(t, err, "prompt has 6 tokens after summarizing earlier outputs, 5 are left")
+		require.Empty(t, renderedPrompts(tracker, "second"))
+		require.Equal(t, map[string]int{"first": 2, "second": 25}, state.TokenUsage)
+	})
+}
+
+func TestSimpleEnv_TokenUsageOfChats(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID: "support",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "lang",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "german",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "greet"}},
+				},
+			},
+			chatTask("greet", "{{ .input }}", "follow_up"),
+			chatTask("follow_up", "and then?", "end"),
+		},
+	}
+	run := func(t *testing.T, maxTokens int64) (*taskengine.ExecutionState, error) {
+		checkpointer := &memoryCheckpointer{}
+		env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, &chatExecutor{},
+			taskengine.WithTokenizer(tokenizerservice.MockTokenizer{}, "tasks-model"),
+			taskengine.WithCheckpointer(checkpointer),
+		)
+		require.NoError(t, err)
+		chain.MaxTokenSize = maxTokens
+		_, err = env.ExecEnv(context.Background(), chain, "hello")
+		last := checkpointer.checkpoints[len(checkpointer.checkpoints)-1]
+		return &last, err
+	}
+
+	// Every chat sends its message templates, the conversation so far and its prompt.
+	state, err := run(t, 0)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"lang": 1, "greet": 4 + 1 + 1 + 1, "follow_up": 4 + 1 + 1 + 3 + 2}, state.TokenUsage)
+	require.Equal(t, 19, state.TotalTokens)
+
+	// The prompt of follow_up fits the budget, the messages sent with it do not.
+	state, err = run(t, 15)
+	require.ErrorIs(t, err, taskengine.ErrTokenLimit)
+	require.ErrorContains(t, err, "task follow_up: token limit exceeded: sending 11 tokens, 7 of the budget of chain support are left")
+	require.Equal(t, map[string]int{"lang": 1, "greet": 7}, state.TokenUsage)
+}
+
+func TestSimpleEnv_TokenUsageOfRetries(t *testing.T) {
+	provider := &modelprovider.MockProvider{
+		Name:            "tasks-model",
+		CanPromptFlag:   true,
+		ContextLength:   2048,
+		ID:              uuid.NewString(),
+		Backends:        []string{"backend"},
+		PromptResponses: []string{"many", "7"},
+	}
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: provider}, taskengine.NewMockHookRegistry())
+	require.NoError(t, err)
+	checkpointer := &memoryCheckpointer{}
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, exec,
+		taskengine.WithTokenizer(tokenizerservice.MockTokenizer{}, "tasks-model"),
+		taskengine.WithCheckpointer(checkpointer),
+	)
+	require.NoError(t, err)
+
+	output, err := env.ExecEnv(context.Background(), countChain(&taskengine.RetryPolicy{MaxRetries: 1, CorrectivePrompt: true}), "the basket")
+	require.NoError(t, err)
+	require.Equal(t, 7, output)
+	require.Len(t, provider.Prompts, 2)
+
+	// Both the first prompt and the corrective prompt of the retry were sent.
+	state := checkpointer.checkpoints[len(checkpointer.checkpoints)-1]
+	require.Equal(t, map[string]int{"count": 7 + 23}, state.TokenUsage)
+}
+
+func TestSimpleEnv_TokenBudgetModelContext(t *testing.T) {
+	provider := &modelprovider.MockProvider{
+		Name:          "tasks-model",
+		CanPromptFlag: true,
+		ContextLength: 3,
+		ID:            uuid.NewString(),
+		Backends:      []string{"backend"},
+	}
+	exec, err := taskengine.NewExec(context.Background(), &llmrepo.MockModelRepo{Provider: provider}, taskengine.NewMockHookRegistry())
+	require.NoError(t, err)
+	env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, exec,
+		taskengine.WithTokenizer(tokenizerservice.MockTokenizer{}, "tasks-model"),
+	)
+	require.NoError(t, err)
+
+	_, err = env.ExecEnv(context.Background(), templateChain("{{ .input }}"), "one two three four")
+	require.ErrorIs(t, err, taskengine.ErrTokenLimit)
+	require.Empty(t, provider.Prompts)
+
+	output, err := env.ExecEnv(context.Background(), templateChain("{{ .input }}"), "one two three")
+	require.NoError(t, err)
+	require.Equal(t, "prompted response for: one two three", output)
+}
+
+func TestSimpleEnv_TokenUsageOfSubChains(t *testing.T) {
+	classify := &taskengine.ChainDefinition{
+		ID: "classify",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "label",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "label {{ .input }} in {{ .lang }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+	run := func(t *testing.T, maxTokens int64) (*taskengine.ExecutionState, error) {
+		checkpointer := &memoryCheckpointer{}
+		env, err := taskengine.NewEnv(context.Background(), &recordingTracker{}, &recordingExecutor{},
+			taskengine.WithTokenizer(tokenizerservice.MockTokenizer{}, "tasks-model"),
+			taskengine.WithChainResolver(mapChainResolver{"classify": classify}),
+			taskengine.WithCheckpointer(checkpointer),
+		)
+		require.NoError(t, err)
+		chain := &taskengine.ChainDefinition{
+			ID:           "support",
+			MaxTokenSize: maxTokens,
+			Tasks:        []taskengine.ChainTask{subChainTask("route", "classify")},
+		}
+		_, err = env.ExecEnv(context.Background(), chain, "ticket")
+		last := checkpointer.checkpoints[len(checkpointer.checkpoints)-1]
+		return &last, err
+	}
+
+	state, err := run(t, 0)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"classify/label": 4}, state.TokenUsage)
+	require.Equal(t, 4, state.TotalTokens)
+
+	// The sub-chain has no budget of its own, its tokens count toward the budget of the caller.
+	state, err = run(t, 3)
+	require.ErrorIs(t, err, taskengine.ErrTokenLimit)
+	require.ErrorContains(t, err, "task route: chain classify: token limit exceeded: the sub-chain used 4 tokens, 3 of the budget of chain support were left")
+	require.Equal(t, 4, state.TotalTokens)
+}
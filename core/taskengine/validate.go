@@ -0,0 +1,593 @@
+package taskengine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// Severity classifies a ValidationIssue.
+type Severity string
+
+const (
+	// SeverityError marks a problem that will make the chain fail at runtime.
+	SeverityError Severity = "error"
+
+	// SeverityWarning marks a problem that may make the chain fail depending on model output.
+	SeverityWarning Severity = "warning"
+)
+
+// ValidationIssue is a single problem found in a ChainDefinition.
+type ValidationIssue struct {
+	// TaskID is the task the issue belongs to, empty for chain level issues.
+	TaskID string `json:"taskId,omitempty"`
+
+	// Severity indicates whether the issue blocks execution.
+	Severity Severity `json:"severity"`
+
+	// Message is a human-readable description of the problem.
+	Message string `json:"message"`
+}
+
+// ValidationResult is the outcome of a static chain validation.
+type ValidationResult struct {
+	// Valid is false if at least one issue has SeverityError.
+	Valid bool `json:"valid"`
+
+	// Issues lists every problem found, in task order.
+	Issues []ValidationIssue `json:"issues"`
+}
+
+// Validate walks the chain graph without executing it and reports every problem found.
+//
+// It reports:
+//   - duplicate, unreachable or missing tasks and dangling transition targets
+//   - transitions without a "_default" branch, unknown operators and expressions that do not type-check
+//   - invalid limits, timeouts, cache TTLs, retry policies and schedules
+//   - hooks and agent tools that are not supported by the registry
+//   - templates and expressions that reference tasks which never run before them
+//
+// If registry is nil, hook names are not checked.
+func Validate(ctx context.Context, chain *ChainDefinition, registry HookRegistry) (*ValidationResult, error) {
+	v := &validator{result: &ValidationResult{Issues: []ValidationIssue{}}}
+	if chain == nil || len(chain.Tasks) == 0 {
+		v.errorf("", "chain has no tasks")
+		return v.done(), nil
+	}
+
+	if chain.MaxSteps < 0 {
+		v.errorf("", "max_steps must not be negative")
+	}
+	if chain.MaxTokenSize < 0 {
+		v.errorf("", "max_token_size must not be negative")
+	}
+	if _, ok := tokenPolicies[chain.TokenPolicy]; !ok {
+		v.errorf("", "unknown token_policy %q", chain.TokenPolicy)
+	}
+	if chain.Timeout != "" {
+		timeout, err := time.ParseDuration(chain.Timeout)
+		if err != nil {
+			v.errorf("", "invalid chain timeout %q: %v", chain.Timeout, err)
+		} else if timeout <= 0 {
+			v.errorf("", "chain timeout %q must be positive", chain.Timeout)
+		}
+	}
+	v.checkCache("", "", chain.Cache)
+	v.checkRetry("", "", chain.Retry)
+
+	for i, trigger := range chain.Triggers {
+		if trigger.Type != TriggerSchedule {
+			continue
+		}
+		if _, err := ParseSchedule(trigger.Pattern, trigger.TimeZone); err != nil {
+			v.errorf("", "schedule trigger at index %d: %v", i, err)
+		}
+	}
+
+	var hooks map[string]struct{}
+	if registry != nil {
+		supported, err := registry.Supports(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list supported hooks: %w", err)
+		}
+		hooks = make(map[string]struct{}, len(supported))
+		for _, name := range supported {
+			hooks[name] = struct{}{}
+		}
+	}
+
+	tasks := make(map[string]*ChainTask, len(chain.Tasks))
+	for i := range chain.Tasks {
+		task := &chain.Tasks[i]
+		if task.ID == "" {
+			v.errorf("", "task at index %d has no id", i)
+			continue
+		}
+		if task.ID == "end" {
+			v.errorf(task.ID, "task id %q is reserved to terminate the chain", task.ID)
+		}
+		if _, ok := tasks[task.ID]; ok {
+			v.errorf(task.ID, "duplicate task id")
+			continue
+		}
+		tasks[task.ID] = task
+	}
+
+	// Branch outputs are stored under their own IDs, so they share the task namespace.
+	branchParent := map[string]string{}
+	for _, task := range chain.Tasks {
+		if task.Type != Parallel || task.Parallel == nil {
+			continue
+		}
+		for _, branch := range task.Parallel.Branches {
+			if branch.ID == "" {
+				continue
+			}
+			_, isTask := tasks[branch.ID]
+			_, isBranch := branchParent[branch.ID]
+			if isTask || isBranch {
+				v.errorf(task.ID, "branch id %q is already in use", branch.ID)
+				continue
+			}
+			branchParent[branch.ID] = task.ID
+		}
+	}
+
+	edges := make(map[string][]string, len(tasks))
+	for _, task := range chain.Tasks {
+		if task.ID == "" {
+			continue
+		}
+		v.checkTask(&task, hooks)
+		for _, target := range transitionTargets(task.Transition) {
+			if _, ok := tasks[target]; !ok {
+				v.errorf(task.ID, "transition target %q does not exist", target)
+				continue
+			}
+			edges[task.ID] = append(edges[task.ID], target)
+		}
+	}
+
+	var types map[string]exprType
+	reachable := walk(chain.Tasks[0].ID, edges)
+	reverse := make(map[string][]string, len(edges))
+	for from, targets := range edges {
+		for _, to := range targets {
+			reverse[to] = append(reverse[to], from)
+		}
+	}
+
+	for _, task := range chain.Tasks {
+		if _, ok := tasks[task.ID]; !ok {
+			continue
+		}
+		if _, ok := reachable[task.ID]; !ok {
+			v.errorf(task.ID, "task is unreachable from %q", chain.Tasks[0].ID)
+			continue
+		}
+		predecessors := walk(task.ID, reverse)
+		// walk always includes the start node, keep it only if the task is part of a cycle.
+		if !inCycle(task.ID, reverse) {
+			delete(predecessors, task.ID)
+		}
+		refs := &templateScope{tasks: tasks, branchParent: branchParent, predecessors: predecessors}
+		v.checkTemplate(task.ID, "prompt_template", task.PromptTemplate, refs)
+		if task.Type == Parallel && task.Parallel != nil {
+			for _, branch := range task.Parallel.Branches {
+				v.checkTemplate(task.ID, fmt.Sprintf("branch %s prompt_template", branch.ID), branch.PromptTemplate, refs)
+			}
+		}
+		if task.Type == SubChain && task.SubChain != nil {
+			for name, tmpl := range task.SubChain.Vars {
+				v.checkTemplate(task.ID, fmt.Sprintf("sub_chain var %s", name), tmpl, refs)
+			}
+		}
+		if task.Type == Hook && task.Hook != nil {
+			names := make([]string, 0, len(task.Hook.Args))
+			for name := range task.Hook.Args {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				v.checkHookArg(task.ID, name, task.Hook.Args[name], refs)
+			}
+		}
+		if task.Type == Chat && task.Chat != nil {
+			for i, msg := range task.Chat.Messages {
+				v.checkTemplate(task.ID, fmt.Sprintf("chat message %d", i), msg.Content, refs)
+			}
+		}
+		// Print and transitions are evaluated after the task ran.
+		predecessors[task.ID] = struct{}{}
+		if task.Print != "" {
+			v.checkTemplate(task.ID, "print", task.Print, refs)
+		}
+		for _, ct := range task.Transition.Next {
+			if ct.When == "" || ct.Value == "_default" {
+				continue
+			}
+			if types == nil {
+				types = expressionTypes(chain)
+			}
+			expr, err := compileExpression(ct.When, types)
+			if err != nil {
+				v.errorf(task.ID, "transition to %q: %v", ct.ID, err)
+				continue
+			}
+			v.checkRefs(task.ID, fmt.Sprintf("transition to %q", ct.ID), expr.refs, refs)
+		}
+	}
+
+	return v.done(), nil
+}
+
+// templateScope describes which variables a template may reference.
+type templateScope struct {
+	tasks        map[string]*ChainTask
+	branchParent map[string]string
+	predecessors map[string]struct{}
+}
+
+type validator struct {
+	result *ValidationResult
+}
+
+func (v *validator) errorf(taskID, format string, args ...any) {
+	v.add(taskID, SeverityError, format, args...)
+}
+
+func (v *validator) warnf(taskID, format string, args ...any) {
+	v.add(taskID, SeverityWarning, format, args...)
+}
+
+func (v *validator) add(taskID string, severity Severity, format string, args ...any) {
+	v.result.Issues = append(v.result.Issues, ValidationIssue{
+		TaskID:   taskID,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) done() *ValidationResult {
+	v.result.Valid = true
+	for _, issue := range v.result.Issues {
+		if issue.Severity == SeverityError {
+			v.result.Valid = false
+			break
+		}
+	}
+	return v.result
+}
+
+// checkTask validates the fields of a single task that do not depend on the rest of the graph.
+func (v *validator) checkTask(task *ChainTask, hooks map[string]struct{}) {
+	v.checkExecution(task.ID, task, hooks)
+	if task.MaxVisits < 0 {
+		v.errorf(task.ID, "max_visits must not be negative")
+	}
+
+	hasDefault := false
+	for _, ct := range task.Transition.Next {
+		if ct.Value == "_default" {
+			hasDefault = true
+			continue
+		}
+		if ct.When != "" {
+			continue
+		}
+		if _, ok := supportedOperators[ct.Operator]; !ok {
+			v.errorf(task.ID, "unknown operator %q in transition to %q", ct.Operator, ct.ID)
+		}
+		if ct.Path != "" && task.Type != PromptToJSON && task.Type != Parallel {
+			v.errorf(task.ID, "transition to %q uses path %q but %s tasks have no structured output", ct.ID, ct.Path, task.Type)
+		}
+	}
+	if !hasDefault {
+		v.warnf(task.ID, "transition has no _default branch, unmatched output will fail the chain")
+	}
+}
+
+// checkExecution validates how a task or parallel branch is executed.
+// Issues are reported on taskID, which for branches is the enclosing Parallel task.
+func (v *validator) checkExecution(taskID string, task *ChainTask, hooks map[string]struct{}) {
+	prefix := ""
+	if taskID != task.ID {
+		prefix = fmt.Sprintf("branch %s: ", task.ID)
+	}
+	switch task.Type {
+	case PromptToString, PromptToNumber, PromptToScore, PromptToRange:
+	case PromptToCondition:
+		if len(task.ConditionMapping) == 0 {
+			v.errorf(taskID, "%scondition task has no condition_mapping", prefix)
+		}
+	case PromptToJSON:
+		for _, problem := range checkSchemaDefinition(task.OutputSchema) {
+			v.errorf(taskID, "%soutput_schema %s", prefix, problem)
+		}
+		if task.RepairAttempts != nil && *task.RepairAttempts < 0 {
+			v.errorf(taskID, "%srepair_attempts must not be negative", prefix)
+		}
+	case Hook:
+		if task.Hook == nil {
+			v.errorf(taskID, "%shook task missing hook definition", prefix)
+		} else if hooks != nil {
+			if _, ok := hooks[task.Hook.Type]; !ok {
+				v.errorf(taskID, "%shook %q is not supported", prefix, task.Hook.Type)
+			}
+		}
+	case SubChain:
+		if task.SubChain == nil || task.SubChain.ChainID == "" {
+			v.errorf(taskID, "%ssub-chain task missing chain_id", prefix)
+		}
+	case Chat:
+		if task.PromptTemplate == "" && (task.Chat == nil || len(task.Chat.Messages) == 0) {
+			v.errorf(taskID, "%schat task has no messages and no prompt_template", prefix)
+		}
+		if task.Chat != nil {
+			for i, msg := range task.Chat.Messages {
+				if _, ok := chatRoles[msg.Role]; !ok {
+					v.errorf(taskID, "%schat message %d has unknown role %q", prefix, i, msg.Role)
+				}
+			}
+			if task.Chat.Conversation && prefix != "" {
+				v.errorf(taskID, "%sparallel branches cannot take part in the chain conversation", prefix)
+			}
+		}
+	case Agent:
+		if task.Agent == nil || len(task.Agent.Tools) == 0 {
+			v.errorf(taskID, "%sagent task has no tools", prefix)
+			break
+		}
+		if task.Agent.MaxIterations < 0 {
+			v.errorf(taskID, "%smax_iterations must not be negative", prefix)
+		}
+		if hooks != nil {
+			for _, tool := range task.Agent.Tools {
+				if _, ok := hooks[tool]; !ok {
+					v.errorf(taskID, "%sagent tool %q is not supported", prefix, tool)
+				}
+			}
+		}
+	case Approval:
+		if prefix != "" {
+			v.errorf(taskID, "%sapproval tasks cannot run as parallel branches", prefix)
+		}
+	case Parallel:
+		if task.Parallel == nil || len(task.Parallel.Branches) == 0 {
+			v.errorf(taskID, "%sparallel task has no branches", prefix)
+			break
+		}
+		if _, err := requiredBranches(task.Parallel); err != nil {
+			v.errorf(taskID, "%s%v", prefix, err)
+		}
+		if task.Parallel.MaxConcurrency < 0 {
+			v.errorf(taskID, "%smax_concurrency must not be negative", prefix)
+		}
+		for i := range task.Parallel.Branches {
+			branch := &task.Parallel.Branches[i]
+			if branch.ID == "" {
+				v.errorf(taskID, "%sbranch at index %d has no id", prefix, i)
+				continue
+			}
+			v.checkExecution(taskID, branch, hooks)
+		}
+	default:
+		v.errorf(taskID, "%sunknown task type %q", prefix, task.Type)
+	}
+
+	if task.Timeout != "" {
+		timeout, err := time.ParseDuration(task.Timeout)
+		if err != nil {
+			v.errorf(taskID, "%sinvalid timeout %q: %v", prefix, task.Timeout, err)
+		} else if timeout <= 0 {
+			v.errorf(taskID, "%stimeout %q must be positive", prefix, task.Timeout)
+		}
+	}
+	if task.RetryOnError < 0 {
+		v.errorf(taskID, "%sretry_on_error must not be negative", prefix)
+	}
+	v.checkCache(taskID, prefix, task.Cache)
+	v.checkRetry(taskID, prefix, task.Retry)
+	if g := task.Generation; g != nil {
+		if g.Temperature != nil && (*g.Temperature < 0 || *g.Temperature > 2) {
+			v.errorf(taskID, "%sgeneration temperature must be between 0 and 2", prefix)
+		}
+		if g.TopP != nil && (*g.TopP <= 0 || *g.TopP > 1) {
+			v.errorf(taskID, "%sgeneration top_p must be greater than 0 and at most 1", prefix)
+		}
+		if g.MaxTokens < 0 {
+			v.errorf(taskID, "%sgeneration max_tokens must not be negative", prefix)
+		}
+	}
+}
+
+func (v *validator) checkCache(taskID, prefix string, cache *CacheConfig) {
+	if cache == nil {
+		return
+	}
+	if cache.TTL == "" {
+		v.errorf(taskID, "%scache has no ttl", prefix)
+		return
+	}
+	ttl, err := time.ParseDuration(cache.TTL)
+	if err != nil {
+		v.errorf(taskID, "%sinvalid cache ttl %q: %v", prefix, cache.TTL, err)
+	} else if ttl <= 0 {
+		v.errorf(taskID, "%scache ttl %q must be positive", prefix, cache.TTL)
+	}
+}
+
+func (v *validator) checkRetry(taskID, prefix string, retry *RetryPolicy) {
+	if retry == nil {
+		return
+	}
+	if retry.MaxRetries < 0 {
+		v.errorf(taskID, "%sretry max_retries must not be negative", prefix)
+	}
+	for _, field := range []struct{ name, value string }{
+		{"backoff", retry.Backoff},
+		{"max_backoff", retry.MaxBackoff},
+	} {
+		if field.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(field.value)
+		if err != nil {
+			v.errorf(taskID, "%sinvalid retry %s %q: %v", prefix, field.name, field.value, err)
+		} else if duration <= 0 {
+			v.errorf(taskID, "%sretry %s %q must be positive", prefix, field.name, field.value)
+		}
+	}
+	if retry.Multiplier != 0 && retry.Multiplier < 1 {
+		v.errorf(taskID, "%sretry multiplier must be at least 1", prefix)
+	}
+	if retry.Jitter < 0 || retry.Jitter > 1 {
+		v.errorf(taskID, "%sretry jitter must be between 0 and 1", prefix)
+	}
+	for _, class := range retry.RetryOn {
+		if _, ok := errorClasses[class]; !ok {
+			v.errorf(taskID, "%sunknown retry error class %q", prefix, class)
+		}
+	}
+}
+
+// checkTemplate reports template syntax errors and field references to tasks
+// that are not guaranteed to have run before the template is rendered.
+func (v *validator) checkTemplate(taskID, field, text string, scope *templateScope) {
+	if text == "" {
+		return
+	}
+	tmpl, err := template.New(field).Funcs(templateFuncs(nil)).Parse(text)
+	if err != nil {
+		v.errorf(taskID, "%s: %v", field, err)
+		return
+	}
+	v.checkRefs(taskID, field, templateRefs(tmpl.Tree), scope)
+}
+
+// checkHookArg is checkTemplate for hook args, which may call the secret function.
+func (v *validator) checkHookArg(taskID, name, text string, scope *templateScope) {
+	field := fmt.Sprintf("hook arg %s", name)
+	tmpl, err := template.New(field).Funcs(templateFuncs(nil)).Funcs(hookArgFuncs(nil)).Parse(text)
+	if err != nil {
+		v.errorf(taskID, "%s: %v", field, err)
+		return
+	}
+	v.checkRefs(taskID, field, templateRefs(tmpl.Tree), scope)
+}
+
+// checkRefs reports references to unknown variables and to tasks that are not guaranteed to have run before.
+func (v *validator) checkRefs(taskID, field string, refs []string, scope *templateScope) {
+	for _, ref := range refs {
+		switch ref {
+		case "input", "previous_output", "loop", "conversation":
+			continue
+		}
+		producer := ref
+		if parent, ok := scope.branchParent[ref]; ok {
+			producer = parent
+		} else if _, ok := scope.tasks[ref]; !ok {
+			v.errorf(taskID, "%s references unknown variable %q", field, ref)
+			continue
+		}
+		if _, ok := scope.predecessors[producer]; !ok {
+			v.errorf(taskID, "%s references task %q which never runs before it", field, ref)
+		}
+	}
+}
+
+// transitionTargets returns every task ID a transition may jump to, excluding chain termination.
+func transitionTargets(transition Transition) []string {
+	var targets []string
+	if transition.OnError != "" && transition.OnError != "end" {
+		targets = append(targets, transition.OnError)
+	}
+	for _, ct := range transition.Next {
+		if ct.ID != "" && ct.ID != "end" {
+			targets = append(targets, ct.ID)
+		}
+	}
+	return targets
+}
+
+// walk returns every node reachable from start, including start itself.
+func walk(start string, edges map[string][]string) map[string]struct{} {
+	seen := map[string]struct{}{start: {}}
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range edges[current] {
+			if _, ok := seen[next]; ok {
+				continue
+			}
+			seen[next] = struct{}{}
+			queue = append(queue, next)
+		}
+	}
+	return seen
+}
+
+// inCycle reports whether id can reach itself through at least one edge.
+func inCycle(id string, edges map[string][]string) bool {
+	for _, next := range edges[id] {
+		if _, ok := walk(next, edges)[id]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// templateRefs returns the top-level variable names referenced as fields (e.g. {{ .task1 }}).
+// Fields inside range and with blocks are skipped since dot is rebound there.
+func templateRefs(tree *parse.Tree) []string {
+	var refs []string
+	var visit func(node parse.Node)
+	visit = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				visit(child)
+			}
+		case *parse.ActionNode:
+			visit(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				visit(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				visit(arg)
+			}
+		case *parse.FieldNode:
+			refs = append(refs, n.Ident[0])
+		case *parse.ChainNode:
+			visit(n.Node)
+		case *parse.IfNode:
+			visit(n.Pipe)
+			visit(n.List)
+			visit(n.ElseList)
+		case *parse.RangeNode:
+			visit(n.Pipe)
+			visit(n.ElseList)
+		case *parse.WithNode:
+			visit(n.Pipe)
+			visit(n.ElseList)
+		case *parse.TemplateNode:
+			visit(n.Pipe)
+		}
+	}
+	if tree != nil {
+		visit(tree.Root)
+	}
+	return refs
+}
@@ -0,0 +1,378 @@
+package taskengine_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/contenox/contenox/core/taskengine"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate_ValidChain(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID: "valid",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "get_length",
+				Type:           taskengine.PromptToNumber,
+				PromptTemplate: "How many words for {{ .input }}?",
+				Timeout:        "10s",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{
+						{Operator: ">", Value: "100", ID: "notify"},
+						{Value: "_default", ID: "generate"},
+					},
+				},
+			},
+			{
+				ID:             "generate",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "Write a {{ .get_length }}-word article about {{ .input }}",
+				Print:          "{{ .generate }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+			{
+				ID:   "notify",
+				Type: taskengine.Hook,
+				Hook: &taskengine.HookCall{Type: "mock"},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "generate"}},
+				},
+			},
+		},
+	}
+
+	result, err := taskengine.Validate(context.Background(), chain, taskengine.NewMockHookRegistry())
+	require.NoError(t, err)
+	require.True(t, result.Valid)
+	require.Empty(t, result.Issues)
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID: "broken",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "first",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "{{ .later }} {{ .missing }}",
+				Timeout:        "soon",
+				Transition: taskengine.Transition{
+					OnError: "nowhere",
+					Next: []taskengine.ConditionalTransition{
+						{Operator: "matches", Value: "x", ID: "later"},
+					},
+				},
+			},
+			{
+				ID:   "later",
+				Type: taskengine.Hook,
+				Hook: &taskengine.HookCall{Type: "send_email"},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+			{
+				ID:             "orphan",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "never",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+
+	result, err := taskengine.Validate(context.Background(), chain, taskengine.NewMockHookRegistry())
+	require.NoError(t, err)
+	require.False(t, result.Valid)
+
+	messages := map[string][]string{}
+	for _, issue := range result.Issues {
+		messages[issue.TaskID] = append(messages[issue.TaskID], issue.Message)
+	}
+	require.ElementsMatch(t, []string{
+		`invalid timeout "soon": time: invalid duration "soon"`,
+		`unknown operator "matches" in transition to "later"`,
+		`transition has no _default branch, unmatched output will fail the chain`,
+		`transition target "nowhere" does not exist`,
+		`prompt_template references task "later" which never runs before it`,
+		`prompt_template references unknown variable "missing"`,
+	}, messages["first"])
+	require.Equal(t, []string{`hook "send_email" is not supported`}, messages["later"])
+	require.Equal(t, []string{`task is unreachable from "first"`}, messages["orphan"])
+}
+
+func TestValidate_LoopAllowsSelfReference(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID: "loop",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "refine",
+				Type:           taskengine.PromptToScore,
+				PromptTemplate: "Improve: {{ .input }} {{ if .refine }}{{ .refine }}{{ end }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{
+						{Operator: "<", Value: "8", ID: "refine"},
+						{Value: "_default", ID: "end"},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := taskengine.Validate(context.Background(), chain, nil)
+	require.NoError(t, err)
+	require.True(t, result.Valid, "%+v", result.Issues)
+}
+
+func TestValidate_ParallelBranches(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID: "fanout",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "early",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "{{ .summary }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "fanout"}},
+				},
+			},
+			{
+				ID:   "fanout",
+				Type: taskengine.Parallel,
+				Parallel: &taskengine.ParallelConfig{
+					Join:   taskengine.JoinQuorum,
+					Quorum: 3,
+					Branches: []taskengine.ChainTask{
+						{ID: "summary", Type: taskengine.PromptToString, PromptTemplate: "{{ .input }}"},
+						{ID: "early", Type: taskengine.Hook},
+					},
+				},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "merge"}},
+				},
+			},
+			{
+				ID:             "merge",
+				Type:           taskengine.PromptToString,
+				PromptTemplate: "{{ .summary }}",
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+
+	result, err := taskengine.Validate(context.Background(), chain, nil)
+	require.NoError(t, err)
+	require.False(t, result.Valid)
+
+	messages := []string{}
+	for _, issue := range result.Issues {
+		messages = append(messages, issue.TaskID+": "+issue.Message)
+	}
+	require.ElementsMatch(t, []string{
+		`early: prompt_template references task "summary" which never runs before it`,
+		`fanout: branch id "early" is already in use`,
+		`fanout: quorum must be between 1 and 2, got 3`,
+		`fanout: branch early: hook task missing hook definition`,
+	}, messages)
+}
+
+func TestValidate_ScheduleTriggers(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID: "digest",
+		Triggers: []taskengine.Trigger{
+			{Type: taskengine.TriggerSchedule, Pattern: "0 7 * * mon-fri", TimeZone: "Europe/Berlin"},
+			{Type: taskengine.TriggerSchedule, Pattern: "0 25 * * *"},
+			{Type: taskengine.TriggerSchedule, Pattern: "@daily", TimeZone: "Nowhere/Special"},
+		},
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:   "summarize",
+				Type: taskengine.PromptToString,
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+	result, err := taskengine.Validate(context.Background(), chain, nil)
+	require.NoError(t, err)
+	require.False(t, result.Valid)
+	require.Len(t, result.Issues, 2)
+	require.Contains(t, result.Issues[0].Message, "schedule trigger at index 1")
+	require.Contains(t, result.Issues[1].Message, "invalid time zone")
+}
+
+func TestValidate_GenerationOptions(t *testing.T) {
+	temperature := 2.5
+	topP := 0.0
+	chain := &taskengine.ChainDefinition{
+		ID: "draft",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:   "write",
+				Type: taskengine.PromptToString,
+				Generation: &taskengine.GenerationOptions{
+					Temperature: &temperature,
+					TopP:        &topP,
+					MaxTokens:   -1,
+				},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+	result, err := taskengine.Validate(context.Background(), chain, nil)
+	require.NoError(t, err)
+	require.False(t, result.Valid)
+	require.Len(t, result.Issues, 3)
+	require.Contains(t, result.Issues[0].Message, "temperature")
+	require.Contains(t, result.Issues[1].Message, "top_p")
+	require.Contains(t, result.Issues[2].Message, "max_tokens")
+}
+
+func TestValidate_CacheTTL(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID:    "eval",
+		Cache: &taskengine.CacheConfig{TTL: "soon"},
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:    "grade",
+				Type:  taskengine.PromptToScore,
+				Cache: &taskengine.CacheConfig{TTL: "-1h"},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+	result, err := taskengine.Validate(context.Background(), chain, nil)
+	require.NoError(t, err)
+	require.False(t, result.Valid)
+	require.Len(t, result.Issues, 2)
+	require.Contains(t, result.Issues[0].Message, `invalid cache ttl "soon"`)
+	require.Contains(t, result.Issues[1].Message, `cache ttl "-1h" must be positive`)
+}
+
+func TestValidate_ChatTasks(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID: "support",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:   "greet",
+				Type: taskengine.Chat,
+				Chat: &taskengine.ChatConfig{
+					Messages: []taskengine.ChatMessage{
+						{Role: "system", Content: "Answer in {{ .lang }}."},
+						{Role: "robot", Content: "beep"},
+					},
+				},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "empty"}},
+				},
+			},
+			{
+				ID:   "empty",
+				Type: taskengine.Chat,
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+	result, err := taskengine.Validate(context.Background(), chain, nil)
+	require.NoError(t, err)
+	require.False(t, result.Valid)
+	require.Len(t, result.Issues, 3)
+	require.Contains(t, result.Issues[0].Message, `unknown role "robot"`)
+	require.Contains(t, result.Issues[1].Message, "no messages and no prompt_template")
+	require.Contains(t, result.Issues[2].Message, `chat message 0 references unknown variable "lang"`)
+}
+
+func TestValidate_AgentTools(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID: "support",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:             "resolve",
+				Type:           taskengine.Agent,
+				PromptTemplate: "{{ .input }}",
+				Agent:          &taskengine.AgentConfig{Tools: []string{"mock", "shell"}, MaxIterations: -1},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+	result, err := taskengine.Validate(context.Background(), chain, taskengine.NewMockHookRegistry())
+	require.NoError(t, err)
+	require.False(t, result.Valid)
+	require.Len(t, result.Issues, 2)
+	require.Contains(t, result.Issues[0].Message, "max_iterations must not be negative")
+	require.Contains(t, result.Issues[1].Message, `agent tool "shell" is not supported`)
+}
+
+func TestValidate_HookArgs(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID: "crm",
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:   "sync",
+				Type: taskengine.Hook,
+				Hook: &taskengine.HookCall{
+					Type: "mock",
+					Args: map[string]string{
+						"headers": `{"Authorization": "Bearer {{ secret "crm_token" }}"}`,
+						"ticket":  "{{ .ticket }}",
+						"url":     "{{ .input",
+					},
+				},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+	result, err := taskengine.Validate(context.Background(), chain, nil)
+	require.NoError(t, err)
+	require.False(t, result.Valid)
+	require.Len(t, result.Issues, 2)
+	require.Contains(t, result.Issues[0].Message, `hook arg ticket references unknown variable "ticket"`)
+	require.Contains(t, result.Issues[1].Message, "hook arg url")
+}
+
+func TestValidate_RetryPolicy(t *testing.T) {
+	chain := &taskengine.ChainDefinition{
+		ID:    "eval",
+		Retry: &taskengine.RetryPolicy{Backoff: "soon", Jitter: 2},
+		Tasks: []taskengine.ChainTask{
+			{
+				ID:    "grade",
+				Type:  taskengine.PromptToScore,
+				Retry: &taskengine.RetryPolicy{MaxBackoff: "-1s", Multiplier: 0.5, RetryOn: []taskengine.ErrorClass{"network"}},
+				Transition: taskengine.Transition{
+					Next: []taskengine.ConditionalTransition{{Value: "_default", ID: "end"}},
+				},
+			},
+		},
+	}
+	result, err := taskengine.Validate(context.Background(), chain, nil)
+	require.NoError(t, err)
+	require.False(t, result.Valid)
+	var messages []string
+	for _, issue := range result.Issues {
+		messages = append(messages, issue.Message)
+	}
+	require.Len(t, messages, 5)
+	require.Contains(t, messages[0], `invalid retry backoff "soon"`)
+	require.Contains(t, messages[1], "retry jitter must be between 0 and 1")
+	require.Contains(t, messages[2], `retry max_backoff "-1s" must be positive`)
+	require.Contains(t, messages[3], "retry multiplier must be at least 1")
+	require.Contains(t, messages[4], `unknown retry error class "network"`)
+}
@@ -21,6 +21,10 @@ type Messenger interface {
 	// Stream streams messages (using channels) from the given subject.
 	Stream(ctx context.Context, subject string, ch chan<- []byte) (Subscription, error)
 
+	// QueueStream streams messages from the given subject like Stream, but each message is
+	// delivered to only one of the subscribers that share the queue name.
+	QueueStream(ctx context.Context, subject, queue string, ch chan<- []byte) (Subscription, error)
+
 	// Close cleans up any underlying resources.
 	Close() error
 }
@@ -41,6 +41,56 @@ func TestStream(t *testing.T) {
 	}
 }
 
+func TestQueueStream(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ps, cleanup, err := libbus.NewTestPubSub()
+	defer cleanup()
+	if err != nil {
+		t.Fatalf("failed to init test stream %s", err)
+	}
+
+	subject := "test.queue"
+	queue := "workers"
+
+	// Subscribe twice within the same queue.
+	firstCh := make(chan []byte, 10)
+	first, err := ps.QueueStream(ctx, subject, queue, firstCh)
+	require.NoError(t, err)
+	defer first.Unsubscribe()
+	secondCh := make(chan []byte, 10)
+	second, err := ps.QueueStream(ctx, subject, queue, secondCh)
+	require.NoError(t, err)
+	defer second.Unsubscribe()
+
+	const count = 10
+	for range count {
+		err = ps.Publish(ctx, subject, []byte("queued message"))
+		require.NoError(t, err)
+	}
+
+	// Every message is delivered to exactly one subscriber.
+	received := 0
+	for received < count {
+		select {
+		case <-firstCh:
+			received++
+		case <-secondCh:
+			received++
+		case <-ctx.Done():
+			t.Fatalf("timed out after %d of %d queued messages", received, count)
+		}
+	}
+	select {
+	case <-firstCh:
+		t.Fatal("message delivered twice")
+	case <-secondCh:
+		t.Fatal("message delivered twice")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
 func TestPublishWithClosedConnection(t *testing.T) {
 	ctx := context.Background()
 
@@ -81,6 +81,10 @@ func (p *ps) Stream(ctx context.Context, subject string, ch chan<- []byte) (Subs
 	return p.stream(ctx, subject, "", ch)
 }
 
+func (p *ps) QueueStream(ctx context.Context, subject, queue string, ch chan<- []byte) (Subscription, error) {
+	return p.stream(ctx, subject, queue, ch)
+}
+
 func (p *ps) stream(ctx context.Context, subject, queue string, ch chan<- []byte) (Subscription, error) {
 	if p.nc == nil || p.nc.IsClosed() {
 		return nil, ErrConnectionClosed
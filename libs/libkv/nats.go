@@ -3,6 +3,7 @@ package libkv
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/nats-io/nats.go"
 )
@@ -16,11 +17,38 @@ type natsKVManager struct {
 	bucket nats.KeyValue
 }
 
+// NatsOption configures a KVManager created by NewNatsKVManager.
+type NatsOption func(*natsOptions)
+
+type natsOptions struct {
+	connect []nats.Option
+	ttl     time.Duration
+}
+
+// WithNatsUserInfo authenticates the connection with user and password.
+func WithNatsUserInfo(user, password string) NatsOption {
+	return func(o *natsOptions) {
+		o.connect = append(o.connect, nats.UserInfo(user, password))
+	}
+}
+
+// WithBucketTTL makes a created bucket expire every value ttl after it was last set.
+// It has no effect on buckets that already exist.
+func WithBucketTTL(ttl time.Duration) NatsOption {
+	return func(o *natsOptions) {
+		o.ttl = ttl
+	}
+}
+
 // NewNatsKVManager creates a new KVManager backed by a NATS JetStream Key-Value store.
 // 'url' is the NATS server address and 'bucketName' is the name of the KV bucket.
-func NewNatsKVManager(url, bucketName string, create bool) (KVManager, error) {
+func NewNatsKVManager(url, bucketName string, create bool, opts ...NatsOption) (KVManager, error) {
+	options := &natsOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
 	// Connect to NATS.
-	nc, err := nats.Connect(url)
+	nc, err := nats.Connect(url, options.connect...)
 	if err != nil {
 		return nil, err
 	}
@@ -37,6 +65,7 @@ func NewNatsKVManager(url, bucketName string, create bool) (KVManager, error) {
 		// Try to create the bucket.
 		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
 			Bucket: bucketName,
+			TTL:    options.ttl,
 		})
 		if err != nil {
 			// If the bucket already exists, open it.